@@ -0,0 +1,144 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/containerd/containerd/v2/contrib/apparmor"
+	"github.com/containerd/containerd/v2/defaults"
+	aasupport "github.com/containerd/containerd/v2/pkg/apparmor"
+	"github.com/containerd/containerd/v2/pkg/identifiers"
+	"github.com/containerd/errdefs"
+)
+
+// apparmorStateComponent is the directory under the containerd root
+// where loaded profile sources are tracked; the apparmor plugin re-loads
+// them at daemon start.
+const apparmorStateComponent = "io.containerd.internal.v1.apparmor"
+
+var apparmorProfilesBucket = []byte("profiles")
+
+// AppArmorStateDB returns the bolt database path tracking loaded
+// profiles under the given containerd root.
+func AppArmorStateDB(root string) string {
+	return filepath.Join(root, apparmorStateComponent, "profiles.db")
+}
+
+// withAppArmorState opens the profile tracking database for the duration
+// of fn. The daemon's apparmor plugin only holds the database during
+// startup, so a short lock timeout suffices.
+func (c *Client) withAppArmorState(writable bool, fn func(tx *bolt.Tx) error) error {
+	root := c.apparmorRoot
+	if root == "" {
+		root = defaults.DefaultRootDir
+	}
+	path := AppArmorStateDB(root)
+	if err := os.MkdirAll(filepath.Dir(path), 0711); err != nil {
+		return err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return fmt.Errorf("opening apparmor profile state: %w", err)
+	}
+	defer db.Close()
+	if writable {
+		return db.Update(fn)
+	}
+	return db.View(fn)
+}
+
+// LoadAppArmorProfile loads the raw profile text into the kernel under
+// the given name and records it so the apparmor plugin re-loads it when
+// the daemon starts. The profile applies to the host the client runs on;
+// on hosts without AppArmor, ErrNotImplemented is returned.
+func (c *Client) LoadAppArmorProfile(ctx context.Context, name, profile string) error {
+	if !aasupport.HostSupports() {
+		return fmt.Errorf("AppArmor is not enabled on this host: %w", errdefs.ErrNotImplemented)
+	}
+	if err := identifiers.Validate(name); err != nil {
+		return fmt.Errorf("profile name: %w", err)
+	}
+	if err := apparmor.LoadProfile(profile); err != nil {
+		return err
+	}
+	loaded, err := apparmor.IsLoaded(name)
+	if err == nil && !loaded {
+		return fmt.Errorf("profile text does not define a profile named %q: %w", name, errdefs.ErrInvalidArgument)
+	}
+	return c.withAppArmorState(true, func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(apparmorProfilesBucket)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(name), []byte(profile))
+	})
+}
+
+// UnloadAppArmorProfile removes the named profile from the kernel and
+// from the tracked profiles. Unloading a profile that is not tracked or
+// not loaded is not an error.
+func (c *Client) UnloadAppArmorProfile(ctx context.Context, name string) error {
+	if !aasupport.HostSupports() {
+		return fmt.Errorf("AppArmor is not enabled on this host: %w", errdefs.ErrNotImplemented)
+	}
+	if err := identifiers.Validate(name); err != nil {
+		return fmt.Errorf("profile name: %w", err)
+	}
+	if loaded, err := apparmor.IsLoaded(name); err != nil {
+		return err
+	} else if loaded {
+		if err := apparmor.UnloadProfile(name); err != nil {
+			return err
+		}
+	}
+	return c.withAppArmorState(true, func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(apparmorProfilesBucket)
+		if bkt == nil {
+			return nil
+		}
+		return bkt.Delete([]byte(name))
+	})
+}
+
+// ListAppArmorProfiles returns the names of the tracked, currently
+// loaded profiles.
+func (c *Client) ListAppArmorProfiles(ctx context.Context) ([]string, error) {
+	if !aasupport.HostSupports() {
+		return nil, fmt.Errorf("AppArmor is not enabled on this host: %w", errdefs.ErrNotImplemented)
+	}
+	var names []string
+	if err := c.withAppArmorState(false, func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(apparmorProfilesBucket)
+		if bkt == nil {
+			return nil
+		}
+		return bkt.ForEach(func(k, v []byte) error {
+			names = append(names, string(k))
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
@@ -0,0 +1,130 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/containerd/errdefs"
+	"github.com/opencontainers/runtime-spec/specs-go/features"
+)
+
+// ShimCapabilities describes what the shim binary behind a runtime
+// supports, negotiated through the shim's runtime info instead of
+// discovering missing features as Unimplemented errors at rpc time.
+type ShimCapabilities struct {
+	// SupportedFeatures advertised by the shim, such as "idmap-mounts",
+	// "seccomp" or "checkpoint".
+	SupportedFeatures []string
+	// RuntimeVersion of the shim's runtime binary.
+	RuntimeVersion string
+}
+
+// Supports reports whether the shim advertises the feature.
+func (s *ShimCapabilities) Supports(feature string) bool {
+	return slices.Contains(s.SupportedFeatures, feature)
+}
+
+// GetCapabilities queries the capabilities of the shim backing the given
+// runtime, or the client's default runtime when empty. Results are cached
+// per runtime binary for the lifetime of the client since a binary's
+// capabilities do not change while it is installed.
+func (c *Client) GetCapabilities(ctx context.Context, runtimePath string) (*ShimCapabilities, error) {
+	c.shimCapsMu.Lock()
+	if caps, ok := c.shimCaps[runtimePath]; ok {
+		c.shimCapsMu.Unlock()
+		return caps, nil
+	}
+	c.shimCapsMu.Unlock()
+
+	info, err := c.RuntimeInfo(ctx, runtimePath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &ShimCapabilities{
+		RuntimeVersion: info.Version.Version,
+	}
+	if f, ok := info.Features.(*features.Features); ok && f != nil {
+		caps.SupportedFeatures = featureList(f)
+	}
+	for k := range info.Annotations {
+		caps.SupportedFeatures = append(caps.SupportedFeatures, k)
+	}
+	slices.Sort(caps.SupportedFeatures)
+
+	c.shimCapsMu.Lock()
+	if c.shimCaps == nil {
+		c.shimCaps = map[string]*ShimCapabilities{}
+	}
+	c.shimCaps[runtimePath] = caps
+	c.shimCapsMu.Unlock()
+	return caps, nil
+}
+
+// RequireShimCapability fails with ErrNotImplemented when the shim for the
+// given runtime does not advertise the feature, so feature-specific code
+// paths can reject an operation before sending an rpc the shim cannot
+// handle.
+func (c *Client) RequireShimCapability(ctx context.Context, runtimePath, feature string) error {
+	caps, err := c.GetCapabilities(ctx, runtimePath)
+	if err != nil {
+		return err
+	}
+	if !caps.Supports(feature) {
+		return fmt.Errorf("shim does not support %q: %w", feature, errdefs.ErrNotImplemented)
+	}
+	return nil
+}
+
+// featureList flattens the runtime's feature document into capability
+// names.
+func featureList(f *features.Features) []string {
+	var list []string
+	if slices.Contains(f.MountOptions, "idmap") {
+		list = append(list, "idmap-mounts")
+	}
+	if f.Linux != nil {
+		if f.Linux.Seccomp != nil && f.Linux.Seccomp.Enabled != nil && *f.Linux.Seccomp.Enabled {
+			list = append(list, "seccomp")
+		}
+		if f.Linux.Apparmor != nil && f.Linux.Apparmor.Enabled != nil && *f.Linux.Apparmor.Enabled {
+			list = append(list, "apparmor")
+		}
+		if f.Linux.Selinux != nil && f.Linux.Selinux.Enabled != nil && *f.Linux.Selinux.Enabled {
+			list = append(list, "selinux")
+		}
+		if f.Linux.Cgroup != nil {
+			if f.Linux.Cgroup.V2 != nil && *f.Linux.Cgroup.V2 {
+				list = append(list, "cgroup-v2")
+			}
+			if f.Linux.Cgroup.Systemd != nil && *f.Linux.Cgroup.Systemd {
+				list = append(list, "cgroup-systemd")
+			}
+		}
+		if f.Linux.IntelRdt != nil && f.Linux.IntelRdt.Enabled != nil && *f.Linux.IntelRdt.Enabled {
+			list = append(list, "intel-rdt")
+		}
+	}
+	// runc based shims implement checkpoint/restore
+	if f.Linux != nil {
+		list = append(list, "checkpoint")
+	}
+	return list
+}
@@ -113,7 +113,9 @@ func New(address string, opts ...Opt) (*Client, error) {
 	}
 
 	c := &Client{
-		defaultns: copts.defaultns,
+		defaultns:        copts.defaultns,
+		snapshotUsageTTL: copts.snapshotUsageTTL,
+		apparmorRoot:     copts.apparmorRoot,
 	}
 
 	if copts.defaultRuntime != "" {
@@ -192,8 +194,10 @@ func NewWithConn(conn *grpc.ClientConn, opts ...Opt) (*Client, error) {
 		}
 	}
 	c := &Client{
-		defaultns: copts.defaultns,
-		conn:      conn,
+		defaultns:        copts.defaultns,
+		conn:             conn,
+		snapshotUsageTTL: copts.snapshotUsageTTL,
+		apparmorRoot:     copts.apparmorRoot,
 	}
 
 	if copts.defaultRuntime != "" {
@@ -232,6 +236,25 @@ type Client struct {
 		sandboxer string
 		mut       sync.Mutex
 	}
+
+	// snapshot usage cache for SnapshotUsageStats
+	snapshotUsageMu  sync.Mutex
+	snapshotUsage    map[string]snapshotUsageEntry
+	snapshotUsageTTL time.Duration
+	apparmorRoot     string
+
+	// node resource summary cache for ResourceSummary(WithCached())
+	resourceSummaryMu      sync.Mutex
+	resourceSummary        *NodeResourceSummary
+	resourceSummaryFetched time.Time
+
+	// releaseConn, when set, is called by Close instead of closing the
+	// connection, used by SharedClient handles to drop their reference.
+	releaseConn func() error
+
+	// shim capability cache for GetCapabilities
+	shimCapsMu sync.Mutex
+	shimCaps   map[string]*ShimCapabilities
 }
 
 // Reconnect re-establishes the GRPC connection to the containerd daemon
@@ -335,6 +358,15 @@ func (c *Client) Containers(ctx context.Context, filters ...string) ([]Container
 	return out, nil
 }
 
+// NewContainerFromBundle creates a new container backed by a pre-extracted
+// OCI bundle directory, reading the spec from the bundle's config.json and
+// mounting the bundle's rootfs directly. Nothing is ingested into the
+// content or snapshot stores and task creation skips snapshot preparation,
+// analogous to running the bundle with `runc run`.
+func (c *Client) NewContainerFromBundle(ctx context.Context, id, bundlePath string, opts ...NewContainerOpts) (Container, error) {
+	return c.NewContainer(ctx, id, append([]NewContainerOpts{WithSpecFromBundle(bundlePath)}, opts...)...)
+}
+
 // NewContainer will create a new container with the provided id.
 // The id must be unique within the namespace.
 func (c *Client) NewContainer(ctx context.Context, id string, opts ...NewContainerOpts) (Container, error) {
@@ -403,6 +435,10 @@ type RemoteContext struct {
 	// If no resolver is provided, defaults to Docker registry resolver.
 	Resolver remotes.Resolver
 
+	// mirrorSet backs the resolver installed by WithMirrors, kept so
+	// WithMirrorHealthCheck can attach to it.
+	mirrorSet *docker.MirrorSet
+
 	// PlatformMatcher is used to match the platforms for an image
 	// operation and define the preference when a single match is required
 	// from multiple platforms.
@@ -680,6 +716,12 @@ func (c *Client) Subscribe(ctx context.Context, filters ...string) (ch <-chan *e
 func (c *Client) Close() error {
 	c.connMu.Lock()
 	defer c.connMu.Unlock()
+	if c.releaseConn != nil {
+		release := c.releaseConn
+		// keep further Closes from touching the shared connection
+		c.releaseConn = func() error { return nil }
+		return release()
+	}
 	if c.conn != nil {
 		return c.conn.Close()
 	}
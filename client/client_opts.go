@@ -17,6 +17,8 @@
 package client
 
 import (
+	"context"
+	"fmt"
 	"maps"
 	"slices"
 	"time"
@@ -24,11 +26,16 @@ import (
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
 	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/internal/grpccompress"
+	"github.com/containerd/containerd/v2/pkg/logging"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/platforms"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 )
 
 type clientOpts struct {
@@ -41,6 +48,8 @@ type clientOpts struct {
 	extraDialOpts    []grpc.DialOption
 	callOptions      []grpc.CallOption
 	timeout          time.Duration
+	snapshotUsageTTL time.Duration
+	apparmorRoot     string
 }
 
 // Opt allows callers to set options on the containerd client
@@ -102,6 +111,73 @@ func WithExtraDialOpts(opts []grpc.DialOption) Opt {
 	}
 }
 
+const (
+	// DefaultKeepaliveTime is the keepalive ping interval used by
+	// WithKeepalive when no time is given. 30s passes through most
+	// corporate firewall idle timeouts.
+	DefaultKeepaliveTime = 30 * time.Second
+
+	// DefaultKeepaliveTimeout is how long WithKeepalive waits for a ping
+	// ack before considering the connection dead when no timeout is given.
+	DefaultKeepaliveTimeout = 10 * time.Second
+)
+
+// WithKeepalive configures gRPC keepalive pings on the client connection so
+// long-lived streams such as event subscriptions are not silently dropped
+// by NAT or firewalls that time out idle TCP connections. Zero durations
+// select the defaults of 30s keepalive time and 10s timeout.
+//
+// Keepalive pings sent more often than the server's enforcement policy
+// permits are answered with ENHANCE_YOUR_CALM and the connection is
+// closed; gRPC servers default to a 5 minute minimum, so lowering the time
+// below that requires the server to opt in, which containerd's daemon does
+// through its keepalive_min_time setting.
+func WithKeepalive(keepaliveTime, timeout time.Duration, permitWithoutStream bool) Opt {
+	return func(c *clientOpts) error {
+		if keepaliveTime == 0 {
+			keepaliveTime = DefaultKeepaliveTime
+		}
+		if timeout == 0 {
+			timeout = DefaultKeepaliveTimeout
+		}
+		c.extraDialOpts = append(c.extraDialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                keepaliveTime,
+			Timeout:             timeout,
+			PermitWithoutStream: permitWithoutStream,
+		}))
+		return nil
+	}
+}
+
+// WithTaskLogging emits a structured log entry (through log/slog, see
+// pkg/logging) for every task service call the client issues, carrying
+// the operation, task and container id, namespace, duration and error.
+// Levels follow the operation: debug for metric polls, info for lifecycle
+// changes, warn for kills and failures.
+func WithTaskLogging() Opt {
+	return func(c *clientOpts) error {
+		c.extraDialOpts = append(c.extraDialOpts, grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor()))
+		return nil
+	}
+}
+
+// WithGRPCCompression compresses all rpcs with the given algorithm, "gzip"
+// or "zstd". Compression trades CPU for bandwidth: it typically cuts
+// content metadata, task list and metrics payloads by half, which pays off
+// on remote TCP connections but rarely on a local unix socket (see the
+// benchmarks in internal/grpccompress). The daemon accepts both algorithms.
+func WithGRPCCompression(algo string) Opt {
+	return func(c *clientOpts) error {
+		switch algo {
+		case "gzip", grpccompress.ZstdName:
+		default:
+			return fmt.Errorf("unsupported grpc compression algorithm %q: %w", algo, errdefs.ErrInvalidArgument)
+		}
+		c.callOptions = append(c.callOptions, grpc.UseCompressor(algo))
+		return nil
+	}
+}
+
 // WithCallOpts allows grpc.CallOptions to be set on the connection
 func WithCallOpts(opts []grpc.CallOption) Opt {
 	return func(c *clientOpts) error {
@@ -121,6 +197,26 @@ func WithServices(opts ...ServicesOpt) Opt {
 	}
 }
 
+// WithSnapshotUsageTTL sets how long SnapshotUsageStats results are cached
+// before the snapshotters are walked again. The default is 30s.
+func WithSnapshotUsageTTL(d time.Duration) Opt {
+	return func(c *clientOpts) error {
+		c.snapshotUsageTTL = d
+		return nil
+	}
+}
+
+// WithAppArmorStateRoot sets the containerd root directory under which
+// AppArmor profile state is tracked, for daemons configured with a
+// non-default root. The default is the platform's default containerd
+// root.
+func WithAppArmorStateRoot(root string) Opt {
+	return func(c *clientOpts) error {
+		c.apparmorRoot = root
+		return nil
+	}
+}
+
 // WithTimeout sets the connection timeout for the client
 func WithTimeout(d time.Duration) Opt {
 	return func(c *clientOpts) error {
@@ -219,6 +315,54 @@ func WithChildLabelMap(fn func(ocispec.Descriptor) []string) RemoteOpt {
 	}
 }
 
+// WithMirrors consults the given mirror base URLs, in order, before the
+// origin registry when pulling: the resolver tries each mirror and falls
+// back to the next (and finally the origin) on errors, preserving the
+// image reference's repository path against every mirror. Pushes bypass
+// the mirrors. Combine with WithMirrorHealthCheck to drop unresponsive
+// mirrors from the rotation. The option replaces the pull's resolver, so
+// apply it before options that wrap the resolver.
+func WithMirrors(mirrors []string) RemoteOpt {
+	return func(_ *Client, c *RemoteContext) error {
+		set, err := docker.NewMirrorSet(mirrors)
+		if err != nil {
+			return err
+		}
+		c.mirrorSet = set
+		c.Resolver = docker.NewResolver(docker.ResolverOptions{
+			Hosts: set.Hosts(),
+		})
+		return nil
+	}
+}
+
+// WithMirrorHealthCheck periodically probes the mirrors configured with
+// WithMirrors and removes unhealthy ones from the rotation until they
+// recover. The checker runs for the remaining lifetime of the process.
+// Must be applied after WithMirrors.
+func WithMirrorHealthCheck(interval time.Duration) RemoteOpt {
+	return func(_ *Client, c *RemoteContext) error {
+		if c.mirrorSet == nil {
+			return fmt.Errorf("WithMirrorHealthCheck requires WithMirrors to be applied first: %w", errdefs.ErrInvalidArgument)
+		}
+		c.mirrorSet.StartHealthCheck(context.Background(), interval)
+		return nil
+	}
+}
+
+// WithPullRateLimit throttles layer fetches against the given registry
+// hostname to rps requests per second with the given burst, and retries
+// 429 responses internally with exponential backoff instead of failing the
+// pull; the caller's context deadline stays the outer bound. Apply the
+// option after WithResolver when a custom resolver is used, so the custom
+// resolver is the one being wrapped.
+func WithPullRateLimit(registry string, rps float64, burst int) RemoteOpt {
+	return func(_ *Client, c *RemoteContext) error {
+		c.Resolver = docker.NewRateLimitedResolver(c.Resolver, registry, rps, burst)
+		return nil
+	}
+}
+
 // WithResolver specifies the resolver to use.
 func WithResolver(resolver remotes.Resolver) RemoteOpt {
 	return func(client *Client, c *RemoteContext) error {
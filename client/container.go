@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/containerd/containerd/api/services/tasks/v1"
@@ -261,6 +262,21 @@ func (c *container) NewTask(ctx context.Context, ioCreate cio.Creator, opts ...N
 			return nil, err
 		}
 	}
+	if len(info.specOpts) > 0 {
+		var spec oci.Spec
+		if err := typeurl.UnmarshalTo(r.Spec, &spec); err != nil {
+			return nil, err
+		}
+		if err := oci.ApplyOpts(ctx, c.client, &r, &spec, info.specOpts...); err != nil {
+			return nil, err
+		}
+		if r.Spec, err = typeurl.MarshalAnyToProto(&spec); err != nil {
+			return nil, err
+		}
+		if r, err = c.client.ContainerService().Update(ctx, r, "spec"); err != nil {
+			return nil, errgrpc.ToNative(err)
+		}
+	}
 	for _, m := range info.RootFS {
 		request.Rootfs = append(request.Rootfs, &types.Mount{
 			Type:    m.Type,
@@ -269,6 +285,13 @@ func (c *container) NewTask(ctx context.Context, ioCreate cio.Creator, opts ...N
 			Options: m.Options,
 		})
 	}
+	if info.readOnlyRootFS {
+		for _, m := range request.Rootfs {
+			if !slices.Contains(m.Options, "ro") {
+				m.Options = append(m.Options, "ro")
+			}
+		}
+	}
 	request.RuntimePath = info.RuntimePath
 	request.TaskApiAddress = info.taskAPIAddress
 	request.TaskApiVersion = info.taskAPIVersion
@@ -279,11 +302,17 @@ func (c *container) NewTask(ctx context.Context, ioCreate cio.Creator, opts ...N
 		}
 		request.Options = typeurl.MarshalProto(o)
 	}
+	timeout := info.creationTimeout
+	if timeout == 0 {
+		timeout = defaultCreationTimeout
+	}
 	t := &task{
-		client: c.client,
-		io:     i,
-		id:     c.id,
-		c:      c,
+		client:          c.client,
+		io:              i,
+		id:              c.id,
+		c:               c,
+		creationTimeout: timeout,
+		firstByteTimer:  info.firstByteTimer,
 	}
 	if info.Checkpoint != nil {
 		request.Checkpoint = info.Checkpoint
@@ -294,9 +323,22 @@ func (c *container) NewTask(ctx context.Context, ioCreate cio.Creator, opts ...N
 		tracing.Attribute("task.request.options", request.Options.String()),
 		tracing.Attribute("task.runtime.name", info.runtime),
 	)
-	response, err := c.client.TaskService().Create(ctx, request)
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	response, err := c.client.TaskService().Create(cctx, request)
 	if err != nil {
-		return nil, errgrpc.ToNative(err)
+		err = errgrpc.ToNative(err)
+		if errdefs.IsDeadlineExceeded(err) && cctx.Err() != nil && ctx.Err() == nil {
+			// The task is stuck being created, clean up any partial
+			// state before reporting the timeout.
+			dctx, dcancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+			defer dcancel()
+			c.client.TaskService().Delete(dctx, &tasks.DeleteTaskRequest{
+				ContainerID: c.id,
+			})
+			return nil, fmt.Errorf("task creation timed out after %v: %w", timeout, context.DeadlineExceeded)
+		}
+		return nil, err
 	}
 
 	span.AddEvent("task created",
@@ -22,6 +22,8 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"os"
+	"path/filepath"
 
 	"github.com/containerd/containerd/v2/core/containers"
 	"github.com/containerd/containerd/v2/core/content"
@@ -35,6 +37,7 @@ import (
 	"github.com/containerd/typeurl/v2"
 	"github.com/opencontainers/image-spec/identity"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
 
 // DeleteOpts allows the caller to set options for the deletion of a container
@@ -86,6 +89,28 @@ func WithSandbox(sandboxID string) NewContainerOpts {
 	}
 }
 
+// SandboxIDLabel carries the sandbox a container belongs to, mirroring
+// the metadata relationship for label-based tooling.
+const SandboxIDLabel = "containerd.io/sandbox.id"
+
+// WithSandboxID associates the container with the sandbox like
+// WithSandbox and additionally records the ID in the SandboxIDLabel
+// label, making the membership queryable with either mechanism. The
+// association is read back with Task.Sandbox and
+// Client.ListContainersBySandbox.
+func WithSandboxID(sandboxID string) NewContainerOpts {
+	return func(ctx context.Context, client *Client, c *containers.Container) error {
+		if err := WithSandbox(sandboxID)(ctx, client, c); err != nil {
+			return err
+		}
+		if c.Labels == nil {
+			c.Labels = make(map[string]string)
+		}
+		c.Labels[SandboxIDLabel] = sandboxID
+		return nil
+	}
+}
+
 // WithImage sets the provided image as the base for the container
 func WithImage(i Image) NewContainerOpts {
 	return func(ctx context.Context, client *Client, c *containers.Container) error {
@@ -327,6 +352,48 @@ func WithNewSpec(opts ...oci.SpecOpts) NewContainerOpts {
 	}
 }
 
+// WithSpecFromBundle sets the container's spec from the config.json of a
+// pre-extracted OCI bundle directory and points the spec's root at the
+// bundle's rootfs. The bundle is used in place: nothing is ingested into the
+// content or snapshot stores and task creation mounts the rootfs directly.
+func WithSpecFromBundle(bundlePath string, opts ...oci.SpecOpts) NewContainerOpts {
+	return func(ctx context.Context, client *Client, c *containers.Container) error {
+		p, err := filepath.Abs(bundlePath)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filepath.Join(p, "config.json"))
+		if err != nil {
+			return fmt.Errorf("failed to read bundle config: %w", err)
+		}
+		var s oci.Spec
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("failed to parse bundle config: %w", err)
+		}
+		if s.Root == nil {
+			s.Root = &specs.Root{}
+		}
+		// The OCI runtime spec resolves a relative root path against the
+		// bundle directory, the daemon writes its own bundle so the path
+		// must be made absolute here.
+		rootfs := s.Root.Path
+		if rootfs == "" {
+			rootfs = "rootfs"
+		}
+		if !filepath.IsAbs(rootfs) {
+			rootfs = filepath.Join(p, rootfs)
+		}
+		s.Root.Path = rootfs
+
+		if err := oci.ApplyOpts(ctx, client, c, &s, opts...); err != nil {
+			return err
+		}
+
+		c.Spec, err = typeurl.MarshalAnyToProto(&s)
+		return err
+	}
+}
+
 // WithSpec sets the provided spec on the container
 func WithSpec(s *oci.Spec, opts ...oci.SpecOpts) NewContainerOpts {
 	return func(ctx context.Context, client *Client, c *containers.Container) error {
@@ -20,6 +20,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -27,13 +28,75 @@ import (
 
 	"github.com/containerd/containerd/v2/core/containers"
 	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/core/snapshots"
 	"github.com/containerd/containerd/v2/internal/userns"
+	"github.com/containerd/containerd/v2/pkg/oci"
 
 	"github.com/containerd/errdefs"
+	"github.com/containerd/typeurl/v2"
 	"github.com/opencontainers/image-spec/identity"
 	"github.com/opencontainers/runtime-spec/specs-go"
 )
 
+// WithSharedNetworkNamespace configures the container to share the network
+// namespace of the container with the given id, mirroring the pod network
+// model: the source task's network namespace at /proc/<pid>/ns/net replaces
+// the container's own and is bind mounted into place by the runtime. The
+// source container must have a running task when the option is applied.
+func WithSharedNetworkNamespace(srcContainerID string) NewContainerOpts {
+	return func(ctx context.Context, client *Client, c *containers.Container) error {
+		if c.Spec == nil {
+			return fmt.Errorf("spec must be set before sharing a network namespace: %w", errdefs.ErrFailedPrecondition)
+		}
+		src, err := client.LoadContainer(ctx, srcContainerID)
+		if err != nil {
+			return err
+		}
+		task, err := src.Task(ctx, nil)
+		if err != nil {
+			return err
+		}
+		pid := task.Pid()
+		if pid == 0 {
+			return fmt.Errorf("task for container %q has no pid to share a network namespace with: %w", srcContainerID, errdefs.ErrFailedPrecondition)
+		}
+
+		var s oci.Spec
+		if err := json.Unmarshal(c.Spec.GetValue(), &s); err != nil {
+			return err
+		}
+		if err := oci.WithLinuxNamespace(specs.LinuxNamespace{
+			Type: specs.NetworkNamespace,
+			Path: fmt.Sprintf("/proc/%d/ns/net", pid),
+		})(ctx, client, c, &s); err != nil {
+			return err
+		}
+		c.Spec, err = typeurl.MarshalAnyToProto(&s)
+		return err
+	}
+}
+
+// WithVolatileSnapshot marks the container's snapshot volatile: the overlay
+// snapshotter mounts it with the volatile option, skipping sync on the
+// upperdir. Suitable for scratch containers whose data can be thrown away
+// after a crash, not for anything that must survive one. The option must be
+// applied after the snapshot has been set up (e.g. by WithNewSnapshot) and
+// is rejected when the kernel does not support volatile overlay mounts.
+func WithVolatileSnapshot(ctx context.Context, client *Client, c *containers.Container) error {
+	if c.SnapshotKey == "" {
+		return fmt.Errorf("container has no snapshot to mark volatile: %w", errdefs.ErrFailedPrecondition)
+	}
+	s, err := client.getSnapshotter(ctx, c.Snapshotter)
+	if err != nil {
+		return err
+	}
+	_, err = s.Update(ctx, snapshots.Info{
+		Name:   c.SnapshotKey,
+		Labels: map[string]string{"containerd.io/snapshot/overlay.volatile": "true"},
+	}, "labels.containerd.io/snapshot/overlay.volatile")
+	return err
+}
+
 // WithRemappedSnapshot creates a new snapshot and remaps the uid/gid for the
 // filesystem to be used by a container with user namespaces
 func WithRemappedSnapshot(id string, i Image, uid, gid uint32) NewContainerOpts {
@@ -60,6 +123,53 @@ func WithUserNSRemappedSnapshotView(id string, i Image, uidmaps, gidmaps []specs
 	return withRemappedSnapshotBase(id, i, uidmaps, gidmaps, true)
 }
 
+// WithIDMappedSnapshot creates a new snapshot for the container carrying
+// uid/gid mapping labels, so a snapshotter with idmapped mount support
+// (overlay on recent kernels) attaches idmapped mounts to the shared lower
+// layers. Unlike WithRemappedSnapshot no chowned copy of the layer chain is
+// made, letting differently-mapped containers share the same layers.
+func WithIDMappedSnapshot(id string, i Image, uidmaps, gidmaps []specs.LinuxIDMapping) NewContainerOpts {
+	return withIDMappedSnapshotBase(id, i, uidmaps, gidmaps, false)
+}
+
+// WithIDMappedSnapshotView is similar to WithIDMappedSnapshot but the rootfs
+// is mounted read-only.
+func WithIDMappedSnapshotView(id string, i Image, uidmaps, gidmaps []specs.LinuxIDMapping) NewContainerOpts {
+	return withIDMappedSnapshotBase(id, i, uidmaps, gidmaps, true)
+}
+
+func withIDMappedSnapshotBase(id string, i Image, uidmaps, gidmaps []specs.LinuxIDMapping, readonly bool) NewContainerOpts {
+	return func(ctx context.Context, client *Client, c *containers.Container) error {
+		diffIDs, err := i.RootFS(ctx)
+		if err != nil {
+			return err
+		}
+
+		c.Snapshotter, err = client.resolveSnapshotterName(ctx, c.Snapshotter)
+		if err != nil {
+			return err
+		}
+		snapshotter, err := client.getSnapshotter(ctx, c.Snapshotter)
+		if err != nil {
+			return err
+		}
+
+		parent := identity.ChainID(diffIDs).String()
+		labels := WithUserNSRemapperLabels(uidmaps, gidmaps)
+		if readonly {
+			_, err = snapshotter.View(ctx, id, parent, labels)
+		} else {
+			_, err = snapshotter.Prepare(ctx, id, parent, labels)
+		}
+		if err != nil {
+			return err
+		}
+		c.SnapshotKey = id
+		c.Image = i.Name()
+		return nil
+	}
+}
+
 func withRemappedSnapshotBase(id string, i Image, uidmaps, gidmaps []specs.LinuxIDMapping, readonly bool) NewContainerOpts {
 	return func(ctx context.Context, client *Client, c *containers.Container) error {
 		diffIDs, err := i.(*image).i.RootFS(ctx, client.ContentStore(), client.platform)
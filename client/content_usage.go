@@ -0,0 +1,155 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/containerd/v2/plugins"
+)
+
+// ContentRef names one holder of a reference to a blob.
+type ContentRef struct {
+	// Namespace holding the reference
+	Namespace string
+	// ReferenceType is "image", "snapshot" or "lease"
+	ReferenceType string
+	// ReferenceID names the referencing object: the image name, the
+	// snapshotter/key pair, or the lease id
+	ReferenceID string
+}
+
+// ContentUsageByDigest reports which namespaces hold references to the
+// blob, the inverse of the garbage collector's mark phase: the blob can be
+// collected once every reported reference is removed. Images are matched
+// by walking their manifest trees, snapshots through their content
+// reference labels, and leases through their leased resources.
+func (c *Client) ContentUsageByDigest(ctx context.Context, dgst digest.Digest) ([]ContentRef, error) {
+	nsList, err := c.NamespaceService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.IntrospectionService().Plugins(ctx, fmt.Sprintf("type==%q", plugins.SnapshotPlugin))
+	if err != nil {
+		return nil, err
+	}
+	var snapshotters []string
+	for _, p := range resp.Plugins {
+		if p.InitErr == nil {
+			snapshotters = append(snapshotters, p.ID)
+		}
+	}
+
+	var refs []ContentRef
+	for _, ns := range nsList {
+		nsctx := namespaces.WithNamespace(ctx, ns)
+
+		imgs, err := c.ImageService().List(nsctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range imgs {
+			ok, err := c.imageReferences(nsctx, img.Target, dgst)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				refs = append(refs, ContentRef{Namespace: ns, ReferenceType: "image", ReferenceID: img.Name})
+			}
+		}
+
+		for _, name := range snapshotters {
+			sn := c.SnapshotService(name)
+			if err := sn.Walk(nsctx, func(_ context.Context, info snapshots.Info) error {
+				for k, v := range info.Labels {
+					if strings.HasPrefix(k, "containerd.io/gc.ref.content") && v == dgst.String() {
+						refs = append(refs, ContentRef{
+							Namespace:     ns,
+							ReferenceType: "snapshot",
+							ReferenceID:   name + "/" + info.Name,
+						})
+						break
+					}
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+
+		ls := c.LeasesService()
+		leaseList, err := ls.List(nsctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, lease := range leaseList {
+			resources, err := ls.ListResources(nsctx, lease)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range resources {
+				if r.Type == "content" && r.ID == dgst.String() {
+					refs = append(refs, ContentRef{Namespace: ns, ReferenceType: "lease", ReferenceID: lease.ID})
+					break
+				}
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+// imageReferences reports whether the manifest tree rooted at target
+// contains dgst. Blobs missing from the content store are skipped, matching
+// how the garbage collector treats incomplete trees.
+func (c *Client) imageReferences(ctx context.Context, target ocispec.Descriptor, dgst digest.Digest) (bool, error) {
+	var (
+		queue = []ocispec.Descriptor{target}
+		seen  = map[digest.Digest]struct{}{}
+	)
+	for len(queue) > 0 {
+		desc := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[desc.Digest]; ok {
+			continue
+		}
+		seen[desc.Digest] = struct{}{}
+		if desc.Digest == dgst {
+			return true, nil
+		}
+
+		children, err := images.Children(ctx, c.ContentStore(), desc)
+		if err != nil {
+			if errdefs.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		queue = append(queue, children...)
+	}
+	return false, nil
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/containerd/errdefs"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/core/snapshots"
+)
+
+// ephemeralLabel marks a container (and its snapshot) as single-use:
+// deleting its task also deletes the snapshot and the container record.
+const ephemeralLabel = "containerd.io/gc.ephemeral"
+
+// WithEphemeralSnapshot marks the container as single-use: when its task is
+// deleted, the snapshot and the container record are deleted with it,
+// removing the cleanup boilerplate of one-shot workloads. The option must
+// be applied after the snapshot has been set up (e.g. by WithNewSnapshot).
+func WithEphemeralSnapshot() NewContainerOpts {
+	return func(ctx context.Context, client *Client, c *containers.Container) error {
+		if c.SnapshotKey == "" {
+			return fmt.Errorf("container has no snapshot to mark ephemeral: %w", errdefs.ErrFailedPrecondition)
+		}
+		s, err := client.getSnapshotter(ctx, c.Snapshotter)
+		if err != nil {
+			return err
+		}
+		if _, err := snapshots.UpdateLabel(ctx, s, c.SnapshotKey, ephemeralLabel, "true"); err != nil {
+			return err
+		}
+		if c.Labels == nil {
+			c.Labels = map[string]string{}
+		}
+		c.Labels[ephemeralLabel] = "true"
+		return nil
+	}
+}
+
+// PreserveLogs captures the task's output before an ephemeral deletion
+// tears it down: when the task's IO was set up against log files (for
+// example cio.LogFile), their final contents are copied to w. FIFO-based
+// IO has already been streamed to its consumer and yields nothing here.
+func PreserveLogs(w io.Writer) ProcessDeleteOpts {
+	return func(ctx context.Context, p Process) error {
+		ioer := p.IO()
+		if ioer == nil {
+			return nil
+		}
+		config := ioer.Config()
+		for _, target := range []string{config.Stdout, config.Stderr} {
+			path := logFilePath(target)
+			if path == "" {
+				continue
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			_, err = io.Copy(w, f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("failed to preserve task logs from %s: %w", path, err)
+			}
+		}
+		return nil
+	}
+}
+
+// logFilePath resolves an IO target to a local file path, handling both
+// bare paths and file:// URIs; fifos and binary:// targets yield nothing.
+func logFilePath(target string) string {
+	if target == "" {
+		return ""
+	}
+	if u, err := url.Parse(target); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	fi, err := os.Stat(target)
+	if err != nil || !fi.Mode().IsRegular() {
+		return ""
+	}
+	return target
+}
@@ -0,0 +1,90 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/events"
+	"github.com/containerd/containerd/v2/core/events/journal"
+)
+
+// EventPosition selects where SubscribeWithReplay starts: at a journal
+// sequence when Sequence is non-zero, otherwise at a timestamp.
+type EventPosition struct {
+	Sequence uint64
+	Time     time.Time
+}
+
+// EventWithReplay is an envelope plus whether it came from the journal
+// rather than live delivery.
+type EventWithReplay struct {
+	Envelope *events.Envelope
+	Replayed bool
+}
+
+// SubscribeWithReplay subscribes like Subscribe but first replays
+// journaled events from the given position, marking them Replayed. The
+// daemon must have the event journal plugin enabled; replay is
+// at-least-once around the replay/live boundary, so consumers tracking
+// exactly-once semantics should deduplicate on (namespace, topic,
+// timestamp). A position that has already been pruned fails the
+// subscription with ErrOutOfRange.
+func (c *Client) SubscribeWithReplay(ctx context.Context, from EventPosition, filters ...string) (<-chan EventWithReplay, <-chan error) {
+	fs := slices.Clone(filters)
+	if from.Sequence > 0 {
+		fs = append(fs, fmt.Sprintf(`from=="%d"`, from.Sequence))
+	} else {
+		fs = append(fs, fmt.Sprintf(`from=="%s"`, from.Time.UTC().Format(time.RFC3339Nano)))
+	}
+
+	var (
+		out  = make(chan EventWithReplay)
+		errq = make(chan error, 1)
+	)
+	ch, errs := c.EventService().Subscribe(ctx, fs...)
+	go func() {
+		defer close(out)
+		replaying := true
+		for {
+			select {
+			case envelope, ok := <-ch:
+				if !ok {
+					return
+				}
+				if replaying && envelope.Topic == journal.ReplayEndTopic {
+					replaying = false
+					continue
+				}
+				select {
+				case out <- EventWithReplay{Envelope: envelope, Replayed: replaying}:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-errs:
+				errq <- err
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, errq
+}
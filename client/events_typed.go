@@ -0,0 +1,214 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/containerd/typeurl/v2"
+
+	"github.com/containerd/containerd/v2/core/events"
+)
+
+// defaultReconnectBackoff is how long SubscribeTyped waits before
+// resubscribing after the event stream fails.
+const defaultReconnectBackoff = time.Second
+
+// TypedEvent is one decoded event from SubscribeTyped.
+type TypedEvent struct {
+	Timestamp time.Time
+	Namespace string
+	Topic     string
+	// Event is the decoded event (e.g. *events.TaskExit from the api
+	// events package); for types without a registered decoder it is the
+	// raw typeurl.Any.
+	Event any
+	// Replayed is set on events recovered from the daemon's journal
+	// after a reconnect.
+	Replayed bool
+}
+
+type typedSubscription struct {
+	filters []string
+	types   map[string]struct{}
+	backoff time.Duration
+}
+
+// SubscribeTypedOpt configures SubscribeTyped.
+type SubscribeTypedOpt func(*typedSubscription) error
+
+// WithEventFilters restricts the subscription with the usual filter
+// strings (e.g. `topic~="^/tasks/"`).
+func WithEventFilters(filters ...string) SubscribeTypedOpt {
+	return func(s *typedSubscription) error {
+		s.filters = append(s.filters, filters...)
+		return nil
+	}
+}
+
+// WithEventTypes decodes only the given event types (pass values such as
+// &events.TaskExit{}); everything else passes through as raw Any.
+// Without this option every registered type is decoded.
+func WithEventTypes(evts ...events.Event) SubscribeTypedOpt {
+	return func(s *typedSubscription) error {
+		if s.types == nil {
+			s.types = map[string]struct{}{}
+		}
+		for _, e := range evts {
+			u, err := typeurl.TypeURL(e)
+			if err != nil {
+				return err
+			}
+			s.types[u] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// WithReconnectBackoff sets the delay between resubscription attempts.
+func WithReconnectBackoff(d time.Duration) SubscribeTypedOpt {
+	return func(s *typedSubscription) error {
+		s.backoff = d
+		return nil
+	}
+}
+
+// SubscribeTyped subscribes to events, decoding each envelope into its
+// concrete type, and keeps the subscription alive across daemon
+// restarts: after a stream failure it backs off and resubscribes, using
+// the last seen timestamp as a journal replay point when the daemon has
+// the event journal enabled (events recovered that way are marked
+// Replayed). Subscription errors are reported on the error channel but
+// do not end the stream; only cancelling the context does, which closes
+// the event channel.
+func (c *Client) SubscribeTyped(ctx context.Context, opts ...SubscribeTypedOpt) (<-chan TypedEvent, <-chan error) {
+	var (
+		out  = make(chan TypedEvent)
+		errq = make(chan error, 1)
+		sub  = typedSubscription{backoff: defaultReconnectBackoff}
+	)
+	for _, o := range opts {
+		if err := o(&sub); err != nil {
+			errq <- err
+			close(out)
+			return out, errq
+		}
+	}
+
+	reportErr := func(err error) {
+		select {
+		case errq <- err:
+		default:
+		}
+	}
+
+	go func() {
+		defer close(out)
+		var lastSeen time.Time
+		for {
+			if err := c.consumeOnce(ctx, &sub, lastSeen, &lastSeen, out); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				reportErr(err)
+			}
+			select {
+			case <-time.After(sub.backoff):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, errq
+}
+
+// consumeOnce runs one subscription attempt, forwarding decoded events
+// until the stream fails or the context ends.
+func (c *Client) consumeOnce(ctx context.Context, sub *typedSubscription, from time.Time, lastSeen *time.Time, out chan<- TypedEvent) error {
+	actx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		evCh     <-chan EventWithReplay
+		rawCh    <-chan *events.Envelope
+		errCh    <-chan error
+		replayed bool
+	)
+	if from.IsZero() {
+		rawCh, errCh = c.EventService().Subscribe(actx, sub.filters...)
+	} else {
+		evCh, errCh = c.SubscribeWithReplay(actx, EventPosition{Time: from}, sub.filters...)
+	}
+
+	for {
+		var (
+			envelope *events.Envelope
+			ok       bool
+		)
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			if err != nil && errdefs.IsNotImplemented(err) && !from.IsZero() {
+				// no journal on the daemon: reconnect without replay
+				return c.consumeOnce(ctx, sub, time.Time{}, lastSeen, out)
+			}
+			return err
+		case ev, evOK := <-evCh:
+			envelope, ok, replayed = ev.Envelope, evOK, ev.Replayed
+		case env, rawOK := <-rawCh:
+			envelope, ok, replayed = env, rawOK, false
+		}
+		if !ok {
+			return nil
+		}
+		if envelope.Timestamp.After(*lastSeen) {
+			*lastSeen = envelope.Timestamp
+		}
+		select {
+		case out <- decodeEnvelope(envelope, sub.types, replayed):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// decodeEnvelope unmarshals the envelope's event when it is a wanted,
+// registered type; otherwise the raw Any passes through.
+func decodeEnvelope(envelope *events.Envelope, types map[string]struct{}, replayed bool) TypedEvent {
+	te := TypedEvent{
+		Timestamp: envelope.Timestamp,
+		Namespace: envelope.Namespace,
+		Topic:     envelope.Topic,
+		Event:     envelope.Event,
+		Replayed:  replayed,
+	}
+	if envelope.Event == nil {
+		return te
+	}
+	if types != nil {
+		if _, ok := types[envelope.Event.GetTypeUrl()]; !ok {
+			return te
+		}
+	}
+	if decoded, err := typeurl.UnmarshalAny(envelope.Event); err == nil {
+		te.Event = decoded
+	}
+	return te
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/containerd/containerd/v2/pkg/cio"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// ExecBatchEntry is one command in an ExecBatch run.
+type ExecBatchEntry struct {
+	// Spec is the process to run; Args, Cwd and Env follow the usual
+	// exec process spec rules.
+	Spec *specs.Process
+	// StopOnFailure ends the batch without running the remaining
+	// entries when this command exits non-zero.
+	StopOnFailure bool
+	// Timeout bounds the command's runtime; on expiry the process is
+	// killed and the batch fails. Zero means no limit.
+	Timeout time.Duration
+}
+
+// ExecBatchResult is the captured outcome of one batch entry.
+type ExecBatchResult struct {
+	ExitCode uint32
+	Stdout   []byte
+	Stderr   []byte
+}
+
+type execBatchConfig struct {
+	idPrefix string
+}
+
+// ExecBatchOpt customizes an ExecBatch run.
+type ExecBatchOpt func(*execBatchConfig)
+
+// WithExecBatchIDPrefix sets the prefix used for the batch's exec IDs,
+// for callers running several batches against the same task.
+func WithExecBatchIDPrefix(prefix string) ExecBatchOpt {
+	return func(c *execBatchConfig) {
+		c.idPrefix = prefix
+	}
+}
+
+// ExecBatch runs the commands serially inside the task, capturing each
+// one's output and exit code, replacing the fragile Exec/Start/Wait
+// chains (or in-container shell scripts) initialization flows need. The
+// batch stops early when an entry with StopOnFailure set exits non-zero;
+// the results returned cover the entries that ran. A command exceeding
+// its Timeout is killed and fails the batch.
+func (t *task) ExecBatch(ctx context.Context, commands []*ExecBatchEntry, opts ...ExecBatchOpt) ([]*ExecBatchResult, error) {
+	config := execBatchConfig{
+		idPrefix: fmt.Sprintf("batch-%d", time.Now().UnixNano()),
+	}
+	for _, o := range opts {
+		o(&config)
+	}
+
+	var results []*ExecBatchResult
+	for i, entry := range commands {
+		result, err := t.execEntry(ctx, fmt.Sprintf("%s-%d", config.idPrefix, i), entry)
+		if err != nil {
+			return results, fmt.Errorf("batch entry %d: %w", i, err)
+		}
+		results = append(results, result)
+		if entry.StopOnFailure && result.ExitCode != 0 {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (t *task) execEntry(ctx context.Context, execID string, entry *ExecBatchEntry) (_ *ExecBatchResult, retErr error) {
+	if entry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, entry.Timeout)
+		defer cancel()
+	}
+	// keep delete and kill working after the timeout fires
+	cleanupCtx := context.WithoutCancel(ctx)
+
+	var stdout, stderr bytes.Buffer
+	process, err := t.Exec(ctx, execID, entry.Spec, cio.NewCreator(cio.WithStreams(nil, &stdout, &stderr)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if _, err := process.Delete(cleanupCtx, WithProcessKill); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+
+	statusC, err := process.Wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := process.Start(ctx); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		_ = process.Kill(cleanupCtx, syscall.SIGKILL)
+		return nil, fmt.Errorf("command timed out after %v: %w", entry.Timeout, ctx.Err())
+	case status := <-statusC:
+		code, _, err := status.Result()
+		if err != nil {
+			return nil, err
+		}
+		process.IO().Wait()
+		return &ExecBatchResult{
+			ExitCode: code,
+			Stdout:   bytes.Clone(stdout.Bytes()),
+			Stderr:   bytes.Clone(stderr.Bytes()),
+		}, nil
+	}
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containerd/typeurl/v2"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/pkg/forensics"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// WithForensicsMode keeps a crashed container inspectable: when its task
+// exits non-zero, the shim freezes the cgroup, publishes a forensics-ready
+// event, and keeps the remaining processes frozen until Task.Delete is
+// called or the window elapses (five minutes by default, zero keeps the
+// default). During the window Task.Exec still works for attaching
+// debuggers. Must be applied after the spec is set.
+func WithForensicsMode(window time.Duration) NewContainerOpts {
+	return func(ctx context.Context, client *Client, c *containers.Container) error {
+		if c.Spec == nil {
+			return fmt.Errorf("spec must be set before enabling forensics mode")
+		}
+		var s specs.Spec
+		if err := json.Unmarshal(c.Spec.GetValue(), &s); err != nil {
+			return err
+		}
+		if s.Annotations == nil {
+			s.Annotations = map[string]string{}
+		}
+		s.Annotations[forensics.ModeAnnotation] = "true"
+		if window > 0 {
+			s.Annotations[forensics.WindowAnnotation] = window.String()
+		}
+		var err error
+		c.Spec, err = typeurl.MarshalAnyToProto(&s)
+		return err
+	}
+}
+
+// ForensicsDump captures the system state of the task's processes — their
+// status, memory maps and open file descriptors — into destDir, one
+// directory per pid. Works best while the container is frozen in its
+// forensics window; the caller must run on the daemon's host.
+func (t *task) ForensicsDump(ctx context.Context, destDir string) error {
+	pids, err := t.Pids(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(destDir, 0700); err != nil {
+		return err
+	}
+
+	for _, p := range pids {
+		pidDir := filepath.Join(destDir, fmt.Sprintf("%d", p.Pid))
+		if err := os.MkdirAll(pidDir, 0700); err != nil {
+			return err
+		}
+		proc := fmt.Sprintf("/proc/%d", p.Pid)
+		for _, name := range []string{"status", "maps", "stack", "cmdline", "environ"} {
+			data, err := os.ReadFile(filepath.Join(proc, name))
+			if err != nil {
+				continue
+			}
+			if err := os.WriteFile(filepath.Join(pidDir, name), data, 0600); err != nil {
+				return err
+			}
+		}
+
+		// record fd targets
+		if entries, err := os.ReadDir(filepath.Join(proc, "fd")); err == nil {
+			var fds []byte
+			for _, e := range entries {
+				target, err := os.Readlink(filepath.Join(proc, "fd", e.Name()))
+				if err != nil {
+					continue
+				}
+				fds = append(fds, []byte(e.Name()+" -> "+target+"\n")...)
+			}
+			if err := os.WriteFile(filepath.Join(pidDir, "fds"), fds, 0600); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/containerd/errdefs"
+
+	"github.com/containerd/containerd/v2/core/mount"
+)
+
+// MountSnapshot mounts the container's snapshot at target on the host so
+// its filesystem can be inspected without running a shell inside the
+// container. While the container has a running or paused task the mount is
+// forced read-only to avoid corrupting live overlayfs state; otherwise it
+// is writable. target is created when missing. The returned function
+// unmounts the snapshot and removes target if it was created here.
+func (c *Client) MountSnapshot(ctx context.Context, containerID, target string) (func() error, error) {
+	container, err := c.LoadContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := container.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.SnapshotKey == "" {
+		return nil, fmt.Errorf("container %q has no snapshot: %w", containerID, errdefs.ErrFailedPrecondition)
+	}
+
+	readonly := false
+	if task, err := container.Task(ctx, nil); err == nil {
+		status, err := task.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch status.Status {
+		case Running, Paused, Pausing:
+			readonly = true
+		}
+	} else if !errdefs.IsNotFound(err) {
+		return nil, err
+	}
+
+	mounts, err := c.SnapshotService(info.Snapshotter).Mounts(ctx, info.SnapshotKey)
+	if err != nil {
+		return nil, err
+	}
+	if readonly {
+		mounts = mount.ReadonlyMounts(mounts)
+	}
+
+	created := false
+	if _, err := os.Stat(target); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err := os.MkdirAll(target, 0700); err != nil {
+			return nil, err
+		}
+		created = true
+	}
+
+	if err := mount.All(mounts, target); err != nil {
+		if created {
+			os.Remove(target)
+		}
+		return nil, fmt.Errorf("failed to mount snapshot of container %q: %w", containerID, err)
+	}
+
+	return func() error {
+		if err := mount.UnmountAll(target, 0); err != nil {
+			return err
+		}
+		if created {
+			return os.Remove(target)
+		}
+		return nil
+	}, nil
+}
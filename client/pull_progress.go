@@ -0,0 +1,164 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/images"
+)
+
+// PullProgress describes the download state of one layer during
+// PullWithProgress.
+type PullProgress struct {
+	// Layer being downloaded
+	Layer digest.Digest
+	// Status is "waiting", "downloading" or "done"
+	Status string
+	// Downloaded bytes so far
+	Downloaded int64
+	// Total size of the layer in bytes
+	Total int64
+	// StartedAt is when the layer was discovered
+	StartedAt time.Time
+}
+
+// pullProgressInterval is how often active downloads are sampled.
+const pullProgressInterval = 300 * time.Millisecond
+
+// PullWithProgress pulls an image like Pull while delivering per-layer
+// download progress on the given channel: a waiting event when a layer is
+// discovered, downloading events while bytes arrive (concurrent downloads
+// each report their own), and a final done event. The channel is closed
+// when the pull completes or fails. Progress sends block until received,
+// so pass a buffered channel and keep draining it: an abandoned receiver
+// stalls the pull until the context ends.
+func (c *Client) PullWithProgress(ctx context.Context, ref string, progress chan<- PullProgress, opts ...RemoteOpt) (_ Image, retErr error) {
+	var (
+		mu     sync.Mutex
+		layers = map[digest.Digest]*PullProgress{}
+	)
+	send := func(p PullProgress) {
+		select {
+		case progress <- p:
+		case <-ctx.Done():
+		}
+	}
+
+	handler := images.HandlerFunc(func(ctx context.Context, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+		if !images.IsLayerType(desc.MediaType) {
+			return nil, nil
+		}
+		mu.Lock()
+		if _, ok := layers[desc.Digest]; !ok {
+			p := &PullProgress{
+				Layer:     desc.Digest,
+				Status:    "waiting",
+				Total:     desc.Size,
+				StartedAt: time.Now(),
+			}
+			layers[desc.Digest] = p
+			mu.Unlock()
+			send(*p)
+			return nil, nil
+		}
+		mu.Unlock()
+		return nil, nil
+	})
+
+	pollCtx, cancelPoll := context.WithCancel(ctx)
+	pollDone := make(chan struct{})
+	go func() {
+		defer close(pollDone)
+		ticker := time.NewTicker(pullProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pollCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			statuses, err := c.ContentStore().ListStatuses(pollCtx)
+			if err != nil {
+				continue
+			}
+			byDigest := map[digest.Digest]int64{}
+			for _, status := range statuses {
+				// fetch refs end in the digest of the blob being pulled
+				for dgst := range layers {
+					if strings.HasSuffix(status.Ref, dgst.String()) {
+						byDigest[dgst] = status.Offset
+					}
+				}
+			}
+
+			mu.Lock()
+			var updates []PullProgress
+			for dgst, p := range layers {
+				offset, active := byDigest[dgst]
+				switch {
+				case active && offset > p.Downloaded:
+					p.Downloaded = offset
+					p.Status = "downloading"
+					updates = append(updates, *p)
+				case !active && p.Status == "downloading":
+					// no ingest anymore: the layer finished downloading
+					if _, err := c.ContentStore().Info(pollCtx, dgst); err == nil {
+						p.Downloaded = p.Total
+						p.Status = "done"
+						updates = append(updates, *p)
+					}
+				}
+			}
+			mu.Unlock()
+			for _, u := range updates {
+				send(u)
+			}
+		}
+	}()
+
+	defer func() {
+		cancelPoll()
+		<-pollDone
+		if retErr == nil {
+			// everything made it into the store, flush final done events
+			mu.Lock()
+			var finals []PullProgress
+			for _, p := range layers {
+				if p.Status != "done" {
+					p.Downloaded = p.Total
+					p.Status = "done"
+					finals = append(finals, *p)
+				}
+			}
+			mu.Unlock()
+			for _, p := range finals {
+				send(p)
+			}
+		}
+		close(progress)
+	}()
+
+	return c.Pull(ctx, ref, append(opts, WithImageHandler(handler))...)
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/containerd/api/services/tasks/v1"
+	tasktypes "github.com/containerd/containerd/api/types/task"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/errdefs/pkg/errgrpc"
+
+	"github.com/containerd/containerd/v2/pkg/cio"
+)
+
+// RecoverTask reconnects to a task whose shim kept running across a daemon
+// or client restart, returning a fully functional handle. The shim is
+// verified to be alive and responding — not just recorded — by refreshing
+// its state and round-tripping a request through it before the handle is
+// returned; a shim that is gone or wedged surfaces as an error instead of
+// a handle that fails on first use. When ioAttach is non-nil the task's
+// existing fifos are reattached, with the Windows pipe handling the
+// regular attach path already applies.
+func (c *Client) RecoverTask(ctx context.Context, containerID string, ioAttach cio.Attach) (Task, error) {
+	container, err := c.LoadContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh the task state from the shim.
+	response, err := c.TaskService().Get(ctx, &tasks.GetRequest{
+		ContainerID: containerID,
+	})
+	if err != nil {
+		err = errgrpc.ToNative(err)
+		if errdefs.IsNotFound(err) {
+			return nil, fmt.Errorf("no task to recover for container %q: %w", containerID, err)
+		}
+		return nil, err
+	}
+	if response.Process.Status == tasktypes.Status_UNKNOWN {
+		return nil, fmt.Errorf("task %q is in unknown state, shim not reporting: %w", containerID, errdefs.ErrUnavailable)
+	}
+
+	// Ping the shim with a request that must reach it, so a dead or
+	// wedged shim is caught now rather than on first use.
+	if _, err := c.TaskService().ListPids(ctx, &tasks.ListPidsRequest{
+		ContainerID: containerID,
+	}); err != nil {
+		return nil, fmt.Errorf("shim for task %q is not responding: %w", containerID, errgrpc.ToNative(err))
+	}
+
+	return container.Task(ctx, ioAttach)
+}
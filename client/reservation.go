@@ -0,0 +1,103 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/containerd/errdefs"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/core/leases"
+	"github.com/containerd/containerd/v2/pkg/oci"
+)
+
+// reservationResourcesLabel holds the reserved resources on the lease
+// backing a reservation.
+const reservationResourcesLabel = "containerd.io/reservation.resources"
+
+// ReserveResources records a resource reservation for a future task so
+// schedulers can prevent oversubscription during the scheduling window.
+// The reservation is backed by a lease carrying the resources and expiring
+// after ttl, so abandoned reservations are cleaned up by the garbage
+// collector. The returned token is redeemed with WithReservationToken when
+// the task is created, completing the two-phase allocation.
+func (c *Client) ReserveResources(ctx context.Context, id string, res *specs.LinuxResources, ttl time.Duration) (string, error) {
+	if res == nil {
+		return "", fmt.Errorf("no resources to reserve: %w", errdefs.ErrInvalidArgument)
+	}
+	if ttl <= 0 {
+		return "", fmt.Errorf("reservation ttl must be positive: %w", errdefs.ErrInvalidArgument)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	lease, err := c.LeasesService().Create(ctx,
+		leases.WithID("reservation-"+id),
+		leases.WithExpiration(ttl),
+		leases.WithLabels(map[string]string{
+			reservationResourcesLabel: string(data),
+		}),
+	)
+	if err != nil {
+		return "", err
+	}
+	return lease.ID, nil
+}
+
+// WithReservationToken redeems a reservation made with ReserveResources:
+// the reserved resources are applied to the task's spec, sizing its cgroup,
+// and the reservation is consumed so it cannot be redeemed twice. An
+// expired or unknown token fails with NotFound.
+func WithReservationToken(token string) NewTaskOpts {
+	return func(ctx context.Context, c *Client, ti *TaskInfo) error {
+		ls := c.LeasesService()
+		list, err := ls.List(ctx, fmt.Sprintf("id==%q", token))
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return fmt.Errorf("reservation %q expired or does not exist: %w", token, errdefs.ErrNotFound)
+		}
+		lease := list[0]
+
+		data, ok := lease.Labels[reservationResourcesLabel]
+		if !ok {
+			return fmt.Errorf("lease %q is not a resource reservation: %w", token, errdefs.ErrInvalidArgument)
+		}
+		var res specs.LinuxResources
+		if err := json.Unmarshal([]byte(data), &res); err != nil {
+			return fmt.Errorf("invalid reservation resources on %q: %w", token, err)
+		}
+
+		ti.specOpts = append(ti.specOpts, func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+			if s.Linux == nil {
+				s.Linux = &specs.Linux{}
+			}
+			s.Linux.Resources = &res
+			return nil
+		})
+
+		// consume the reservation
+		return ls.Delete(ctx, lease)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	tasktypes "github.com/containerd/containerd/api/services/tasks/v1"
+	apitask "github.com/containerd/containerd/api/types/task"
+	"github.com/containerd/typeurl/v2"
+
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/containerd/v2/pkg/oci"
+)
+
+// resourceSummaryTTL is how long a cached summary stays fresh, see
+// WithCached.
+const resourceSummaryTTL = 5 * time.Second
+
+// defaultCPUPeriod is assumed when a container sets a CPU quota without
+// an explicit period, matching the runtime default.
+const defaultCPUPeriod = 100000
+
+// NodeResourceSummary aggregates what is allocated across every
+// namespace on the node, for schedulers making placement decisions.
+type NodeResourceSummary struct {
+	// AllocatedCPUMillis sums the CPU limits from every container's
+	// spec, in milli-CPUs; containers without a quota contribute
+	// nothing.
+	AllocatedCPUMillis int64
+	// AllocatedMemoryBytes sums the memory limits from every
+	// container's spec.
+	AllocatedMemoryBytes int64
+	// RunningTasks counts tasks currently running.
+	RunningTasks int
+	// PausedTasks counts tasks paused or pausing.
+	PausedTasks int
+	// PendingTasks counts tasks created but not yet started.
+	PendingTasks int
+	// EphemeralDiskBytes is the disk consumed by writable snapshots.
+	EphemeralDiskBytes int64
+}
+
+type resourceSummaryConfig struct {
+	cached bool
+}
+
+// ResourceSummaryOpt customizes ResourceSummary.
+type ResourceSummaryOpt func(*resourceSummaryConfig)
+
+// WithCached serves the summary from a cache refreshed at most every 5s,
+// for callers polling on every scheduling decision.
+func WithCached() ResourceSummaryOpt {
+	return func(c *resourceSummaryConfig) {
+		c.cached = true
+	}
+}
+
+// ResourceSummary aggregates allocated resources and task counts across
+// all namespaces. The aggregation uses one bulk container and task list
+// per namespace plus the snapshotter usage walk instead of per-task
+// metrics rpcs, so the cost is bounded by the number of namespaces, not
+// containers.
+func (c *Client) ResourceSummary(ctx context.Context, opts ...ResourceSummaryOpt) (*NodeResourceSummary, error) {
+	var config resourceSummaryConfig
+	for _, o := range opts {
+		o(&config)
+	}
+	if config.cached {
+		c.resourceSummaryMu.Lock()
+		if c.resourceSummary != nil && time.Since(c.resourceSummaryFetched) < resourceSummaryTTL {
+			summary := *c.resourceSummary
+			c.resourceSummaryMu.Unlock()
+			return &summary, nil
+		}
+		c.resourceSummaryMu.Unlock()
+	}
+
+	nsList, err := c.NamespaceService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary NodeResourceSummary
+	for _, ns := range nsList {
+		nsctx := namespaces.WithNamespace(ctx, ns)
+
+		containers, err := c.ContainerService().List(nsctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, container := range containers {
+			if container.Spec == nil {
+				continue
+			}
+			v, err := typeurl.UnmarshalAny(container.Spec)
+			if err != nil {
+				continue
+			}
+			spec, ok := v.(*oci.Spec)
+			if !ok || spec.Linux == nil || spec.Linux.Resources == nil {
+				continue
+			}
+			if cpu := spec.Linux.Resources.CPU; cpu != nil && cpu.Quota != nil && *cpu.Quota > 0 {
+				period := int64(defaultCPUPeriod)
+				if cpu.Period != nil && *cpu.Period > 0 {
+					period = int64(*cpu.Period)
+				}
+				summary.AllocatedCPUMillis += *cpu.Quota * 1000 / period
+			}
+			if mem := spec.Linux.Resources.Memory; mem != nil && mem.Limit != nil && *mem.Limit > 0 {
+				summary.AllocatedMemoryBytes += *mem.Limit
+			}
+		}
+
+		resp, err := c.TaskService().List(nsctx, &tasktypes.ListTasksRequest{})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range resp.Tasks {
+			switch t.Status {
+			case apitask.Status_RUNNING:
+				summary.RunningTasks++
+			case apitask.Status_PAUSED, apitask.Status_PAUSING:
+				summary.PausedTasks++
+			case apitask.Status_CREATED:
+				summary.PendingTasks++
+			}
+		}
+	}
+
+	usage, err := c.SnapshotUsageStats(ctx, "*")
+	if err != nil {
+		return nil, err
+	}
+	for _, stat := range usage {
+		summary.EphemeralDiskBytes += stat.WritableBytes
+	}
+
+	if config.cached {
+		c.resourceSummaryMu.Lock()
+		cached := summary
+		c.resourceSummary = &cached
+		c.resourceSummaryFetched = time.Now()
+		c.resourceSummaryMu.Unlock()
+	}
+	return &summary, nil
+}
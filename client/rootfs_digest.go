@@ -0,0 +1,84 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containerd/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/identity"
+
+	"github.com/containerd/containerd/v2/pkg/rootfs"
+)
+
+// GetRootFSDigest computes the checksum of the container's immutable rootfs
+// layers: the chain ID over the DiffIDs of the container's image, read from
+// the content store without mounting anything. For a container whose layers
+// have not been tampered with since startup this matches the image's rootfs
+// digest; changes in the writable layer do not affect it, use
+// GetMutableLayerDigest to capture those.
+func (c *Client) GetRootFSDigest(ctx context.Context, containerID string) (digest.Digest, error) {
+	container, err := c.LoadContainer(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	image, err := container.Image(ctx)
+	if err != nil {
+		return "", err
+	}
+	diffIDs, err := image.RootFS(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(diffIDs) == 0 {
+		return "", fmt.Errorf("image %q has no rootfs layers: %w", image.Name(), errdefs.ErrNotFound)
+	}
+	return identity.ChainID(diffIDs), nil
+}
+
+// GetMutableLayerDigest computes the digest of the changes in the
+// container's writable layer by asking the daemon's diff service for the
+// layer diff of the container's snapshot. An unmodified container yields
+// the digest of an empty diff, stable across calls until the layer
+// changes.
+func (c *Client) GetMutableLayerDigest(ctx context.Context, containerID string) (digest.Digest, error) {
+	container, err := c.LoadContainer(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	info, err := container.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+	if info.SnapshotKey == "" {
+		return "", fmt.Errorf("container %q has no snapshot: %w", containerID, errdefs.ErrFailedPrecondition)
+	}
+
+	ctx, done, err := c.WithLease(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer done(ctx)
+
+	desc, err := rootfs.CreateDiff(ctx, info.SnapshotKey, c.SnapshotService(info.Snapshotter), c.DiffService())
+	if err != nil {
+		return "", fmt.Errorf("failed to diff writable layer: %w", err)
+	}
+	return desc.Digest, nil
+}
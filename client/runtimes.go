@@ -0,0 +1,92 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/containerd/errdefs"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/plugins"
+)
+
+// WithAutoRuntime selects the container's runtime from its spec: the
+// selector sees the spec and returns a runtime name, for example choosing a
+// VM isolated runtime for containers carrying a particular annotation. An
+// empty result keeps the runtime already set on the container. The option
+// must be applied after the spec is set and runs before the container is
+// persisted.
+func WithAutoRuntime(selector func(*specs.Spec) string) NewContainerOpts {
+	return func(ctx context.Context, client *Client, c *containers.Container) error {
+		if c.Spec == nil {
+			return fmt.Errorf("spec must be set before selecting a runtime: %w", errdefs.ErrFailedPrecondition)
+		}
+		var s specs.Spec
+		if err := json.Unmarshal(c.Spec.GetValue(), &s); err != nil {
+			return err
+		}
+		if name := selector(&s); name != "" {
+			c.Runtime.Name = name
+		}
+		return nil
+	}
+}
+
+// RegisteredRuntime describes a runtime plugin known to the daemon.
+type RegisteredRuntime struct {
+	// Name is the fully qualified plugin name of the runtime
+	Name string
+	// Platforms the runtime supports
+	Platforms []ocispec.Platform
+}
+
+// ListRegisteredRuntimes returns the runtime plugins the daemon knows
+// about, resolved through the introspection service so no container has to
+// be created to probe them.
+func (c *Client) ListRegisteredRuntimes(ctx context.Context) ([]RegisteredRuntime, error) {
+	resp, err := c.IntrospectionService().Plugins(ctx,
+		fmt.Sprintf("type==%q", plugins.RuntimePluginV2),
+		fmt.Sprintf("type==%q", plugins.RuntimePlugin),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimes := make([]RegisteredRuntime, 0, len(resp.Plugins))
+	for _, p := range resp.Plugins {
+		if p.InitErr != nil {
+			continue
+		}
+		r := RegisteredRuntime{
+			Name: p.Type + "." + p.ID,
+		}
+		for _, pl := range p.Platforms {
+			r.Platforms = append(r.Platforms, ocispec.Platform{
+				OS:           pl.OS,
+				Architecture: pl.Architecture,
+				Variant:      pl.Variant,
+			})
+		}
+		runtimes = append(runtimes, r)
+	}
+	return runtimes, nil
+}
@@ -243,3 +243,13 @@ func WithSandboxLabels(labels map[string]string) NewSandboxOpts {
 		return nil
 	}
 }
+
+// ListContainersBySandbox returns every container associated with the
+// sandbox, whether through WithSandbox, WithSandboxID or the
+// SandboxIDLabel convention.
+func (c *Client) ListContainersBySandbox(ctx context.Context, sandboxID string) ([]Container, error) {
+	return c.Containers(ctx,
+		fmt.Sprintf("sandbox==%q", sandboxID),
+		fmt.Sprintf("labels.%q==%q", SandboxIDLabel, sandboxID),
+	)
+}
@@ -0,0 +1,80 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/containerd/errdefs"
+	metrics "github.com/docker/go-metrics"
+
+	"github.com/containerd/containerd/v2/pkg/cio"
+)
+
+var (
+	schedulingDelayTimer metrics.Timer
+	schedulingMetricOnce sync.Once
+)
+
+// registerSchedulingMetrics exports the scheduling delay histogram on the
+// prometheus default registry; deferred until the metric is first used so
+// plain clients do not register collectors.
+func registerSchedulingMetrics() {
+	schedulingMetricOnce.Do(func() {
+		ns := metrics.NewNamespace("containerd", "client", nil)
+		schedulingDelayTimer = ns.NewTimer("task_scheduling_delay", "duration between task start and the first byte of output")
+		metrics.Register(ns)
+	})
+}
+
+// WithSchedulingDelayTimer attaches a first byte timer to the task so
+// SchedulingDelay can relate the Start call to the task's first output.
+// The timer must wrap the stdout/stderr writers of the task's IO, see
+// cio.FirstByteTimer.Wrap.
+func WithSchedulingDelayTimer(timer *cio.FirstByteTimer) NewTaskOpts {
+	return func(ctx context.Context, c *Client, ti *TaskInfo) error {
+		ti.firstByteTimer = timer
+		return nil
+	}
+}
+
+// SchedulingDelay reports the time between the Start call being issued and
+// the first byte the task wrote to its stdout or stderr. The task must
+// have been created with WithSchedulingDelayTimer and writers wrapped by
+// the timer; before any output has been observed ErrUnavailable is
+// returned. The observed delay is also exported as a prometheus histogram
+// (containerd_client_task_scheduling_delay).
+func (t *task) SchedulingDelay(ctx context.Context) (time.Duration, error) {
+	if t.firstByteTimer == nil {
+		return 0, fmt.Errorf("task was created without a scheduling delay timer: %w", errdefs.ErrFailedPrecondition)
+	}
+	if t.startedAt.IsZero() {
+		return 0, fmt.Errorf("task has not been started: %w", errdefs.ErrFailedPrecondition)
+	}
+	firstByte := t.firstByteTimer.FirstByteAt()
+	if firstByte.IsZero() {
+		return 0, fmt.Errorf("task has not produced output yet: %w", errdefs.ErrUnavailable)
+	}
+
+	delay := firstByte.Sub(t.startedAt)
+	registerSchedulingMetrics()
+	schedulingDelayTimer.Update(delay)
+	return delay, nil
+}
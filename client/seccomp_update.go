@@ -0,0 +1,70 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/containerd/errdefs"
+	"github.com/containerd/typeurl/v2"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/containerd/v2/pkg/oci"
+	"github.com/containerd/containerd/v2/pkg/seccomp"
+)
+
+// seccompUpdatedLabel journals the last runtime update of the
+// container's seccomp profile.
+const seccompUpdatedLabel = "containerd.io/seccomp.updated"
+
+// UpdateSeccompProfile replaces the seccomp profile in the task's stored
+// spec after validating it against the running architecture's syscall
+// table. The new policy takes effect when a task is next created from
+// the container — a restart, or a restore after checkpoint; Linux does
+// not allow replacing the filter attached to a running process from
+// outside, so the current task instance (including its execs, whose
+// policy runc captured at create) keeps the policy it started with.
+// Task.Spec reflects the change immediately.
+func (t *task) UpdateSeccompProfile(ctx context.Context, profile *specs.LinuxSeccomp) error {
+	if err := seccomp.ValidateProfile(profile); err != nil {
+		return err
+	}
+
+	r, err := t.client.ContainerService().Get(ctx, t.id)
+	if err != nil {
+		return err
+	}
+	var spec oci.Spec
+	if err := typeurl.UnmarshalTo(r.Spec, &spec); err != nil {
+		return err
+	}
+	if spec.Linux == nil {
+		return fmt.Errorf("task has no linux spec: %w", errdefs.ErrFailedPrecondition)
+	}
+	spec.Linux.Seccomp = profile
+	if r.Spec, err = typeurl.MarshalAnyToProto(&spec); err != nil {
+		return err
+	}
+	if r.Labels == nil {
+		r.Labels = map[string]string{}
+	}
+	r.Labels[seccompUpdatedLabel] = time.Now().UTC().Format(time.RFC3339)
+	_, err = t.client.ContainerService().Update(ctx, r, "spec", "labels."+seccompUpdatedLabel)
+	return err
+}
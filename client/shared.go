@@ -0,0 +1,81 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"sync"
+)
+
+var (
+	sharedMu    sync.Mutex
+	sharedConns = map[string]*sharedConn{}
+)
+
+// sharedConn tracks the client owning a coalesced connection and how many
+// handles reference it.
+type sharedConn struct {
+	address string
+	owner   *Client
+	refs    int
+}
+
+// SharedClient returns a client that shares one underlying gRPC connection
+// per address with every other SharedClient caller in the process, keeping
+// the daemon's connection count at one per address instead of one per
+// client. Closing the returned handle only releases its reference; the
+// connection is closed when the last handle is closed. Options are applied
+// to each handle, but dial options only take effect for the first handle
+// of an address, which establishes the connection.
+func SharedClient(address string, opts ...Opt) (*Client, error) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+
+	sc, ok := sharedConns[address]
+	if !ok {
+		owner, err := New(address, opts...)
+		if err != nil {
+			return nil, err
+		}
+		sc = &sharedConn{address: address, owner: owner}
+		sharedConns[address] = sc
+	}
+
+	handle, err := NewWithConn(sc.owner.conn, opts...)
+	if err != nil {
+		if sc.refs == 0 {
+			delete(sharedConns, address)
+			sc.owner.conn.Close()
+		}
+		return nil, err
+	}
+	sc.refs++
+	handle.releaseConn = sc.release
+	return handle, nil
+}
+
+// release drops one reference, closing the connection when the last one is
+// gone.
+func (sc *sharedConn) release() error {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	sc.refs--
+	if sc.refs > 0 {
+		return nil
+	}
+	delete(sharedConns, sc.address)
+	return sc.owner.conn.Close()
+}
@@ -0,0 +1,82 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+)
+
+func TestSharedClient(t *testing.T) {
+	// grpc connections are established lazily, no daemon is needed to
+	// exercise the coalescing.
+	const address = "/run/containerd/shared-client-test.sock"
+
+	c1, err := SharedClient(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := SharedClient(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1.conn != c2.conn {
+		t.Fatal("handles should share one underlying connection")
+	}
+
+	sharedMu.Lock()
+	if refs := sharedConns[address].refs; refs != 2 {
+		sharedMu.Unlock()
+		t.Fatalf("expected 2 references, got %d", refs)
+	}
+	sharedMu.Unlock()
+
+	if err := c1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// closing the same handle again must not release another reference
+	if err := c1.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sharedMu.Lock()
+	if refs := sharedConns[address].refs; refs != 1 {
+		sharedMu.Unlock()
+		t.Fatalf("expected 1 reference after close, got %d", refs)
+	}
+	sharedMu.Unlock()
+
+	if err := c2.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sharedMu.Lock()
+	if _, ok := sharedConns[address]; ok {
+		sharedMu.Unlock()
+		t.Fatal("connection should be released when the last handle closes")
+	}
+	sharedMu.Unlock()
+
+	// a new SharedClient after full release dials fresh
+	c3, err := SharedClient(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c3.Close()
+	if c3.conn == nil {
+		t.Fatal("expected a new connection")
+	}
+}
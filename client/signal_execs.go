@@ -0,0 +1,91 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	tasks "github.com/containerd/containerd/api/services/tasks/v1"
+	"github.com/containerd/containerd/api/types/runc/options"
+	"github.com/containerd/errdefs"
+	"github.com/containerd/errdefs/pkg/errgrpc"
+	"github.com/containerd/typeurl/v2"
+)
+
+// signalAllExecsTimeout bounds the parallel per-exec Kill rpcs.
+const signalAllExecsTimeout = 10 * time.Second
+
+// SignalAllExecs delivers the signal to every running exec process in the
+// task without touching the init process, the middle ground between Kill
+// on one exec and Kill with WithKillAll. The signals are sent in parallel
+// under a timeout; execs that already exited are skipped. Use
+// WithExcludeExec to exempt specific execs such as a monitoring sidecar.
+func (t *task) SignalAllExecs(ctx context.Context, s syscall.Signal, opts ...KillOpts) error {
+	var i KillInfo
+	for _, o := range opts {
+		if err := o(ctx, &i); err != nil {
+			return err
+		}
+	}
+	excluded := make(map[string]struct{}, len(i.ExcludedExecs))
+	for _, id := range i.ExcludedExecs {
+		excluded[id] = struct{}{}
+	}
+
+	processes, err := t.Pids(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, signalAllExecsTimeout)
+	defer cancel()
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, p := range processes {
+		if p.Info == nil {
+			continue
+		}
+		details, err := typeurl.UnmarshalAny(p.Info)
+		if err != nil {
+			return err
+		}
+		d, ok := details.(*options.ProcessDetails)
+		if !ok || d.ExecID == "" {
+			// init process, or a runtime without exec details
+			continue
+		}
+		if _, ok := excluded[d.ExecID]; ok {
+			continue
+		}
+		eg.Go(func() error {
+			_, err := t.client.TaskService().Kill(ctx, &tasks.KillRequest{
+				Signal:      uint32(s),
+				ContainerID: t.id,
+				ExecID:      d.ExecID,
+			})
+			if err := errgrpc.ToNative(err); err != nil && !errdefs.IsNotFound(err) {
+				return err
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
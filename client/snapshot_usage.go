@@ -0,0 +1,133 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	"github.com/containerd/containerd/v2/plugins"
+)
+
+// defaultSnapshotUsageTTL is how long SnapshotUsageStats results are served
+// from cache, see WithSnapshotUsageTTL.
+const defaultSnapshotUsageTTL = 30 * time.Second
+
+// SnapshotNamespaceStat aggregates a snapshotter's disk usage within one
+// namespace.
+type SnapshotNamespaceStat struct {
+	// Namespace the snapshots belong to
+	Namespace string
+	// CommittedBytes used by committed snapshots
+	CommittedBytes int64
+	// WritableBytes used by active and view snapshots
+	WritableBytes int64
+	// SnapshotCount is the total number of snapshots
+	SnapshotCount int
+	// WritableCount is the number of active and view snapshots
+	WritableCount int
+}
+
+type snapshotUsageEntry struct {
+	stats   []SnapshotNamespaceStat
+	fetched time.Time
+}
+
+// SnapshotUsageStats reports how the given snapshotter's disk usage is
+// distributed across namespaces. Passing "*" aggregates every registered
+// snapshotter. Results are cached for the client's snapshot usage TTL
+// (30s by default, see WithSnapshotUsageTTL) since walking every snapshot
+// is expensive on the metadata layer.
+func (c *Client) SnapshotUsageStats(ctx context.Context, snapshotter string) ([]SnapshotNamespaceStat, error) {
+	c.snapshotUsageMu.Lock()
+	ttl := c.snapshotUsageTTL
+	if ttl == 0 {
+		ttl = defaultSnapshotUsageTTL
+	}
+	if e, ok := c.snapshotUsage[snapshotter]; ok && time.Since(e.fetched) < ttl {
+		c.snapshotUsageMu.Unlock()
+		return e.stats, nil
+	}
+	c.snapshotUsageMu.Unlock()
+
+	snapshotters := []string{snapshotter}
+	if snapshotter == "*" {
+		resp, err := c.IntrospectionService().Plugins(ctx, fmt.Sprintf("type==%q", plugins.SnapshotPlugin))
+		if err != nil {
+			return nil, err
+		}
+		snapshotters = snapshotters[:0]
+		for _, p := range resp.Plugins {
+			if p.InitErr == nil {
+				snapshotters = append(snapshotters, p.ID)
+			}
+		}
+	}
+
+	nsList, err := c.NamespaceService().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byNS := make(map[string]*SnapshotNamespaceStat)
+	for _, name := range snapshotters {
+		sn := c.SnapshotService(name)
+		for _, ns := range nsList {
+			nsctx := namespaces.WithNamespace(ctx, ns)
+			stat := byNS[ns]
+			if stat == nil {
+				stat = &SnapshotNamespaceStat{Namespace: ns}
+				byNS[ns] = stat
+			}
+			if err := sn.Walk(nsctx, func(ctx context.Context, info snapshots.Info) error {
+				usage, err := sn.Usage(ctx, info.Name)
+				if err != nil {
+					return err
+				}
+				stat.SnapshotCount++
+				if info.Kind == snapshots.KindCommitted {
+					stat.CommittedBytes += usage.Size
+				} else {
+					stat.WritableBytes += usage.Size
+					stat.WritableCount++
+				}
+				return nil
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	stats := make([]SnapshotNamespaceStat, 0, len(byNS))
+	for _, s := range byNS {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Namespace < stats[j].Namespace })
+
+	c.snapshotUsageMu.Lock()
+	if c.snapshotUsage == nil {
+		c.snapshotUsage = map[string]snapshotUsageEntry{}
+	}
+	c.snapshotUsage[snapshotter] = snapshotUsageEntry{stats: stats, fetched: time.Now()}
+	c.snapshotUsageMu.Unlock()
+
+	return stats, nil
+}
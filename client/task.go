@@ -23,6 +23,7 @@ import (
 	"io"
 	goruntime "runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -119,6 +120,10 @@ type CheckpointTaskInfo struct {
 	ParentCheckpoint digest.Digest
 	// Options hold runtime specific settings for checkpointing a task
 	Options any
+	// RWLayerMediaType requests a specific layer media type for the
+	// checkpointed writable layer, e.g. zstd compressed. The default is a
+	// gzip compressed tar.
+	RWLayerMediaType string
 
 	runtime string
 }
@@ -157,6 +162,22 @@ type TaskInfo struct {
 	taskAPIAddress string
 	// taskAPIVersion is task api version, currently supported value is 2 and 3.
 	taskAPIVersion uint32
+
+	// readOnlyRootFS marks the rootfs mounts read-only so the shim remounts
+	// them with MS_RDONLY when setting up the task.
+	readOnlyRootFS bool
+
+	// creationTimeout bounds the Create and Start rpcs for the task,
+	// defaulting to defaultCreationTimeout when left zero.
+	creationTimeout time.Duration
+
+	// specOpts are applied to the container's spec and persisted before the
+	// task is created.
+	specOpts []oci.SpecOpts
+
+	// firstByteTimer measures the scheduling delay, see
+	// WithSchedulingDelayTimer.
+	firstByteTimer *cio.FirstByteTimer
 }
 
 // Runtime name for the container
@@ -220,7 +241,54 @@ type Task interface {
 	Spec(context.Context) (*oci.Spec, error)
 }
 
+// TaskExtensions are the optional helpers implemented by tasks this
+// client creates, kept off the Task interface so external Task
+// implementations do not break as helpers are added. Obtain them with a
+// type assertion:
+//
+//	if ext, ok := task.(client.TaskExtensions); ok { ... }
+type TaskExtensions interface {
+	Task
+
+	// OOMNotify subscribes to out-of-memory kill notifications for the
+	// task's cgroup. The returned channel is closed when the context is
+	// cancelled or the event stream ends.
+	OOMNotify(context.Context) (<-chan OOMEvent, error)
+	// StdinWriter opens a new writer connected to the task's stdin,
+	// letting data be injected after the task has started without
+	// holding the writer the task was created with.
+	StdinWriter(context.Context) (io.WriteCloser, error)
+	// SchedulingDelay reports the time between Start being called and
+	// the first byte of output, for tasks created with a scheduling
+	// delay timer.
+	SchedulingDelay(context.Context) (time.Duration, error)
+	// WatchTask multiplexes the task's status changes, OOM events and
+	// exec exits into one typed channel, closed when the task is
+	// deleted or the context is cancelled.
+	WatchTask(context.Context) (<-chan TaskEvent, error)
+	// ExecBatch runs a sequence of exec processes serially inside the
+	// task, capturing each one's output and exit code.
+	ExecBatch(context.Context, []*ExecBatchEntry, ...ExecBatchOpt) ([]*ExecBatchResult, error)
+	// SignalAllExecs delivers the signal to every running exec process
+	// without touching the init process; WithExcludeExec exempts
+	// individual execs.
+	SignalAllExecs(context.Context, syscall.Signal, ...KillOpts) error
+	// UpdateSeccompProfile validates and replaces the seccomp profile in
+	// the task's stored spec; processes created from now on use it.
+	UpdateSeccompProfile(context.Context, *specs.LinuxSeccomp) error
+	// Sandbox returns the sandbox the task's container belongs to,
+	// failing with NotFound for sandboxless tasks.
+	Sandbox(context.Context) (Sandbox, error)
+}
+
 var _ = (Task)(&task{})
+var _ = (TaskExtensions)(&task{})
+
+// defaultCreationTimeout bounds task Create and Start rpcs when no
+// explicit timeout is requested, so a missing shim binary or
+// misconfigured runtime fails instead of hanging on callers without a
+// deadline on their context.
+const defaultCreationTimeout = 120 * time.Second
 
 type task struct {
 	client *Client
@@ -229,6 +297,17 @@ type task struct {
 	io  cio.IO
 	id  string
 	pid uint32
+
+	// creationTimeout bounds the Start rpc, set from the task's creation
+	// options.
+	creationTimeout time.Duration
+
+	// stdinMu serializes writes from concurrent StdinWriter users.
+	stdinMu sync.Mutex
+
+	// startedAt and firstByteTimer measure the task's scheduling delay.
+	startedAt      time.Time
+	firstByteTimer *cio.FirstByteTimer
 }
 
 // Spec returns the current OCI specification for the task
@@ -253,7 +332,15 @@ func (t *task) Start(ctx context.Context) error {
 	)
 	defer span.End()
 
-	r, err := t.client.TaskService().Start(ctx, &tasks.StartRequest{
+	timeout := t.creationTimeout
+	if timeout == 0 {
+		timeout = defaultCreationTimeout
+	}
+	sctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	t.startedAt = time.Now()
+	r, err := t.client.TaskService().Start(sctx, &tasks.StartRequest{
 		ContainerID: t.id,
 	})
 	if err != nil {
@@ -261,7 +348,18 @@ func (t *task) Start(ctx context.Context) error {
 			t.io.Cancel()
 			t.io.Close()
 		}
-		return errgrpc.ToNative(err)
+		err = errgrpc.ToNative(err)
+		if errdefs.IsDeadlineExceeded(err) && sctx.Err() != nil && ctx.Err() == nil {
+			// The task is stuck starting, clean up any partial state
+			// before reporting the timeout.
+			dctx, dcancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+			defer dcancel()
+			t.client.TaskService().Delete(dctx, &tasks.DeleteTaskRequest{
+				ContainerID: t.id,
+			})
+			return fmt.Errorf("task start timed out after %v: %w", timeout, context.DeadlineExceeded)
+		}
+		return err
 	}
 	span.SetAttributes(tracing.Attribute("task.pid", r.Pid))
 	t.pid = r.Pid
@@ -437,6 +535,16 @@ func (t *task) Delete(ctx context.Context, opts ...ProcessDeleteOpts) (*ExitStat
 	if t.io != nil {
 		t.io.Close()
 	}
+
+	// Ephemeral containers are single-use: their snapshot and container
+	// record go away with the task.
+	if t.c != nil {
+		if labels, err := t.c.Labels(ctx); err == nil && labels[ephemeralLabel] == "true" {
+			if err := t.c.Delete(ctx, WithSnapshotCleanup); err != nil && !errdefs.IsNotFound(err) {
+				return es, fmt.Errorf("task deleted but ephemeral container cleanup failed: %w", err)
+			}
+		}
+	}
 	return es, nil
 }
 
@@ -618,7 +726,7 @@ func (t *task) Checkpoint(ctx context.Context, opts ...CheckpointTaskOpts) (Imag
 		index.Annotations["image.name"] = cr.Image
 	}
 	if cr.SnapshotKey != "" {
-		if err := t.checkpointRWSnapshot(ctx, &index, cr.Snapshotter, cr.SnapshotKey); err != nil {
+		if err := t.checkpointRWSnapshot(ctx, &index, cr.Snapshotter, cr.SnapshotKey, i.RWLayerMediaType); err != nil {
 			return nil, err
 		}
 	}
@@ -641,6 +749,10 @@ func (t *task) Checkpoint(ctx context.Context, opts ...CheckpointTaskOpts) (Imag
 
 // UpdateTaskInfo allows updated specific settings to be changed on a task
 type UpdateTaskInfo struct {
+	// specAnnotations rewrites annotations in the container's stored
+	// spec, an empty value removing the key. See WithSpecAnnotation.
+	specAnnotations map[string]string
+
 	// Resources updates a tasks resource constraints
 	Resources any
 	// Annotations allows arbitrary and/or experimental resource constraints for task update
@@ -674,10 +786,58 @@ func (t *task) Update(ctx context.Context, opts ...UpdateTaskOpts) error {
 	if i.Annotations != nil {
 		request.Annotations = i.Annotations
 	}
+
+	if len(i.specAnnotations) > 0 {
+		if err := t.updateSpecAnnotations(ctx, i.specAnnotations); err != nil {
+			return err
+		}
+	}
+	if i.Resources == nil && i.Annotations == nil {
+		// spec annotation only update, nothing to send to the runtime
+		return nil
+	}
 	_, err := t.client.TaskService().Update(ctx, request)
 	return errgrpc.ToNative(err)
 }
 
+// updateSpecAnnotations rewrites annotations in the container's stored
+// spec, journaling the update time in the container's labels. Task.Spec
+// reads the stored spec, so the change is visible immediately.
+func (t *task) updateSpecAnnotations(ctx context.Context, annotations map[string]string) error {
+	r, err := t.client.ContainerService().Get(ctx, t.id)
+	if err != nil {
+		return err
+	}
+	var spec oci.Spec
+	if err := typeurl.UnmarshalTo(r.Spec, &spec); err != nil {
+		return err
+	}
+	if spec.Annotations == nil {
+		spec.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		if v == "" {
+			delete(spec.Annotations, k)
+		} else {
+			spec.Annotations[k] = v
+		}
+	}
+	if r.Spec, err = typeurl.MarshalAnyToProto(&spec); err != nil {
+		return err
+	}
+	if r.Labels == nil {
+		r.Labels = map[string]string{}
+	}
+	// journal when the spec annotations were last rewritten
+	r.Labels[specAnnotationsUpdatedLabel] = time.Now().UTC().Format(time.RFC3339)
+	_, err = t.client.ContainerService().Update(ctx, r, "spec", "labels."+specAnnotationsUpdatedLabel)
+	return err
+}
+
+// specAnnotationsUpdatedLabel journals the last runtime update of the
+// container's spec annotations.
+const specAnnotationsUpdatedLabel = "containerd.io/spec-annotations.updated"
+
 func (t *task) LoadProcess(ctx context.Context, id string, ioAttach cio.Attach) (Process, error) {
 	if id == t.id && ioAttach == nil {
 		return t, nil
@@ -749,10 +909,13 @@ func (t *task) checkpointTask(ctx context.Context, index *v1.Index, request *tas
 	return nil
 }
 
-func (t *task) checkpointRWSnapshot(ctx context.Context, index *v1.Index, snapshotterName string, id string) error {
+func (t *task) checkpointRWSnapshot(ctx context.Context, index *v1.Index, snapshotterName string, id string, mediaType string) error {
 	opts := []diff.Opt{
 		diff.WithReference(fmt.Sprintf("checkpoint-rw-%s", id)),
 	}
+	if mediaType != "" {
+		opts = append(opts, diff.WithMediaType(mediaType))
+	}
 	rw, err := rootfs.CreateDiff(ctx, id, t.client.SnapshotService(snapshotterName), t.client.DiffService(), opts...)
 	if err != nil {
 		return err
@@ -815,3 +978,21 @@ func isCheckpointPathExist(runtime string, v any) bool {
 
 	return false
 }
+
+// Sandbox returns the sandbox the task's container belongs to, from the
+// metadata relationship or the SandboxIDLabel; tasks without a sandbox
+// fail with NotFound.
+func (t *task) Sandbox(ctx context.Context) (Sandbox, error) {
+	info, err := t.client.ContainerService().Get(ctx, t.id)
+	if err != nil {
+		return nil, err
+	}
+	sandboxID := info.SandboxID
+	if sandboxID == "" {
+		sandboxID = info.Labels[SandboxIDLabel]
+	}
+	if sandboxID == "" {
+		return nil, fmt.Errorf("task %s has no sandbox: %w", t.id, errdefs.ErrNotFound)
+	}
+	return t.client.LoadSandbox(ctx, sandboxID)
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/containerd/cgroups/v3/cgroup1/stats"
+	v2 "github.com/containerd/cgroups/v3/cgroup2/stats"
+	eventstypes "github.com/containerd/containerd/api/events"
+	"github.com/containerd/typeurl/v2"
+)
+
+// taskOOMEventFilter selects OOM notifications published by the shim and
+// fanned out through the daemon's event service.
+const taskOOMEventFilter = `topic=="/tasks/oom"`
+
+// OOMEvent is delivered to OOMNotify subscribers when a process in the
+// task's cgroup is killed by the kernel's OOM killer.
+type OOMEvent struct {
+	// ContainerID is the ID of the task whose cgroup triggered the event
+	ContainerID string
+	// Timestamp of the OOM notification
+	Timestamp time.Time
+	// MemoryLimit is the memory limit configured for the task in bytes,
+	// zero when unknown
+	MemoryLimit int64
+	// MemoryUsage is the task's memory usage in bytes sampled when the
+	// notification was received, zero when it could not be collected
+	MemoryUsage int64
+}
+
+// OOMNotify subscribes to out-of-memory kill notifications for the task's
+// cgroup. Memory limit and usage are sampled from the task's metrics when
+// the notification is received, best effort: the OOM killed process may
+// already have released its memory by then.
+func (t *task) OOMNotify(ctx context.Context) (<-chan OOMEvent, error) {
+	evCh, errCh := t.client.EventService().Subscribe(ctx, taskOOMEventFilter)
+	ch := make(chan OOMEvent)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-errCh:
+				return
+			case env, ok := <-evCh:
+				if !ok {
+					return
+				}
+				e, err := typeurl.UnmarshalAny(env.Event)
+				if err != nil {
+					continue
+				}
+				oom, ok := e.(*eventstypes.TaskOOM)
+				if !ok || oom.ContainerID != t.id {
+					continue
+				}
+				ev := OOMEvent{
+					ContainerID: t.id,
+					Timestamp:   env.Timestamp,
+				}
+				ev.MemoryLimit, ev.MemoryUsage = t.memoryStats(ctx)
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// memoryStats samples the task's current memory limit and usage from its
+// runtime metrics, returning zero values when they cannot be collected.
+func (t *task) memoryStats(ctx context.Context) (limit, usage int64) {
+	m, err := t.Metrics(ctx)
+	if err != nil || m.Data == nil {
+		return 0, 0
+	}
+	data, err := typeurl.UnmarshalAny(m.Data)
+	if err != nil {
+		return 0, 0
+	}
+	switch d := data.(type) {
+	case *v1.Metrics:
+		if d.Memory != nil && d.Memory.Usage != nil {
+			return int64(d.Memory.Usage.Limit), int64(d.Memory.Usage.Usage)
+		}
+	case *v2.Metrics:
+		if d.Memory != nil {
+			return int64(d.Memory.UsageLimit), int64(d.Memory.Usage)
+		}
+	}
+	return 0, 0
+}
@@ -20,14 +20,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"syscall"
+	"time"
 
 	"github.com/containerd/containerd/api/types"
 	"github.com/containerd/containerd/api/types/runc/options"
+	"github.com/containerd/containerd/v2/core/containers"
 	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/internal/idempotency"
+	"github.com/containerd/containerd/v2/pkg/oci"
 	"github.com/containerd/errdefs"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"google.golang.org/grpc/metadata"
 )
 
 // NewTaskOpts allows the caller to set options on a new task
@@ -68,6 +75,71 @@ func WithTaskAPIEndpoint(address string, version uint32) NewTaskOpts {
 	}
 }
 
+// WithIdempotencyKey returns a context carrying an idempotency key for
+// the task operation issued with it. The daemon deduplicates Start, Exec
+// and Delete requests with the same key (scoped per namespace) for ten
+// minutes, returning the original response instead of re-executing, so a
+// network-level retry cannot start or exec a process twice.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, idempotency.MetadataKey, key)
+}
+
+// WithTaskCreationTimeout bounds the task's Create and Start rpcs with the
+// given timeout instead of the default of 120s. When the deadline expires
+// the client deletes any partially created task state and returns
+// errdefs.ErrDeadlineExceeded.
+func WithTaskCreationTimeout(d time.Duration) NewTaskOpts {
+	return func(ctx context.Context, c *Client, ti *TaskInfo) error {
+		if d <= 0 {
+			return fmt.Errorf("task creation timeout must be positive: %w", errdefs.ErrInvalidArgument)
+		}
+		ti.creationTimeout = d
+		return nil
+	}
+}
+
+// WithReadOnlyRootFS sets up the task with a read-only root filesystem. The
+// container's spec is updated to set Root.Readonly and the rootfs mounts are
+// remounted read-only when the shim sets up the task. The option is
+// idempotent if the spec is already read-only.
+func WithReadOnlyRootFS() NewTaskOpts {
+	return func(ctx context.Context, c *Client, ti *TaskInfo) error {
+		ti.readOnlyRootFS = true
+		ti.specOpts = append(ti.specOpts, func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+			if s.Root == nil {
+				s.Root = &specs.Root{}
+			}
+			s.Root.Readonly = true
+			return nil
+		})
+		return nil
+	}
+}
+
+// WithReadOnlyMounts marks every bind mount in the container's spec
+// read-only. Bind flags are preserved so the mounts still resolve, only the
+// rw/ro state is rewritten.
+func WithReadOnlyMounts() NewTaskOpts {
+	return func(ctx context.Context, c *Client, ti *TaskInfo) error {
+		ti.specOpts = append(ti.specOpts, func(_ context.Context, _ oci.Client, _ *containers.Container, s *oci.Spec) error {
+			for i, m := range s.Mounts {
+				if m.Type != "bind" && !slices.Contains(m.Options, "bind") && !slices.Contains(m.Options, "rbind") {
+					continue
+				}
+				opts := make([]string, 0, len(m.Options)+1)
+				for _, o := range m.Options {
+					if o != "rw" && o != "ro" {
+						opts = append(opts, o)
+					}
+				}
+				s.Mounts[i].Options = append(opts, "ro")
+			}
+			return nil
+		})
+		return nil
+	}
+}
+
 // WithTaskCheckpoint allows a task to be created with live runtime and memory data from a
 // previous checkpoint. Additional software such as CRIU may be required to
 // restore a task from a checkpoint
@@ -94,6 +166,15 @@ func WithTaskCheckpoint(im Image) NewTaskOpts {
 	}
 }
 
+// WithCheckpointZstd compresses the checkpointed writable layer with zstd
+// instead of gzip, trading a slightly larger layer for much faster
+// compression. Applying such a checkpoint relies on the apply side's
+// compression detection, present in all current daemons.
+func WithCheckpointZstd(r *CheckpointTaskInfo) error {
+	r.RWLayerMediaType = ocispec.MediaTypeImageLayerZstd
+	return nil
+}
+
 // WithCheckpointName sets the image name for the checkpoint
 func WithCheckpointName(name string) CheckpointTaskOpts {
 	return func(r *CheckpointTaskInfo) error {
@@ -188,6 +269,9 @@ type KillInfo struct {
 	All bool
 	// ExecID is the ID of a process to kill
 	ExecID string
+	// ExcludedExecs are exec IDs SignalAllExecs leaves untouched;
+	// ignored by Kill
+	ExcludedExecs []string
 }
 
 // KillOpts allows options to be set for the killing of a process
@@ -207,6 +291,15 @@ func WithKillExecID(execID string) KillOpts {
 	}
 }
 
+// WithExcludeExec exempts the exec from SignalAllExecs; it may be given
+// multiple times.
+func WithExcludeExec(execID string) KillOpts {
+	return func(ctx context.Context, i *KillInfo) error {
+		i.ExcludedExecs = append(i.ExcludedExecs, execID)
+		return nil
+	}
+}
+
 // WithResources sets the provided resources for task updates. Resources must be
 // either a *specs.LinuxResources or a *specs.WindowsResources
 func WithResources(resources any) UpdateTaskOpts {
@@ -223,6 +316,102 @@ func WithResources(resources any) UpdateTaskOpts {
 	}
 }
 
+// hierarchicalUpdateAnnotation mirrors the runc shim's
+// HierarchicalUpdateAnnotation constant.
+const hierarchicalUpdateAnnotation = "containerd.io/update/hierarchical"
+
+// WithHierarchicalResources updates the task's resources like
+// WithResources and additionally asks the runtime to walk the container's
+// cgroup tree, applying the constraints to every child cgroup. Needed for
+// containers whose init (e.g. systemd) spawns its own cgroups, which a
+// top-level update does not reach.
+func WithHierarchicalResources(resources *specs.LinuxResources) UpdateTaskOpts {
+	return func(ctx context.Context, client *Client, r *UpdateTaskInfo) error {
+		r.Resources = resources
+		if r.Annotations == nil {
+			r.Annotations = make(map[string]string)
+		}
+		r.Annotations[hierarchicalUpdateAnnotation] = "true"
+		return nil
+	}
+}
+
+// cpuResources resolves the LinuxResources structure being assembled for
+// the update, creating it on first use so the CPU opts compose with each
+// other and with WithResources (last write per field wins).
+func cpuResources(r *UpdateTaskInfo) (*specs.LinuxCPU, error) {
+	if r.Resources == nil {
+		r.Resources = &specs.LinuxResources{}
+	}
+	res, ok := r.Resources.(*specs.LinuxResources)
+	if !ok {
+		return nil, fmt.Errorf("CPU opts require *specs.LinuxResources, have %T: %w", r.Resources, errdefs.ErrInvalidArgument)
+	}
+	if res.CPU == nil {
+		res.CPU = &specs.LinuxCPU{}
+	}
+	return res.CPU, nil
+}
+
+// WithCPUQuota updates the task's CFS quota, with period setting the CFS
+// period when non-zero. A quota of -1 removes the limit, mirroring the
+// cgroup semantics.
+func WithCPUQuota(quota int64, period uint64) UpdateTaskOpts {
+	return func(ctx context.Context, client *Client, r *UpdateTaskInfo) error {
+		cpu, err := cpuResources(r)
+		if err != nil {
+			return err
+		}
+		cpu.Quota = &quota
+		if period > 0 {
+			cpu.Period = &period
+		}
+		return nil
+	}
+}
+
+// WithCPUShares updates the task's CPU shares.
+func WithCPUShares(shares uint64) UpdateTaskOpts {
+	return func(ctx context.Context, client *Client, r *UpdateTaskInfo) error {
+		cpu, err := cpuResources(r)
+		if err != nil {
+			return err
+		}
+		cpu.Shares = &shares
+		return nil
+	}
+}
+
+// WithCPUPeriod updates the task's CFS period.
+func WithCPUPeriod(period uint64) UpdateTaskOpts {
+	return func(ctx context.Context, client *Client, r *UpdateTaskInfo) error {
+		cpu, err := cpuResources(r)
+		if err != nil {
+			return err
+		}
+		cpu.Period = &period
+		return nil
+	}
+}
+
+// WithSpecAnnotation updates a single annotation in the container's
+// stored OCI spec as part of Task.Update, making scheduling metadata
+// readable from the spec immediately (Task.Spec reflects it). An empty
+// value removes the annotation, and the update time is journaled in the
+// container's labels.
+func WithSpecAnnotation(key, value string) UpdateTaskOpts {
+	return func(ctx context.Context, client *Client, r *UpdateTaskInfo) error {
+		if key == "" {
+			return fmt.Errorf("annotation key cannot be empty: %w", errdefs.ErrInvalidArgument)
+		}
+		if r.specAnnotations == nil {
+			r.specAnnotations = map[string]string{}
+		}
+		r.specAnnotations[key] = value
+		return nil
+	}
+}
+
 // WithAnnotations sets the provided annotations for task updates.
 func WithAnnotations(annotations map[string]string) UpdateTaskOpts {
 	return func(ctx context.Context, client *Client, r *UpdateTaskInfo) error {
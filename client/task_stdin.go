@@ -0,0 +1,87 @@
+//go:build !windows
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"syscall"
+
+	"github.com/containerd/errdefs"
+	"github.com/containerd/fifo"
+)
+
+// StdinWriter opens a new writer connected to the task's stdin fifo so
+// data can be injected after the task has started, without holding the
+// writer the task was created with. Tasks created without stdin (for
+// example with cio.NullIO) are rejected with ErrNotImplemented.
+// Concurrent writers on the same task are serialized per write. Closing
+// the returned writer signals EOF to the process through CloseIO without
+// affecting the task itself; the process only sees the EOF once every
+// other stdin writer — including the stream the task was created with —
+// has been closed as well, and stdin can only be closed once.
+func (t *task) StdinWriter(ctx context.Context) (io.WriteCloser, error) {
+	if t.io == nil {
+		return nil, fmt.Errorf("task has no IO attached: %w", errdefs.ErrNotImplemented)
+	}
+	path := t.io.Config().Stdin
+	if path == "" {
+		return nil, fmt.Errorf("task was created without stdin: %w", errdefs.ErrNotImplemented)
+	}
+
+	f, err := fifo.OpenFifo(ctx, path, syscall.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin fifo: %w", err)
+	}
+	return &stdinWriter{
+		t: t,
+		f: f,
+		// keep namespace and other values for the deferred CloseIO while
+		// detaching from the caller's cancellation
+		ctx: context.WithoutCancel(ctx),
+	}, nil
+}
+
+type stdinWriter struct {
+	t    *task
+	f    io.WriteCloser
+	ctx  context.Context
+	once sync.Once
+}
+
+func (w *stdinWriter) Write(p []byte) (int, error) {
+	w.t.stdinMu.Lock()
+	defer w.t.stdinMu.Unlock()
+	return w.f.Write(p)
+}
+
+// Close releases the fifo handle and asks the shim to close the process's
+// stdin, delivering EOF; the task keeps running.
+func (w *stdinWriter) Close() error {
+	var err error
+	w.once.Do(func() {
+		err = w.f.Close()
+		if cerr := w.t.CloseIO(w.ctx, WithStdinCloser); cerr != nil && err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
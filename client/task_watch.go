@@ -0,0 +1,131 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"time"
+
+	eventstypes "github.com/containerd/containerd/api/events"
+	"github.com/containerd/typeurl/v2"
+)
+
+// TaskEventKind classifies the events WatchTask delivers.
+type TaskEventKind string
+
+const (
+	// TaskEventStatusChanged covers lifecycle transitions: started,
+	// paused, resumed and the init process exiting.
+	TaskEventStatusChanged TaskEventKind = "status-changed"
+	// TaskEventOOM is delivered when the kernel OOM killer acted inside
+	// the task's cgroup.
+	TaskEventOOM TaskEventKind = "oom"
+	// TaskEventExecExited is delivered when an exec'd process exits.
+	TaskEventExecExited TaskEventKind = "exec-exited"
+	// TaskEventProcessSignaled is reserved for runtimes reporting
+	// delivered signals; the core runtimes do not emit it today.
+	TaskEventProcessSignaled TaskEventKind = "process-signaled"
+)
+
+// TaskEvent is one occurrence on a watched task; Payload carries the
+// kind-specific event type from the api/events package.
+type TaskEvent struct {
+	Kind      TaskEventKind
+	Timestamp time.Time
+	Payload   any
+}
+
+// WatchTask multiplexes everything happening to the task — lifecycle
+// status changes, OOM kills and exec exits — into one typed channel,
+// replacing the combination of Wait, OOMNotify and manual event bus
+// subscriptions. The channel closes when the task is deleted or the
+// context is cancelled.
+func (t *task) WatchTask(ctx context.Context) (<-chan TaskEvent, error) {
+	evCh, errCh := t.client.EventService().Subscribe(ctx, `topic~="^/tasks/"`)
+	ch := make(chan TaskEvent)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-errCh:
+				return
+			case env, ok := <-evCh:
+				if !ok {
+					return
+				}
+				e, err := typeurl.UnmarshalAny(env.Event)
+				if err != nil {
+					continue
+				}
+				ev, done := t.classify(e)
+				if ev == nil && !done {
+					continue
+				}
+				if ev != nil {
+					ev.Timestamp = env.Timestamp
+					select {
+					case ch <- *ev:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if done {
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// classify maps a raw event to the task's typed event, returning done when
+// the task is gone and the watch should end.
+func (t *task) classify(e any) (*TaskEvent, bool) {
+	switch ev := e.(type) {
+	case *eventstypes.TaskStart:
+		if ev.ContainerID == t.id {
+			return &TaskEvent{Kind: TaskEventStatusChanged, Payload: ev}, false
+		}
+	case *eventstypes.TaskPaused:
+		if ev.ContainerID == t.id {
+			return &TaskEvent{Kind: TaskEventStatusChanged, Payload: ev}, false
+		}
+	case *eventstypes.TaskResumed:
+		if ev.ContainerID == t.id {
+			return &TaskEvent{Kind: TaskEventStatusChanged, Payload: ev}, false
+		}
+	case *eventstypes.TaskOOM:
+		if ev.ContainerID == t.id {
+			return &TaskEvent{Kind: TaskEventOOM, Payload: ev}, false
+		}
+	case *eventstypes.TaskExit:
+		if ev.ContainerID != t.id {
+			return nil, false
+		}
+		if ev.ID != "" && ev.ID != t.id {
+			return &TaskEvent{Kind: TaskEventExecExited, Payload: ev}, false
+		}
+		return &TaskEvent{Kind: TaskEventStatusChanged, Payload: ev}, false
+	case *eventstypes.TaskDelete:
+		if ev.ContainerID == t.id {
+			return &TaskEvent{Kind: TaskEventStatusChanged, Payload: ev}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,76 @@
+//go:build fips
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// fipsCipherSuites are the TLS 1.2 cipher suites approved by FIPS 140-2.
+// TLS 1.3 suites are not configurable in Go and are all FIPS approved.
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+}
+
+// WithFIPSTLS configures mutual TLS restricted to FIPS 140-2 approved
+// cipher suites and TLS 1.2 or newer, with no fallback to other suites.
+// Connections from or to peers that cannot negotiate an approved suite are
+// rejected. Only available on builds with the fips tag; without it the
+// option is a no-op.
+func WithFIPSTLS(certFile, keyFile, caFile string) Opt {
+	return func(c *clientOpts) error {
+		cfg, err := fipsTLSConfig(certFile, keyFile, caFile)
+		if err != nil {
+			return err
+		}
+		c.extraDialOpts = append(c.extraDialOpts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+		return nil
+	}
+}
+
+func fipsTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client key pair: %w", err)
+	}
+	caCert, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in CA file %q", caFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: fipsCipherSuites,
+	}, nil
+}
@@ -0,0 +1,28 @@
+//go:build !fips
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+// WithFIPSTLS configures mutual TLS restricted to FIPS 140-2 approved
+// cipher suites. On builds without the fips tag it is a no-op and the
+// connection keeps whatever transport security was otherwise configured.
+func WithFIPSTLS(certFile, keyFile, caFile string) Opt {
+	return func(c *clientOpts) error {
+		return nil
+	}
+}
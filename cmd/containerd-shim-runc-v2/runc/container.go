@@ -464,7 +464,13 @@ func (c *Container) Update(ctx context.Context, r *task.UpdateTaskRequest) error
 	if err != nil {
 		return err
 	}
-	return p.(*process.Init).Update(ctx, r.Resources)
+	if err := p.(*process.Init).Update(ctx, r.Resources); err != nil {
+		return err
+	}
+	if r.Annotations[HierarchicalUpdateAnnotation] == "true" {
+		return c.updateChildCgroups(ctx, r)
+	}
+	return nil
 }
 
 // HasPid returns true if the container owns a specific pid
@@ -0,0 +1,112 @@
+//go:build linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package runc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cgroup1 "github.com/containerd/cgroups/v3/cgroup1"
+	cgroupsv2 "github.com/containerd/cgroups/v3/cgroup2"
+	"github.com/containerd/containerd/api/runtime/task/v3"
+	"github.com/containerd/log"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// HierarchicalUpdateAnnotation asks Update to walk the container's cgroup
+// tree and apply the new resources to every child cgroup as well, for
+// containers whose init (e.g. systemd) spawns its own cgroups.
+const HierarchicalUpdateAnnotation = "containerd.io/update/hierarchical"
+
+const unifiedMountpoint = "/sys/fs/cgroup"
+
+// updateChildCgroups applies the updated resources depth first to every
+// cgroup below the container's own. The top-level cgroup is left to the
+// regular runtime update.
+func (c *Container) updateChildCgroups(ctx context.Context, r *task.UpdateTaskRequest) error {
+	var resources specs.LinuxResources
+	if err := json.Unmarshal(r.Resources.GetValue(), &resources); err != nil {
+		return err
+	}
+
+	switch c.Cgroup().(type) {
+	case *cgroupsv2.Manager:
+		group, err := cgroupsv2.PidGroupPath(int(c.Pid()))
+		if err != nil {
+			return err
+		}
+		root := filepath.Join(unifiedMountpoint, group)
+		return walkChildCgroups(root, func(path string) error {
+			m, err := cgroupsv2.Load(strings.TrimPrefix(path, unifiedMountpoint))
+			if err != nil {
+				return err
+			}
+			return m.Update(cgroupsv2.ToResources(&resources))
+		})
+	case cgroup1.Cgroup:
+		// enumerate children from the memory hierarchy; children a
+		// supervisor created in only some subsystems are skipped when
+		// loading reports them deleted
+		rel, err := cgroup1.PidPath(int(c.Pid()))("memory")
+		if err != nil {
+			return err
+		}
+		base := filepath.Join(unifiedMountpoint, "memory")
+		return walkChildCgroups(filepath.Join(base, rel), func(path string) error {
+			child, err := cgroup1.Load(cgroup1.StaticPath(strings.TrimPrefix(path, base)))
+			if err != nil {
+				if errors.Is(err, cgroup1.ErrCgroupDeleted) {
+					log.G(ctx).WithField("cgroup", path).Debug("skipping partial child cgroup")
+					return nil
+				}
+				return err
+			}
+			return child.Update(&resources)
+		})
+	default:
+		return nil
+	}
+}
+
+// walkChildCgroups runs fn for every cgroup directory below root, depth
+// first, skipping root itself.
+func walkChildCgroups(root string, fn func(path string) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// a child exited while walking
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() || path == root {
+			return nil
+		}
+		if err := fn(path); err != nil {
+			return fmt.Errorf("updating child cgroup %s: %w", path, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+//go:build linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package task
+
+import (
+	"syscall"
+
+	cgroup1 "github.com/containerd/cgroups/v3/cgroup1"
+	cgroupsv2 "github.com/containerd/cgroups/v3/cgroup2"
+	"golang.org/x/sys/unix"
+
+	"github.com/containerd/containerd/v2/cmd/containerd-shim-runc-v2/runc"
+	"github.com/containerd/containerd/v2/pkg/exitinfo"
+	runcC "github.com/containerd/go-runc"
+)
+
+// collectExitInfo builds the enriched exit event from the container's
+// cgroup just before it is reaped. Every field degrades to its zero
+// value when the kernel or cgroup version does not expose it.
+func collectExitInfo(e runcC.Exit, c *runc.Container, execID string) *exitinfo.ExitInfo {
+	info := &exitinfo.ExitInfo{
+		ContainerID: c.ID,
+		ID:          execID,
+		Pid:         uint32(e.Pid),
+		ExitStatus:  uint32(e.Status),
+	}
+	// the 128+n convention marks deaths by signal
+	if e.Status > 128 && e.Status < 128+64 {
+		info.Signal = unix.SignalName(syscall.Signal(e.Status - 128))
+	}
+
+	switch cg := c.Cgroup().(type) {
+	case cgroup1.Cgroup:
+		if stats, err := cg.Stat(cgroup1.IgnoreNotExist); err == nil && stats != nil {
+			if stats.Memory != nil && stats.Memory.Usage != nil {
+				info.MemoryPeakBytes = stats.Memory.Usage.Max
+			}
+			if stats.CPU != nil && stats.CPU.Usage != nil {
+				info.CPUTotalUsec = stats.CPU.Usage.Total / 1000
+			}
+			if stats.MemoryOomControl != nil {
+				info.OOMKilled = stats.MemoryOomControl.OomKill > 0
+			}
+		}
+	case *cgroupsv2.Manager:
+		if stats, err := cg.Stat(); err == nil && stats != nil {
+			if stats.Memory != nil {
+				// v2 exposes the peak only via memory.peak, which the
+				// stats message does not carry; fall back to the final
+				// usage as a lower bound
+				info.MemoryPeakBytes = stats.Memory.Usage
+			}
+			if stats.CPU != nil {
+				info.CPUTotalUsec = stats.CPU.UsageUsec
+			}
+			if stats.MemoryEvents != nil {
+				info.OOMKilled = stats.MemoryEvents.OomKill > 0
+			}
+		}
+	}
+	return info
+}
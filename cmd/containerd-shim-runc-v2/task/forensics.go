@@ -0,0 +1,112 @@
+//go:build linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package task
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	cgroup1 "github.com/containerd/cgroups/v3/cgroup1"
+	cgroupsv2 "github.com/containerd/cgroups/v3/cgroup2"
+	"github.com/containerd/log"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/containerd/containerd/v2/cmd/containerd-shim-runc-v2/runc"
+	"github.com/containerd/containerd/v2/pkg/forensics"
+)
+
+// maybeFreezeForForensics freezes a crashed container's cgroup when the
+// container requested forensics mode, so its remaining processes can be
+// inspected before cleanup. The freeze lifts automatically after the
+// configured window.
+func (s *service) maybeFreezeForForensics(c *runc.Container, exitStatus int) {
+	if exitStatus == 0 {
+		return
+	}
+	window, ok := forensicsWindow(c.Bundle)
+	if !ok {
+		return
+	}
+	ctx := s.context
+
+	if err := freezeCgroup(c.Cgroup(), true); err != nil {
+		log.G(ctx).WithError(err).WithField("container_id", c.ID).Warn("failed to freeze crashed container for forensics")
+		return
+	}
+	log.G(ctx).WithField("container_id", c.ID).WithField("window", window).Info("froze crashed container for forensics")
+
+	if err := s.publisher.Publish(ctx, forensics.ReadyEventTopic, &forensics.Ready{
+		ContainerID: c.ID,
+		ExitStatus:  uint32(exitStatus),
+		Window:      window.String(),
+	}); err != nil {
+		log.G(ctx).WithError(err).Error("failed to publish forensics event")
+	}
+
+	time.AfterFunc(window, func() {
+		if err := freezeCgroup(c.Cgroup(), false); err != nil {
+			log.G(ctx).WithError(err).WithField("container_id", c.ID).Warn("failed to thaw container after forensics window")
+		} else {
+			log.G(ctx).WithField("container_id", c.ID).Info("forensics window elapsed, container thawed")
+		}
+	})
+}
+
+// forensicsWindow reads the forensics annotations from the bundle's spec.
+func forensicsWindow(bundle string) (time.Duration, bool) {
+	data, err := os.ReadFile(filepath.Join(bundle, "config.json"))
+	if err != nil {
+		return 0, false
+	}
+	var spec specs.Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return 0, false
+	}
+	if spec.Annotations[forensics.ModeAnnotation] != "true" {
+		return 0, false
+	}
+	window := forensics.DefaultWindow
+	if v, ok := spec.Annotations[forensics.WindowAnnotation]; ok {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			window = d
+		}
+	}
+	return window, true
+}
+
+// freezeCgroup freezes or thaws either cgroup version.
+func freezeCgroup(cg any, freeze bool) error {
+	switch c := cg.(type) {
+	case cgroup1.Cgroup:
+		if freeze {
+			return c.Freeze()
+		}
+		return c.Thaw()
+	case *cgroupsv2.Manager:
+		if freeze {
+			return c.Freeze()
+		}
+		return c.Thaw()
+	}
+	// no cgroup tracked for the container, try the v2 path by pid is not
+	// possible anymore after exit; nothing to freeze
+	return nil
+}
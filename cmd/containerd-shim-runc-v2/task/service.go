@@ -352,6 +352,14 @@ func (s *service) Start(ctx context.Context, r *taskAPI.StartRequest) (*taskAPI.
 
 // Delete the initial process and container
 func (s *service) Delete(ctx context.Context, r *taskAPI.DeleteRequest) (*taskAPI.DeleteResponse, error) {
+	// a container frozen for forensics must thaw before deletion
+	if r.ExecID == "" {
+		if c, err := s.getContainer(r.ID); err == nil {
+			if err := freezeCgroup(c.Cgroup(), false); err != nil {
+				log.G(ctx).WithError(err).WithField("container_id", r.ID).Debug("failed to thaw container before delete")
+			}
+		}
+	}
 	container, err := s.getContainer(r.ID)
 	if err != nil {
 		return nil, err
@@ -696,6 +704,9 @@ func (s *service) processExits() {
 }
 
 func (s *service) oomEvent(id string) {
+	if dir := os.Getenv("CONTAINERD_OOM_SMAPS_DIR"); dir != "" {
+		s.dumpSmaps(id, dir)
+	}
 	err := s.publisher.Publish(s.context, runtime.TaskOOMEventTopic, &eventstypes.TaskOOM{
 		ContainerID: id,
 	})
@@ -704,6 +715,38 @@ func (s *service) oomEvent(id string) {
 	}
 }
 
+// dumpSmaps preserves /proc/<pid>/smaps of the container's init process in
+// dir so memory mappings can be inspected after the OOM killer has run.
+// Enabled by setting CONTAINERD_OOM_SMAPS_DIR in the shim's environment.
+func (s *service) dumpSmaps(id, dir string) {
+	container, err := s.getContainer(id)
+	if err != nil {
+		return
+	}
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/smaps", container.Pid()))
+	if err != nil {
+		log.G(s.context).WithError(err).WithField("container_id", id).Warn("failed to read smaps for OOM event")
+		return
+	}
+	f, err := os.CreateTemp(dir, fmt.Sprintf("smaps-%s-", id))
+	if err != nil {
+		log.G(s.context).WithError(err).WithField("container_id", id).Warn("failed to create smaps dump for OOM event")
+		return
+	}
+	_, werr := f.Write(data)
+	if err := f.Close(); werr == nil {
+		werr = err
+	}
+	if werr != nil {
+		log.G(s.context).WithError(werr).WithField("container_id", id).Warn("failed to write smaps dump for OOM event")
+		return
+	}
+	log.G(s.context).WithFields(log.Fields{
+		"container_id": id,
+		"path":         f.Name(),
+	}).Info("wrote smaps dump for OOM event")
+}
+
 func (s *service) send(evt any) {
 	select {
 	case s.events <- evt:
@@ -774,6 +817,8 @@ func (s *service) handleProcessExit(e runcC.Exit, c *runc.Container, p process.P
 				WithError(err).
 				Error("failed to stop oom event watcher")
 		}
+		// Keep crashed containers inspectable when forensics mode is on.
+		s.maybeFreezeForForensics(c, e.Status)
 	}
 	s.send(&eventstypes.TaskExit{
 		ContainerID: c.ID,
@@ -782,6 +827,9 @@ func (s *service) handleProcessExit(e runcC.Exit, c *runc.Container, p process.P
 		ExitStatus:  uint32(e.Status),
 		ExitedAt:    protobuf.ToTimestamp(p.ExitedAt()),
 	})
+	// enrich the exit with the reason and a final resource snapshot,
+	// collected while the cgroup still exists
+	s.send(collectExitInfo(e, c, p.ID()))
 	if !isInit {
 		s.lifecycleMu.Lock()
 		s.runningExecs[c]--
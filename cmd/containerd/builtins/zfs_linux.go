@@ -18,4 +18,4 @@
 
 package builtins
 
-import _ "github.com/containerd/zfs/v2/plugin"
+import _ "github.com/containerd/containerd/v2/plugins/snapshots/zfs/plugin"
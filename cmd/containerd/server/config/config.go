@@ -39,6 +39,7 @@ import (
 	"dario.cat/mergo"
 	"github.com/pelletier/go-toml/v2"
 
+	"github.com/containerd/containerd/v2/internal/tomlext"
 	"github.com/containerd/containerd/v2/version"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
@@ -404,6 +405,16 @@ type ProxyPlugin struct {
 	Platform     string            `toml:"platform"`
 	Exports      map[string]string `toml:"exports"`
 	Capabilities []string          `toml:"capabilities"`
+
+	// FastTimeout bounds quick metadata operations against a proxy
+	// snapshotter (Stat, Usage, Mounts, Update, Walk), 10s by default.
+	FastTimeout tomlext.Duration `toml:"fast_timeout"`
+	// SlowTimeout bounds operations that may move data around (Prepare,
+	// View, Commit, Remove, Cleanup), 2m by default.
+	SlowTimeout tomlext.Duration `toml:"slow_timeout"`
+	// Retries is how often idempotent read operations are retried after
+	// Unavailable or DeadlineExceeded responses, 2 by default.
+	Retries int `toml:"retries"`
 }
 
 // Decode unmarshals a plugin specific configuration by plugin id
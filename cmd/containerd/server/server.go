@@ -33,7 +33,10 @@ import (
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 
 	diffapi "github.com/containerd/containerd/api/services/diff/v1"
 	sbapi "github.com/containerd/containerd/api/services/sandbox/v1"
@@ -327,8 +330,18 @@ func LoadPlugins(ctx context.Context, config *srvconfig.Config) ([]plugin.Regist
 		case string(plugins.SnapshotPlugin), "snapshot":
 			t = plugins.SnapshotPlugin
 			ssname := name
+			var ssopts []ssproxy.Opt
+			if d := time.Duration(pp.FastTimeout); d > 0 {
+				ssopts = append(ssopts, ssproxy.WithFastTimeout(d))
+			}
+			if d := time.Duration(pp.SlowTimeout); d > 0 {
+				ssopts = append(ssopts, ssproxy.WithSlowTimeout(d))
+			}
+			if pp.Retries > 0 {
+				ssopts = append(ssopts, ssproxy.WithRetries(pp.Retries))
+			}
 			f = func(conn *grpc.ClientConn) any {
-				return ssproxy.NewSnapshotter(ssapi.NewSnapshotsClient(conn), ssname)
+				return ssproxy.NewSnapshotter(ssapi.NewSnapshotsClient(conn), ssname, ssopts...)
 			}
 
 		case string(plugins.ContentPlugin), "content":
@@ -375,6 +388,14 @@ func LoadPlugins(ctx context.Context, config *srvconfig.Config) ([]plugin.Regist
 				if err != nil {
 					return nil, err
 				}
+				if t == plugins.SnapshotPlugin {
+					// Probe on dial so a dead snapshotter shows up as a
+					// degraded plugin in introspection instead of being
+					// discovered at first use.
+					if err := probeProxyPlugin(ic.Context, conn); err != nil {
+						return nil, fmt.Errorf("proxy plugin at %q is not healthy: %w", address, err)
+					}
+				}
 				return f(conn), nil
 			},
 		})
@@ -386,6 +407,26 @@ func LoadPlugins(ctx context.Context, config *srvconfig.Config) ([]plugin.Regist
 	return registry.Graph(filter(config.DisabledPlugins)), nil
 }
 
+// probeProxyPlugin checks the proxy's grpc health service with a short
+// deadline. Plugins that do not implement the health service pass the
+// probe, only an unreachable or explicitly unhealthy plugin fails it.
+func probeProxyPlugin(ctx context.Context, conn *grpc.ClientConn) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil
+		}
+		return err
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health status %s", resp.Status)
+	}
+	return nil
+}
+
 type proxyClients struct {
 	m       sync.Mutex
 	clients map[string]*grpc.ClientConn
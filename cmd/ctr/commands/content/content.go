@@ -56,6 +56,7 @@ var (
 			pushObjectCommand,
 			setLabelsCommand,
 			pruneCommand,
+			verifyCommand,
 		},
 	}
 
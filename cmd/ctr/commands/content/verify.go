@@ -0,0 +1,123 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/containerd/v2/cmd/ctr/commands"
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/urfave/cli/v2"
+)
+
+var verifyCommand = &cli.Command{
+	Name:      "verify",
+	Usage:     "Re-hash blobs in the content store and report corruption",
+	ArgsUsage: "[flags] [<filter>, ...]",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Number of blobs to re-hash concurrently",
+			Value: 4,
+		},
+		&cli.Int64Flag{
+			Name:  "rate-limit",
+			Usage: "Total read rate limit in bytes per second (0 for unlimited)",
+		},
+		&cli.BoolFlag{
+			Name:  "quarantine",
+			Usage: "Relabel corrupt blobs with " + content.LabelCorrupt + " instead of leaving them in place",
+		},
+		&cli.BoolFlag{
+			Name:  "delete",
+			Usage: "Delete corrupt blobs from the store",
+		},
+	},
+	Action: func(cliContext *cli.Context) error {
+		client, ctx, cancel, err := commands.NewClient(cliContext)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+
+		if cliContext.Bool("quarantine") && cliContext.Bool("delete") {
+			return fmt.Errorf("quarantine and delete are mutually exclusive")
+		}
+
+		opts := []content.VerifyOpt{
+			content.WithVerifyConcurrency(cliContext.Int("concurrency")),
+			content.WithVerifyImageResolver(imageResolver(client.ImageService())),
+			content.WithVerifyFilters(cliContext.Args().Slice()...),
+		}
+		if bps := cliContext.Int64("rate-limit"); bps > 0 {
+			opts = append(opts, content.WithVerifyRateLimit(bps))
+		}
+		if cliContext.Bool("quarantine") {
+			opts = append(opts, content.WithVerifyQuarantine())
+		}
+		if cliContext.Bool("delete") {
+			opts = append(opts, content.WithVerifyDelete())
+		}
+
+		report, err := content.Verify(ctx, client.ContentStore(), opts...)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("checked %d blobs (%d bytes) in %v\n", report.Checked, report.CheckedBytes, report.Elapsed)
+		if len(report.Corrupt) == 0 {
+			return nil
+		}
+		for _, c := range report.Corrupt {
+			if c.Err != nil {
+				fmt.Printf("%v\tUNREADABLE\t%v\n", c.Digest, c.Err)
+			} else {
+				fmt.Printf("%v\tCORRUPT\tactual %v\timages: %s\n", c.Digest, c.Actual, strings.Join(c.Images, ","))
+			}
+		}
+		return fmt.Errorf("%d corrupt blobs found", len(report.Corrupt))
+	},
+}
+
+// imageResolver reports the images whose content trees reference a digest,
+// matching on the image target and the gc content reference labels.
+func imageResolver(is images.Store) func(context.Context, digest.Digest) []string {
+	return func(ctx context.Context, dgst digest.Digest) []string {
+		imgs, err := is.List(ctx)
+		if err != nil {
+			return nil
+		}
+		var refs []string
+		for _, img := range imgs {
+			if img.Target.Digest == dgst {
+				refs = append(refs, img.Name)
+				continue
+			}
+			for k, v := range img.Labels {
+				if strings.HasPrefix(k, "containerd.io/gc.ref.content") && v == dgst.String() {
+					refs = append(refs, img.Name)
+					break
+				}
+			}
+		}
+		return refs
+	}
+}
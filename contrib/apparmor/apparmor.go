@@ -80,6 +80,47 @@ func LoadDefaultProfile(name string) error {
 	return nil
 }
 
+// LoadProfile loads the raw profile text into the kernel by writing it
+// to a temporary file and running apparmor_parser -r on it.
+func LoadProfile(profile string) error {
+	f, err := os.CreateTemp(os.Getenv("XDG_RUNTIME_DIR"), "apparmor")
+	if err != nil {
+		return err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	_, err = f.WriteString(profile)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if err := load(path); err != nil {
+		return fmt.Errorf("load apparmor profile %s: %w", path, err)
+	}
+	return nil
+}
+
+// UnloadProfile removes the named profile from the kernel, the
+// equivalent of apparmor_parser -R without needing the profile source.
+func UnloadProfile(name string) error {
+	f, err := os.OpenFile("/sys/kernel/security/apparmor/.remove", os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(name); err != nil {
+		return fmt.Errorf("unload apparmor profile %s: %w", name, err)
+	}
+	return nil
+}
+
+// IsLoaded reports whether a profile with the given name is loaded in
+// the kernel.
+func IsLoaded(name string) (bool, error) {
+	return isLoaded(name)
+}
+
 // DumpDefaultProfile dumps the default profile with the given name.
 func DumpDefaultProfile(name string) (string, error) {
 	p, err := loadData(name)
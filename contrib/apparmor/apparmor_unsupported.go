@@ -41,3 +41,19 @@ func WithDefaultProfile(name string) oci.SpecOpts {
 		return errors.New("apparmor is not supported")
 	}
 }
+
+// LoadProfile loads the raw profile text into the kernel.
+func LoadProfile(profile string) error {
+	return errors.New("apparmor is not supported")
+}
+
+// UnloadProfile removes the named profile from the kernel.
+func UnloadProfile(name string) error {
+	return errors.New("apparmor is not supported")
+}
+
+// IsLoaded reports whether a profile with the given name is loaded in
+// the kernel.
+func IsLoaded(name string) (bool, error) {
+	return false, errors.New("apparmor is not supported")
+}
@@ -108,6 +108,25 @@ type Status struct {
 // WalkFunc defines the callback for a blob walk.
 type WalkFunc func(Info) error
 
+type walkPageSizeKey struct{}
+
+// WalkWithPageSize returns a context which instructs Walk implementations
+// to read and buffer at most pageSize records at a time, bounding memory
+// use when walking very large stores. Stores that do not support paging
+// ignore it.
+func WalkWithPageSize(ctx context.Context, pageSize int) context.Context {
+	return context.WithValue(ctx, walkPageSizeKey{}, pageSize)
+}
+
+// WalkPageSize returns the page size set on the context with
+// WalkWithPageSize, or zero when unset.
+func WalkPageSize(ctx context.Context) int {
+	if v, ok := ctx.Value(walkPageSizeKey{}).(int); ok {
+		return v
+	}
+	return 0
+}
+
 // InfoReaderProvider provides both info and reader for the specific content.
 type InfoReaderProvider interface {
 	InfoProvider
@@ -142,6 +161,47 @@ type Manager interface {
 	Delete(ctx context.Context, dgst digest.Digest) error
 }
 
+// StatusEventType describes what happened to an ingest.
+type StatusEventType string
+
+const (
+	// StatusEventStarted is emitted when a new ingest begins.
+	StatusEventStarted StatusEventType = "started"
+	// StatusEventUpdated is emitted as data is written to an ingest.
+	StatusEventUpdated StatusEventType = "updated"
+	// StatusEventCommitted is emitted when an ingest is committed.
+	StatusEventCommitted StatusEventType = "committed"
+	// StatusEventAborted is emitted when an ingest is aborted.
+	StatusEventAborted StatusEventType = "aborted"
+)
+
+// StatusEvent pairs an ingest status with what happened to it.
+type StatusEvent struct {
+	Type   StatusEventType
+	Status Status
+}
+
+// StatusTracker is an optional interface implemented by stores that can
+// push ingest status changes to subscribers, so progress consumers do not
+// have to poll ListStatuses and miss fast blobs. Events are delivered in
+// order per ref and the channel is closed when the context ends; slow
+// subscribers that cannot keep up may be disconnected early.
+type StatusTracker interface {
+	SubscribeStatus(ctx context.Context, filters ...string) (<-chan StatusEvent, error)
+}
+
+// BatchDeleter is an optional interface implemented by stores that can
+// remove several blobs in one operation, avoiding the per-call overhead of
+// issuing individual Deletes. Callers should fall back to calling Delete for
+// each digest when the store does not implement it.
+type BatchDeleter interface {
+	// DeleteAll removes the content for each of the provided digests,
+	// returning the digests that were removed. Implementations that delete
+	// atomically remove nothing on error; others report the digests deleted
+	// before the error occurred.
+	DeleteAll(ctx context.Context, dgsts []digest.Digest) (deleted []digest.Digest, err error)
+}
+
 // Writer handles writing of content into a content store
 type Writer interface {
 	// Close closes the writer, if the writer has not been
@@ -186,6 +246,20 @@ func WithLabels(labels map[string]string) Opt {
 	}
 }
 
+// WithExpiration marks content as expiring d from now by setting the
+// "containerd.io/gc.expire" label. Until the expiration passes the content
+// is treated as a garbage collection root; afterwards it becomes
+// collectible even when no explicit delete was issued.
+func WithExpiration(d time.Duration) Opt {
+	return func(info *Info) error {
+		if info.Labels == nil {
+			info.Labels = map[string]string{}
+		}
+		info.Labels["containerd.io/gc.expire"] = time.Now().Add(d).Format(time.RFC3339)
+		return nil
+	}
+}
+
 // WriterOpts is internally used by WriterOpt.
 type WriterOpts struct {
 	Ref  string
@@ -0,0 +1,204 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/errdefs"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+)
+
+// CopyEvent reports the outcome for one blob during CopyStore.
+type CopyEvent string
+
+const (
+	// CopyEventCopied means the blob was transferred to the destination.
+	CopyEventCopied CopyEvent = "copied"
+	// CopyEventExists means the destination already had the blob, either
+	// before the copy or because a concurrent copy won the commit race.
+	CopyEventExists CopyEvent = "exists"
+)
+
+// CopyProgress is delivered to the progress callback once per blob.
+type CopyProgress struct {
+	Descriptor ocispec.Descriptor
+	Event      CopyEvent
+}
+
+// CopyStoreConfig is assembled from CopyStoreOpts.
+type CopyStoreConfig struct {
+	// Concurrency bounds how many blobs are copied at once, 1 when zero.
+	Concurrency int
+	// Progress, when set, is called once per blob with the outcome.
+	Progress func(CopyProgress)
+	// Children enumerates the descriptors referenced by a blob. The
+	// default understands OCI and Docker manifests and indexes.
+	Children func(context.Context, Provider, ocispec.Descriptor) ([]ocispec.Descriptor, error)
+}
+
+// CopyStoreOpt configures CopyStore.
+type CopyStoreOpt func(*CopyStoreConfig)
+
+// WithCopyConcurrency bounds how many blobs are copied concurrently.
+func WithCopyConcurrency(n int) CopyStoreOpt {
+	return func(c *CopyStoreConfig) {
+		c.Concurrency = n
+	}
+}
+
+// WithCopyProgress registers a callback invoked once per blob.
+func WithCopyProgress(fn func(CopyProgress)) CopyStoreOpt {
+	return func(c *CopyStoreConfig) {
+		c.Progress = fn
+	}
+}
+
+// WithCopyChildren overrides how referenced descriptors are enumerated.
+func WithCopyChildren(fn func(context.Context, Provider, ocispec.Descriptor) ([]ocispec.Descriptor, error)) CopyStoreOpt {
+	return func(c *CopyStoreConfig) {
+		c.Children = fn
+	}
+}
+
+// CopyStore transfers the descriptor graph rooted at desc from one store to
+// another, for example when migrating a node's content root or seeding an
+// air-gapped host through the gRPC content proxy. Blobs already present in
+// the destination are skipped based on an Info check, the rest are copied
+// with bounded concurrency preserving their labels, and commit races with
+// concurrent copies of the same content are treated as success.
+func CopyStore(ctx context.Context, from InfoReaderProvider, to Ingester, desc ocispec.Descriptor, opts ...CopyStoreOpt) error {
+	var config CopyStoreConfig
+	for _, o := range opts {
+		o(&config)
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.Children == nil {
+		config.Children = copyChildren
+	}
+
+	// Collect the graph first so the copy pass can run with simple bounded
+	// concurrency; manifests and indexes are small.
+	var (
+		queue = []ocispec.Descriptor{desc}
+		seen  = map[string]struct{}{}
+		descs []ocispec.Descriptor
+	)
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[d.Digest.String()]; ok {
+			continue
+		}
+		seen[d.Digest.String()] = struct{}{}
+		descs = append(descs, d)
+
+		children, err := config.Children(ctx, from, d)
+		if err != nil {
+			return fmt.Errorf("failed to enumerate children of %v: %w", d.Digest, err)
+		}
+		queue = append(queue, children...)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.Concurrency)
+	for _, d := range descs {
+		g.Go(func() error {
+			event, err := copyBlob(gctx, from, to, d)
+			if err != nil {
+				return err
+			}
+			if config.Progress != nil {
+				config.Progress(CopyProgress{Descriptor: d, Event: event})
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+func copyBlob(ctx context.Context, from InfoReaderProvider, to Ingester, desc ocispec.Descriptor) (CopyEvent, error) {
+	if ip, ok := to.(InfoProvider); ok {
+		if _, err := ip.Info(ctx, desc.Digest); err == nil {
+			return CopyEventExists, nil
+		} else if !errdefs.IsNotFound(err) {
+			return "", err
+		}
+	}
+
+	info, err := from.Info(ctx, desc.Digest)
+	if err != nil {
+		return "", fmt.Errorf("failed to read info of %v from source: %w", desc.Digest, err)
+	}
+
+	ra, err := from.ReaderAt(ctx, desc)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %v in source: %w", desc.Digest, err)
+	}
+	defer ra.Close()
+
+	ref := "copy-" + desc.Digest.Encoded()
+	var opts []Opt
+	if len(info.Labels) > 0 {
+		opts = append(opts, WithLabels(info.Labels))
+	}
+	if err := WriteBlob(ctx, to, ref, NewReader(ra), desc, opts...); err != nil {
+		// A concurrent copy of the same content may have won the race
+		// between the Info check and the commit.
+		if errdefs.IsAlreadyExists(err) {
+			return CopyEventExists, nil
+		}
+		return "", fmt.Errorf("failed to copy %v: %w", desc.Digest, err)
+	}
+	return CopyEventCopied, nil
+}
+
+// copyChildren enumerates the descriptors referenced by OCI and Docker
+// manifests and indexes, returning nothing for other media types.
+func copyChildren(ctx context.Context, from Provider, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	if !strings.Contains(desc.MediaType, "manifest") && !strings.Contains(desc.MediaType, "index") {
+		return nil, nil
+	}
+
+	p, err := ReadBlob(ctx, from, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Manifests []ocispec.Descriptor `json:"manifests"`
+		Config    *ocispec.Descriptor  `json:"config"`
+		Layers    []ocispec.Descriptor `json:"layers"`
+	}
+	if err := json.Unmarshal(p, &manifest); err != nil {
+		return nil, err
+	}
+
+	var children []ocispec.Descriptor
+	children = append(children, manifest.Manifests...)
+	if manifest.Config != nil {
+		children = append(children, *manifest.Config)
+	}
+	children = append(children, manifest.Layers...)
+	return children, nil
+}
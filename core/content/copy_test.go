@@ -0,0 +1,167 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+)
+
+type labelStore struct {
+	mu     sync.Mutex
+	labels map[digest.Digest]map[string]string
+}
+
+func newLabelStore() *labelStore {
+	return &labelStore{labels: map[digest.Digest]map[string]string{}}
+}
+
+func (s *labelStore) Get(d digest.Digest) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.labels[d], nil
+}
+
+func (s *labelStore) Set(d digest.Digest, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.labels[d] = labels
+	return nil
+}
+
+func (s *labelStore) Update(d digest.Digest, update map[string]string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	labels := s.labels[d]
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range update {
+		if v == "" {
+			delete(labels, k)
+		} else {
+			labels[k] = v
+		}
+	}
+	s.labels[d] = labels
+	return labels, nil
+}
+
+func writeTestBlob(t *testing.T, cs content.Store, data []byte, mediaType string, labels map[string]string) ocispec.Descriptor {
+	t.Helper()
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	var opts []content.Opt
+	if labels != nil {
+		opts = append(opts, content.WithLabels(labels))
+	}
+	if err := content.WriteBlob(context.Background(), cs, "write-"+desc.Digest.Encoded(), bytes.NewReader(data), desc, opts...); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+func TestCopyStore(t *testing.T) {
+	ctx := context.Background()
+
+	from, err := local.NewLabeledStore(t.TempDir(), newLabelStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := local.NewLabeledStore(t.TempDir(), newLabelStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layer := writeTestBlob(t, from, []byte("layer data"), ocispec.MediaTypeImageLayer, nil)
+	config := writeTestBlob(t, from, []byte(`{"architecture":"amd64"}`), ocispec.MediaTypeImageConfig, map[string]string{"kind": "config"})
+
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := writeTestBlob(t, from, manifestJSON, ocispec.MediaTypeImageManifest, nil)
+
+	// The layer already exists in the destination and must be skipped.
+	writeTestBlob(t, to, []byte("layer data"), ocispec.MediaTypeImageLayer, nil)
+
+	events := map[digest.Digest]content.CopyEvent{}
+	var mu sync.Mutex
+	if err := content.CopyStore(ctx, from, to, manifest,
+		content.WithCopyConcurrency(2),
+		content.WithCopyProgress(func(p content.CopyProgress) {
+			mu.Lock()
+			events[p.Descriptor.Digest] = p.Event
+			mu.Unlock()
+		}),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, desc := range []ocispec.Descriptor{manifest, config, layer} {
+		if _, err := to.Info(ctx, desc.Digest); err != nil {
+			t.Fatalf("blob %v missing in destination: %v", desc.Digest, err)
+		}
+	}
+
+	if events[layer.Digest] != content.CopyEventExists {
+		t.Fatalf("expected layer to be reported as existing, got %q", events[layer.Digest])
+	}
+	if events[manifest.Digest] != content.CopyEventCopied || events[config.Digest] != content.CopyEventCopied {
+		t.Fatalf("expected manifest and config to be copied, got %v", events)
+	}
+
+	// Labels travel with copied blobs.
+	info, err := to.Info(ctx, config.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Labels["kind"] != "config" {
+		t.Fatalf("expected config labels to be preserved, got %v", info.Labels)
+	}
+
+	// Copying again reports everything as existing.
+	events = map[digest.Digest]content.CopyEvent{}
+	if err := content.CopyStore(ctx, from, to, manifest, content.WithCopyProgress(func(p content.CopyProgress) {
+		mu.Lock()
+		events[p.Descriptor.Digest] = p.Event
+		mu.Unlock()
+	})); err != nil {
+		t.Fatal(err)
+	}
+	for d, e := range events {
+		if e != content.CopyEventExists {
+			t.Fatalf("expected %v to exist on second copy, got %q", d, e)
+		}
+	}
+}
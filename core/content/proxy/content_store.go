@@ -115,6 +115,20 @@ func (pcs *proxyContentStore) Delete(ctx context.Context, dgst digest.Digest) er
 	return nil
 }
 
+// DeleteAll removes the content for each of the provided digests. The content
+// service has no batch delete rpc, so one Delete call is issued per digest,
+// reporting the digests removed before any failure.
+func (pcs *proxyContentStore) DeleteAll(ctx context.Context, dgsts []digest.Digest) ([]digest.Digest, error) {
+	deleted := make([]digest.Digest, 0, len(dgsts))
+	for _, dgst := range dgsts {
+		if err := pcs.Delete(ctx, dgst); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, dgst)
+	}
+	return deleted, nil
+}
+
 // ReaderAt ignores MediaType.
 func (pcs *proxyContentStore) ReaderAt(ctx context.Context, desc ocispec.Descriptor) (content.ReaderAt, error) {
 	i, err := pcs.Info(ctx, desc.Digest)
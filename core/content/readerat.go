@@ -0,0 +1,120 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package content
+
+import (
+	"io"
+	"sync"
+)
+
+// defaultReadaheadWindow is the buffer size used by NewReadaheadReaderAt
+// when the caller does not request a specific window.
+const defaultReadaheadWindow = 1 << 20
+
+// readaheadPool recycles window buffers across readers. All readers created
+// with the default window share pooled buffers; custom windows allocate
+// their own.
+var readaheadPool = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, defaultReadaheadWindow)
+		return &buffer
+	},
+}
+
+// NewReadaheadReaderAt wraps ra in a buffered reader which fetches
+// window-sized chunks, so consumers issuing many small sequential ReadAt
+// calls (archive apply, conversion) hit the underlying store once per
+// window instead of once per call. A window of zero selects the 1 MiB
+// default, backed by a shared buffer pool.
+//
+// Reads larger than the window bypass the buffer entirely. The wrapper
+// serializes concurrent readers around its single buffer, so random-access
+// consumers such as estargz should keep using the store's ReaderAt
+// directly.
+func NewReadaheadReaderAt(ra ReaderAt, window int) ReaderAt {
+	r := &readaheadReaderAt{
+		ra:  ra,
+		off: -1,
+	}
+	if window <= 0 {
+		r.pooled = readaheadPool.Get().(*[]byte)
+		r.buf = *r.pooled
+	} else {
+		r.buf = make([]byte, window)
+	}
+	r.window = len(r.buf)
+	return r
+}
+
+type readaheadReaderAt struct {
+	ra     ReaderAt
+	window int // immutable, read without the lock
+
+	mu     sync.Mutex
+	buf    []byte
+	pooled *[]byte // set when buf came from readaheadPool
+	off    int64   // offset of buf[0] in the blob, -1 when empty
+	valid  int     // number of valid bytes in buf
+}
+
+func (r *readaheadReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if len(p) >= r.window {
+		return r.ra.ReadAt(p, off)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.buf == nil {
+		// closed, read through
+		r.mu.Unlock()
+		n, err := r.ra.ReadAt(p, off)
+		r.mu.Lock()
+		return n, err
+	}
+
+	for n < len(p) {
+		o := off + int64(n)
+		if r.off < 0 || o < r.off || o >= r.off+int64(r.valid) {
+			fn, ferr := r.ra.ReadAt(r.buf, o)
+			r.off, r.valid = o, fn
+			if fn == 0 {
+				return n, ferr
+			}
+			if ferr != nil && ferr != io.EOF {
+				return n, ferr
+			}
+		}
+		n += copy(p[n:], r.buf[o-r.off:r.valid])
+	}
+	return n, nil
+}
+
+func (r *readaheadReaderAt) Size() int64 {
+	return r.ra.Size()
+}
+
+func (r *readaheadReaderAt) Close() error {
+	r.mu.Lock()
+	if r.pooled != nil {
+		readaheadPool.Put(r.pooled)
+		r.pooled = nil
+		r.buf = nil
+	}
+	r.off, r.valid = -1, 0
+	r.mu.Unlock()
+	return r.ra.Close()
+}
@@ -0,0 +1,154 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package content
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"testing"
+)
+
+type bytesReaderAt struct {
+	*bytes.Reader
+	reads int
+}
+
+func (b *bytesReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	b.reads++
+	return b.Reader.ReadAt(p, off)
+}
+
+func (b *bytesReaderAt) Size() int64 { return int64(b.Reader.Len()) }
+func (b *bytesReaderAt) Close() error {
+	return nil
+}
+
+func TestReadaheadReaderAt(t *testing.T) {
+	const window = 64
+
+	blob := make([]byte, 1000)
+	if _, err := rand.Read(blob); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		off  int64
+		n    int
+	}{
+		{name: "WithinWindow", off: 10, n: 20},
+		{name: "ExactWindow", off: 0, n: window},
+		{name: "AcrossWindowBoundary", off: window - 5, n: 10},
+		{name: "MultipleWindows", off: 3, n: 3*window + 7},
+		{name: "UpToEOF", off: 1000 - 13, n: 13},
+		{name: "AcrossEOF", off: 1000 - 13, n: 20},
+		{name: "PastEOF", off: 2000, n: 10},
+		{name: "BackwardSeek", off: 0, n: 30},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ra := NewReadaheadReaderAt(&bytesReaderAt{Reader: bytes.NewReader(blob)}, window)
+			defer ra.Close()
+
+			// Prime the buffer past the read offset so backward seeks and
+			// boundary reads exercise refills.
+			prime := make([]byte, 10)
+			ra.ReadAt(prime, 500)
+
+			got := make([]byte, tc.n)
+			n, err := ra.ReadAt(got, tc.off)
+
+			want := make([]byte, tc.n)
+			wantN, wantErr := bytes.NewReader(blob).ReadAt(want, tc.off)
+
+			if n != wantN || !bytes.Equal(got[:n], want[:wantN]) {
+				t.Fatalf("read %d bytes at %d, expected %d", n, tc.off, wantN)
+			}
+			if (err == nil) != (wantErr == nil) || (err != nil && err != io.EOF) {
+				t.Fatalf("got error %v, expected %v", err, wantErr)
+			}
+		})
+	}
+}
+
+func TestReadaheadReaderAtBatchesReads(t *testing.T) {
+	blob := make([]byte, 1<<16)
+	inner := &bytesReaderAt{Reader: bytes.NewReader(blob)}
+	ra := NewReadaheadReaderAt(inner, 1<<14)
+	defer ra.Close()
+
+	buf := make([]byte, 512)
+	for off := int64(0); off < int64(len(blob)); off += int64(len(buf)) {
+		if _, err := ra.ReadAt(buf, off); err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+	}
+	if expected := len(blob) / (1 << 14); inner.reads > expected+1 {
+		t.Fatalf("expected at most %d underlying reads, got %d", expected+1, inner.reads)
+	}
+}
+
+func BenchmarkReadaheadReaderAt(b *testing.B) {
+	// A layer-sized blob read sequentially in archive-typical 32KiB calls.
+	const size = 256 << 20
+
+	f, err := os.CreateTemp(b.TempDir(), "blob-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Truncate(size); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bench := range []struct {
+		name string
+		wrap func(ReaderAt) ReaderAt
+	}{
+		{name: "Direct", wrap: func(ra ReaderAt) ReaderAt { return ra }},
+		{name: "Readahead", wrap: func(ra ReaderAt) ReaderAt { return NewReadaheadReaderAt(ra, 0) }},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			ra := bench.wrap(fileReaderAt{f})
+			buf := make([]byte, 32<<10)
+			b.SetBytes(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for off := int64(0); off < size; off += int64(len(buf)) {
+					if _, err := ra.ReadAt(buf, off); err != nil && err != io.EOF {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+type fileReaderAt struct {
+	f *os.File
+}
+
+func (r fileReaderAt) ReadAt(p []byte, off int64) (int, error) { return r.f.ReadAt(p, off) }
+func (r fileReaderAt) Size() int64 {
+	fi, err := r.f.Stat()
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+func (r fileReaderAt) Close() error { return nil }
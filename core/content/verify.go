@@ -0,0 +1,254 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+)
+
+// LabelCorrupt is set on blobs that failed verification when quarantining
+// is requested. The value is the digest the data actually hashed to.
+const LabelCorrupt = "containerd.io/content.corrupt"
+
+// Corruption describes a blob whose bytes no longer match its digest.
+type Corruption struct {
+	// Digest the blob is stored under.
+	Digest digest.Digest
+	// Actual digest of the bytes read back, empty when the blob could not
+	// be read at all.
+	Actual digest.Digest
+	// Err is set when the blob could not be read back.
+	Err error
+	// Images referencing the corrupt blob, when a reference resolver was
+	// configured.
+	Images []string
+}
+
+// VerifyReport summarizes a verification pass.
+type VerifyReport struct {
+	// Checked is the number of blobs that were re-hashed.
+	Checked int
+	// CheckedBytes is the total number of bytes read.
+	CheckedBytes int64
+	// Corrupt lists the blobs that failed verification.
+	Corrupt []Corruption
+	// Elapsed is the wall time of the pass.
+	Elapsed time.Duration
+}
+
+// VerifyConfig is assembled from VerifyOpts.
+type VerifyConfig struct {
+	// Filters restricts which blobs are checked, using the same syntax as
+	// Walk.
+	Filters []string
+	// Concurrency bounds how many blobs are hashed at once, 1 when zero.
+	Concurrency int
+	// BytesPerSecond rate limits reads across all workers, unlimited when
+	// zero.
+	BytesPerSecond int64
+	// ResolveImages maps a corrupt digest to the images referencing it.
+	ResolveImages func(context.Context, digest.Digest) []string
+	// Quarantine relabels corrupt blobs with LabelCorrupt instead of
+	// leaving them untouched, keeping the bytes for inspection.
+	Quarantine bool
+	// Delete removes corrupt blobs from the store.
+	Delete bool
+}
+
+// VerifyOpt configures a verification pass.
+type VerifyOpt func(*VerifyConfig)
+
+// WithVerifyFilters restricts verification to blobs matching the filters.
+func WithVerifyFilters(filters ...string) VerifyOpt {
+	return func(c *VerifyConfig) {
+		c.Filters = filters
+	}
+}
+
+// WithVerifyConcurrency bounds how many blobs are re-hashed concurrently.
+func WithVerifyConcurrency(n int) VerifyOpt {
+	return func(c *VerifyConfig) {
+		c.Concurrency = n
+	}
+}
+
+// WithVerifyRateLimit caps the total read rate of the pass, so a
+// verification sweep does not starve foreground IO.
+func WithVerifyRateLimit(bytesPerSecond int64) VerifyOpt {
+	return func(c *VerifyConfig) {
+		c.BytesPerSecond = bytesPerSecond
+	}
+}
+
+// WithVerifyImageResolver resolves the images referencing a corrupt blob
+// for the report.
+func WithVerifyImageResolver(fn func(context.Context, digest.Digest) []string) VerifyOpt {
+	return func(c *VerifyConfig) {
+		c.ResolveImages = fn
+	}
+}
+
+// WithVerifyQuarantine relabels corrupt blobs with LabelCorrupt instead of
+// leaving them in place, keeping the bytes around for inspection.
+func WithVerifyQuarantine() VerifyOpt {
+	return func(c *VerifyConfig) {
+		c.Quarantine = true
+	}
+}
+
+// WithVerifyDelete removes corrupt blobs from the store.
+func WithVerifyDelete() VerifyOpt {
+	return func(c *VerifyConfig) {
+		c.Delete = true
+	}
+}
+
+// Verify walks the store's blobs and re-computes their digests, reporting
+// every blob whose bytes no longer match. Reads happen with bounded
+// concurrency and an optional rate limit; corrupt blobs can additionally be
+// quarantined (relabeled with LabelCorrupt) or deleted.
+func Verify(ctx context.Context, store Store, opts ...VerifyOpt) (*VerifyReport, error) {
+	var config VerifyConfig
+	for _, o := range opts {
+		o(&config)
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	var limiter *rate.Limiter
+	if config.BytesPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.BytesPerSecond), int(config.BytesPerSecond))
+	}
+
+	var (
+		t1     = time.Now()
+		report VerifyReport
+		mu     sync.Mutex
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.Concurrency)
+
+	if err := store.Walk(ctx, func(info Info) error {
+		select {
+		case <-gctx.Done():
+			return gctx.Err()
+		default:
+		}
+		g.Go(func() error {
+			actual, n, err := rehash(gctx, store, info, limiter)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checked++
+			report.CheckedBytes += n
+			if err == nil && actual == info.Digest {
+				return nil
+			}
+			c := Corruption{
+				Digest: info.Digest,
+				Err:    err,
+			}
+			if err == nil {
+				c.Actual = actual
+			}
+			if config.ResolveImages != nil {
+				c.Images = config.ResolveImages(gctx, info.Digest)
+			}
+			report.Corrupt = append(report.Corrupt, c)
+			return nil
+		})
+		return nil
+	}, config.Filters...); err != nil {
+		g.Wait()
+		return nil, err
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range report.Corrupt {
+		switch {
+		case config.Delete:
+			if err := store.Delete(ctx, c.Digest); err != nil {
+				return &report, fmt.Errorf("failed to delete corrupt blob %v: %w", c.Digest, err)
+			}
+		case config.Quarantine:
+			info := Info{
+				Digest: c.Digest,
+				Labels: map[string]string{LabelCorrupt: c.Actual.String()},
+			}
+			if _, err := store.Update(ctx, info, "labels."+LabelCorrupt); err != nil {
+				return &report, fmt.Errorf("failed to quarantine corrupt blob %v: %w", c.Digest, err)
+			}
+		}
+	}
+
+	report.Elapsed = time.Since(t1)
+	return &report, nil
+}
+
+// rehash reads the blob back and returns the digest of its bytes.
+func rehash(ctx context.Context, store Store, info Info, limiter *rate.Limiter) (digest.Digest, int64, error) {
+	ra, err := store.ReaderAt(ctx, ocispec.Descriptor{Digest: info.Digest, Size: info.Size})
+	if err != nil {
+		return "", 0, err
+	}
+	defer ra.Close()
+
+	var (
+		digester = info.Digest.Algorithm().Digester()
+		r        = io.Reader(NewReader(ra))
+	)
+	if limiter != nil {
+		r = &limitedReader{r: r, ctx: ctx, limiter: limiter}
+	}
+	n, err := io.Copy(digester.Hash(), r)
+	if err != nil {
+		return "", n, err
+	}
+	return digester.Digest(), n, nil
+}
+
+// limitedReader throttles reads through a shared rate limiter.
+type limitedReader struct {
+	r       io.Reader
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if b := l.limiter.Burst(); len(p) > b {
+		p = p[:b]
+	}
+	n, err := l.r.Read(p)
+	if n > 0 {
+		if werr := l.limiter.WaitN(l.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
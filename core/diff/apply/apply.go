@@ -22,13 +22,16 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/diff"
 	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/pkg/archive"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
+	"github.com/containerd/typeurl/v2"
 	digest "github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -79,35 +82,83 @@ func (s *fsApplier) Apply(ctx context.Context, desc ocispec.Descriptor, mounts [
 		}
 	}
 
-	ra, err := s.store.ReaderAt(ctx, desc)
-	if err != nil {
-		return emptyDesc, fmt.Errorf("failed to get reader from content store: %w", err)
+	var archiveOpts []archive.ApplyOpt
+	if payload, ok := config.ProcessorPayloads[diff.IDMappingPayloadKey]; ok {
+		v, err := typeurl.UnmarshalAny(payload)
+		if err != nil {
+			return emptyDesc, fmt.Errorf("failed to unmarshal id mapping payload: %w", err)
+		}
+		mapping, ok := v.(*diff.ApplyIDMapping)
+		if !ok {
+			return emptyDesc, fmt.Errorf("invalid id mapping payload type %T", v)
+		}
+		// Idmapped target mounts already map ownership in the kernel,
+		// only remap explicitly when they do not.
+		if !mountsIDMapped(mounts) {
+			archiveOpts = append(archiveOpts, archive.WithRemapIDs(toArchiveIDMaps(mapping.UIDMappings), toArchiveIDMaps(mapping.GIDMappings)))
+		}
 	}
-	var r io.ReadCloser
-	if config.Progress != nil {
-		r = newProgressReader(ra, config.Progress)
-	} else {
-		r = newReadCloser(ra)
+	if payload, ok := config.ProcessorPayloads[diff.ApplyFiltersPayloadKey]; ok {
+		v, err := typeurl.UnmarshalAny(payload)
+		if err != nil {
+			return emptyDesc, fmt.Errorf("failed to unmarshal apply filters: %w", err)
+		}
+		filters, ok := v.(*diff.ApplyFilters)
+		if !ok {
+			return emptyDesc, fmt.Errorf("invalid apply filters payload type %T", v)
+		}
+		if len(filters.ExcludePatterns) > 0 {
+			archiveOpts = append(archiveOpts, archive.WithExcludePatterns(filters.ExcludePatterns))
+		}
+		if filters.IgnoreXattrErrors {
+			archiveOpts = append(archiveOpts, archive.WithXattrErrorHandler(func(path, key string, err error) error {
+				return nil
+			}))
+		}
 	}
-	defer r.Close()
 
-	var processors []diff.StreamProcessor
-	processor := diff.NewProcessorChain(desc.MediaType, r)
-	processors = append(processors, processor)
-	for {
-		if processor, err = diff.GetProcessor(ctx, processor, config.ProcessorPayloads); err != nil {
-			return emptyDesc, fmt.Errorf("failed to get stream processor for %s: %w", desc.MediaType, err)
+	var (
+		tarStream  io.Reader
+		processors []diff.StreamProcessor
+	)
+	if config.DecompressedStream != nil && onlyFilterPayloads(config.ProcessorPayloads) {
+		// the caller pipelined the decompression ahead of the apply
+		defer config.DecompressedStream.Close()
+		tarStream = config.DecompressedStream
+	} else {
+		ra, err := s.store.ReaderAt(ctx, desc)
+		if err != nil {
+			return emptyDesc, fmt.Errorf("failed to get reader from content store: %w", err)
+		}
+		// The layer is consumed sequentially in small reads, batch them
+		// through a readahead window.
+		ra = content.NewReadaheadReaderAt(ra, 0)
+		var r io.ReadCloser
+		if config.Progress != nil {
+			r = newProgressReader(ra, config.Progress)
+		} else {
+			r = newReadCloser(ra)
 		}
+		defer r.Close()
+
+		processor := diff.NewProcessorChain(desc.MediaType, r)
 		processors = append(processors, processor)
-		if processor.MediaType() == ocispec.MediaTypeImageLayer {
-			break
+		for {
+			if processor, err = diff.GetProcessor(ctx, processor, config.ProcessorPayloads); err != nil {
+				return emptyDesc, fmt.Errorf("failed to get stream processor for %s: %w", desc.MediaType, err)
+			}
+			processors = append(processors, processor)
+			if processor.MediaType() == ocispec.MediaTypeImageLayer {
+				break
+			}
 		}
+		defer processor.Close()
+		tarStream = processor
 	}
-	defer processor.Close()
 
 	digester := digest.Canonical.Digester()
 	rc := &readCounter{
-		r: io.TeeReader(processor, digester.Hash()),
+		r: io.TeeReader(tarStream, digester.Hash()),
 	}
 
 	// The number of `mounts` that need to be parsed by the mount manager
@@ -127,7 +178,7 @@ func (s *fsApplier) Apply(ctx context.Context, desc ocispec.Descriptor, mounts [
 		}
 	}
 
-	if err := apply(ctx, mounts, rc, config.SyncFs); err != nil {
+	if err := apply(ctx, mounts, rc, config.SyncFs, archiveOpts...); err != nil {
 		return emptyDesc, err
 	}
 
@@ -153,6 +204,39 @@ func (s *fsApplier) Apply(ctx context.Context, desc ocispec.Descriptor, mounts [
 	}, nil
 }
 
+// onlyFilterPayloads reports whether the payloads contain nothing beyond
+// apply adjustments, which do not require the stream processor chain.
+func onlyFilterPayloads(payloads map[string]typeurl.Any) bool {
+	for key := range payloads {
+		if key != diff.ApplyFiltersPayloadKey && key != diff.IDMappingPayloadKey {
+			return false
+		}
+	}
+	return true
+}
+
+// mountsIDMapped reports whether the target mounts apply their own id
+// mapping through idmapped mounts.
+func mountsIDMapped(mounts []mount.Mount) bool {
+	for _, m := range mounts {
+		for _, opt := range m.Options {
+			if strings.HasPrefix(opt, "uidmap=") || strings.HasPrefix(opt, "gidmap=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toArchiveIDMaps converts payload mappings to the archive's type.
+func toArchiveIDMaps(mappings []diff.LinuxIDMapping) []archive.IDMap {
+	out := make([]archive.IDMap, 0, len(mappings))
+	for _, m := range mappings {
+		out = append(out, archive.IDMap{ContainerID: m.ContainerID, HostID: m.HostID, Size: m.Size})
+	}
+	return out
+}
+
 type readCounter struct {
 	r io.Reader
 	c int64
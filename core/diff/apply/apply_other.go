@@ -27,11 +27,11 @@ import (
 	"github.com/containerd/containerd/v2/pkg/archive"
 )
 
-func apply(ctx context.Context, mounts []mount.Mount, r io.Reader, _sync bool) error {
+func apply(ctx context.Context, mounts []mount.Mount, r io.Reader, _sync bool, applyOpts ...archive.ApplyOpt) error {
 	// TODO: for windows, how to sync?
 
 	if !mount.HasBindMounts && len(mounts) == 1 && mounts[0].Type == "bind" {
-		opts := []archive.ApplyOpt{}
+		opts := append([]archive.ApplyOpt{}, applyOpts...)
 
 		if os.Getuid() != 0 {
 			opts = append(opts, archive.WithNoSameOwner())
@@ -45,7 +45,7 @@ func apply(ctx context.Context, mounts []mount.Mount, r io.Reader, _sync bool) e
 	}
 
 	return mount.WithTempMount(ctx, mounts, func(root string) error {
-		_, err := archive.Apply(ctx, root, r)
+		_, err := archive.Apply(ctx, root, r, applyOpts...)
 		return err
 	})
 }
@@ -71,6 +71,12 @@ type ApplyConfig struct {
 	SyncFs bool
 	// Progress is a function which reports status of processed read data
 	Progress func(int64)
+	// DecompressedStream provides an already decompressed tar stream for
+	// the blob being applied, letting callers pipeline decompression
+	// ahead of the apply. Appliers that cannot consume it, such as remote
+	// proxies, ignore it and decompress from the content store
+	// themselves; it is closed by whoever consumes it.
+	DecompressedStream io.ReadCloser
 }
 
 // ApplyOpt is used to configure an Apply operation
@@ -122,6 +128,105 @@ func WithLabels(labels map[string]string) Opt {
 }
 
 // WithPayloads sets the apply processor payloads to the config
+func init() {
+	typeurl.Register(&ApplyFilters{}, "github.com/containerd/containerd/v2/core/diff", "ApplyFilters")
+	typeurl.Register(&BlobStream{}, "github.com/containerd/containerd/v2/core/diff", "BlobStream")
+	typeurl.Register(&ApplyIDMapping{}, "github.com/containerd/containerd/v2/core/diff", "ApplyIDMapping")
+}
+
+// IDMappingPayloadKey is the processor payload key carrying ApplyIDMapping
+// across the diff service.
+const IDMappingPayloadKey = "io.containerd.diff.apply.idmapping.v1"
+
+// ApplyIDMapping requests that extracted ownership be remapped through
+// the given ranges when the target mounts are not idmapped; targets
+// applied through idmapped mounts ignore it since the kernel maps
+// ownership there.
+type ApplyIDMapping struct {
+	UIDMappings []LinuxIDMapping `json:"uid_mappings,omitempty"`
+	GIDMappings []LinuxIDMapping `json:"gid_mappings,omitempty"`
+}
+
+// LinuxIDMapping mirrors the runtime spec's id mapping triple.
+type LinuxIDMapping struct {
+	ContainerID uint32 `json:"container_id"`
+	HostID      uint32 `json:"host_id"`
+	Size        uint32 `json:"size"`
+}
+
+// WithIDMapping attaches the ownership remapping to the apply as a
+// processor payload, honored by appliers when the target mounts are not
+// already idmapped.
+func WithIDMapping(mapping ApplyIDMapping) ApplyOpt {
+	return func(_ context.Context, _ ocispec.Descriptor, c *ApplyConfig) error {
+		data, err := typeurl.MarshalAny(&mapping)
+		if err != nil {
+			return err
+		}
+		if c.ProcessorPayloads == nil {
+			c.ProcessorPayloads = map[string]typeurl.Any{}
+		}
+		c.ProcessorPayloads[IDMappingPayloadKey] = data
+		return nil
+	}
+}
+
+// BlobStreamPayloadKey is the processor payload key pointing the applier
+// at a stream carrying the layer bytes, see BlobStream.
+const BlobStreamPayloadKey = "io.containerd.diff.blob-stream.v1"
+
+// BlobStream tells an applier to read the layer bytes from the named
+// stream of its streaming service instead of the content store, letting
+// differs run on nodes without access to the shared store. The media type
+// describes the streamed bytes so the receiver can decompress them.
+type BlobStream struct {
+	StreamID  string `json:"stream_id"`
+	MediaType string `json:"media_type"`
+}
+
+// ApplyFiltersPayloadKey is the processor payload key carrying
+// ApplyFilters across the diff service, so remote appliers honor them.
+const ApplyFiltersPayloadKey = "io.containerd.diff.apply.filters.v1"
+
+// ApplyFilters adjusts how a layer is extracted: xattr write failures can
+// be downgraded to warnings and paths matching the exclude patterns are
+// skipped entirely.
+type ApplyFilters struct {
+	// ExcludePatterns skips archive entries matching any of the patterns
+	// (path.Match syntax against the rootless entry name).
+	ExcludePatterns []string `json:"exclude_patterns,omitempty"`
+	// IgnoreXattrErrors downgrades failures to set extended attributes to
+	// warnings, for filesystems rejecting certain xattrs.
+	IgnoreXattrErrors bool `json:"ignore_xattr_errors,omitempty"`
+}
+
+// WithApplyFilters attaches the filters to the apply as a processor
+// payload, which travels through the diff service so remote appliers
+// honor them as well.
+func WithApplyFilters(filters ApplyFilters) ApplyOpt {
+	return func(_ context.Context, _ ocispec.Descriptor, c *ApplyConfig) error {
+		data, err := typeurl.MarshalAny(&filters)
+		if err != nil {
+			return err
+		}
+		if c.ProcessorPayloads == nil {
+			c.ProcessorPayloads = map[string]typeurl.Any{}
+		}
+		c.ProcessorPayloads[ApplyFiltersPayloadKey] = data
+		return nil
+	}
+}
+
+// WithDecompressedStream provides an already decompressed tar stream for
+// the blob being applied, see ApplyConfig.DecompressedStream. Callers stay
+// responsible for closing the stream when the applier did not consume it.
+func WithDecompressedStream(r io.ReadCloser) ApplyOpt {
+	return func(_ context.Context, _ ocispec.Descriptor, c *ApplyConfig) error {
+		c.DecompressedStream = r
+		return nil
+	}
+}
+
 func WithPayloads(payloads map[string]typeurl.Any) ApplyOpt {
 	return func(_ context.Context, _ ocispec.Descriptor, c *ApplyConfig) error {
 		c.ProcessorPayloads = payloads
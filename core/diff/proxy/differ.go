@@ -18,6 +18,10 @@ package proxy
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
 
 	diffapi "github.com/containerd/containerd/api/services/diff/v1"
 	"github.com/containerd/errdefs/pkg/errgrpc"
@@ -25,8 +29,11 @@ import (
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/diff"
 	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/core/streaming"
+	tstreaming "github.com/containerd/containerd/v2/core/transfer/streaming"
 	"github.com/containerd/containerd/v2/pkg/epoch"
 	"github.com/containerd/containerd/v2/pkg/oci"
 	ptypes "github.com/containerd/containerd/v2/pkg/protobuf/types"
@@ -40,8 +47,23 @@ func NewDiffApplier(client diffapi.DiffClient) any {
 	}
 }
 
+// NewStreamingDiffApplier is like NewDiffApplier but streams the layer
+// bytes to the remote differ through its streaming service, so the differ
+// does not need access to the shared content store (split-node or
+// sandboxed differs). Remotes without streaming support simply keep
+// reading from the store, the payload does not affect them.
+func NewStreamingDiffApplier(client diffapi.DiffClient, streams streaming.StreamCreator, store content.Provider) any {
+	return &diffRemote{
+		client:  client,
+		streams: streams,
+		store:   store,
+	}
+}
+
 type diffRemote struct {
-	client diffapi.DiffClient
+	client  diffapi.DiffClient
+	streams streaming.StreamCreator
+	store   content.Provider
 }
 
 func (r *diffRemote) Apply(ctx context.Context, desc ocispec.Descriptor, mounts []mount.Mount, opts ...diff.ApplyOpt) (ocispec.Descriptor, error) {
@@ -56,6 +78,38 @@ func (r *diffRemote) Apply(ctx context.Context, desc ocispec.Descriptor, mounts
 	for k, v := range config.ProcessorPayloads {
 		payloads[k] = typeurl.MarshalProto(v)
 	}
+
+	if r.streams != nil && r.store != nil {
+		// offer the layer bytes as a stream for differs without store
+		// access; the sender goroutine ends when the remote read the
+		// stream or the apply returns
+		streamID := fmt.Sprintf("diff-blob-%s-%s", desc.Digest.Encoded(), uniquePart())
+		stream, err := r.streams.Create(ctx, streamID)
+		if err == nil {
+			blob, err := typeurl.MarshalAny(&diff.BlobStream{
+				StreamID:  streamID,
+				MediaType: desc.MediaType,
+			})
+			if err != nil {
+				stream.Close()
+				return ocispec.Descriptor{}, err
+			}
+			payloads[diff.BlobStreamPayloadKey] = typeurl.MarshalProto(blob)
+
+			sctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			go func() {
+				defer stream.Close()
+				ra, err := r.store.ReaderAt(sctx, desc)
+				if err != nil {
+					return
+				}
+				defer ra.Close()
+				tstreaming.SendStream(sctx, content.NewReader(ra), stream)
+			}()
+		}
+	}
+
 	if config.Progress != nil {
 		config.Progress(0)
 	}
@@ -103,3 +157,11 @@ func (r *diffRemote) Compare(ctx context.Context, a, b []mount.Mount, opts ...di
 	}
 	return oci.DescriptorFromProto(resp.Diff), nil
 }
+
+// uniquePart returns a unique suffix for stream ids.
+func uniquePart() string {
+	t := time.Now()
+	var b [3]byte
+	rand.Read(b[:])
+	return fmt.Sprintf("%d-%s", t.Nanosecond(), base64.URLEncoding.EncodeToString(b[:]))
+}
@@ -29,6 +29,16 @@ type Envelope struct {
 	Namespace string
 	Topic     string
 	Event     typeurl.Any
+
+	// Sequence is a monotonically increasing per-namespace number the
+	// exchange stamps at publish time, letting in-process subscribers of
+	// a namespace's full stream detect gaps. Subscribers only see
+	// contiguous numbers when nothing hides part of the stream from
+	// them: filters, and topics under a coalescing rule (whose raw
+	// deliveries consume numbers the batched view never shows), make
+	// jumps expected. It does not cross the grpc wire, whose envelope
+	// has no such field.
+	Sequence uint64
 }
 
 // Field returns the value for the given fieldpath as a string, if defined.
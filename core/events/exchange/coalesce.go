@@ -0,0 +1,166 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package exchange
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/docker/go-metrics"
+
+	"github.com/containerd/typeurl/v2"
+
+	"github.com/containerd/containerd/v2/core/events"
+)
+
+func init() {
+	typeurl.Register(&CoalescedEvents{}, "io.containerd.event.v1.CoalescedEvents")
+}
+
+// CoalescedEvents is the payload of a batched envelope: every event of
+// one (namespace, topic) pair that arrived during a coalescing interval.
+type CoalescedEvents struct {
+	// Count is len(Events), kept explicit for cheap inspection.
+	Count int `json:"count"`
+	// Events are the batched events in arrival order.
+	Events []CoalescedEvent `json:"events"`
+}
+
+// CoalescedEvent is one batched event.
+type CoalescedEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	TypeURL   string    `json:"type_url,omitempty"`
+	Value     []byte    `json:"value,omitempty"`
+}
+
+var (
+	coalescedCounter metrics.Counter
+	droppedCounter   metrics.Counter
+	eventMetricsOnce sync.Once
+)
+
+func registerEventMetrics() {
+	eventMetricsOnce.Do(func() {
+		ns := metrics.NewNamespace("containerd", "events", nil)
+		coalescedCounter = ns.NewCounter("coalesced", "events batched into coalesced envelopes")
+		droppedCounter = ns.NewCounter("dropped", "events dropped because a subscriber sink failed")
+		metrics.Register(ns)
+	})
+}
+
+type coalesceRule struct {
+	prefix   string
+	interval time.Duration
+}
+
+// coalesceBucket accumulates events for one (namespace, topic) pair until
+// its flush timer fires.
+type coalesceBucket struct {
+	namespace string
+	topic     string
+	events    []CoalescedEvent
+}
+
+// ruleFor returns the coalescing rule covering the topic, if any.
+func (e *Exchange) ruleFor(topic string) *coalesceRule {
+	for i := range e.coalesceRules {
+		if strings.HasPrefix(topic, e.coalesceRules[i].prefix) {
+			return &e.coalesceRules[i]
+		}
+	}
+	return nil
+}
+
+// enqueueCoalesced adds the envelope to its bucket, scheduling the flush
+// when the bucket is new.
+func (e *Exchange) enqueueCoalesced(rule *coalesceRule, envelope *events.Envelope) {
+	ev := CoalescedEvent{Timestamp: envelope.Timestamp}
+	if envelope.Event != nil {
+		ev.TypeURL = envelope.Event.GetTypeUrl()
+		ev.Value = envelope.Event.GetValue()
+	}
+
+	key := envelope.Namespace + "\x00" + envelope.Topic
+	e.coalesceMu.Lock()
+	bucket, ok := e.coalescing[key]
+	if !ok {
+		bucket = &coalesceBucket{namespace: envelope.Namespace, topic: envelope.Topic}
+		e.coalescing[key] = bucket
+		time.AfterFunc(rule.interval, func() {
+			e.flushCoalesced(key)
+		})
+	}
+	bucket.events = append(bucket.events, ev)
+	e.coalesceMu.Unlock()
+	coalescedCounter.Inc(1)
+}
+
+// flushCoalesced delivers one bucket as a single envelope to the
+// subscribers that did not opt out of coalescing.
+func (e *Exchange) flushCoalesced(key string) {
+	e.coalesceMu.Lock()
+	bucket, ok := e.coalescing[key]
+	delete(e.coalescing, key)
+	e.coalesceMu.Unlock()
+	if !ok || len(bucket.events) == 0 {
+		return
+	}
+
+	payload, err := typeurl.MarshalAny(&CoalescedEvents{
+		Count:  len(bucket.events),
+		Events: bucket.events,
+	})
+	if err != nil {
+		return
+	}
+	_ = e.deliver(&events.Envelope{
+		Timestamp: time.Now().UTC(),
+		Namespace: bucket.namespace,
+		Topic:     bucket.topic,
+		Event:     payload,
+	}, deliverCoalesced)
+}
+
+// noCoalesceClause is the pseudo-filter a subscriber passes to opt out of
+// coalescing and receive the raw event stream.
+const noCoalesceClause = `coalesce=="false"`
+
+// StripNoCoalesce removes the opt-out pseudo-clause from the filters,
+// reporting whether it was present. The events service uses it to keep
+// pseudo-clauses out of filters it evaluates itself (e.g. for journal
+// replay).
+func StripNoCoalesce(fs []string) ([]string, bool) {
+	var (
+		out   []string
+		found bool
+	)
+	for _, f := range fs {
+		var clauses []string
+		for clause := range strings.SplitSeq(f, ",") {
+			if strings.TrimSpace(clause) == noCoalesceClause {
+				found = true
+				continue
+			}
+			clauses = append(clauses, clause)
+		}
+		if len(clauses) > 0 {
+			out = append(out, strings.Join(clauses, ","))
+		}
+	}
+	return out, found
+}
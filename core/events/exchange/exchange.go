@@ -18,8 +18,11 @@ package exchange
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/containerd/containerd/v2/core/events"
@@ -30,18 +33,303 @@ import (
 	"github.com/containerd/log"
 	"github.com/containerd/typeurl/v2"
 	goevents "github.com/docker/go-events"
+	"golang.org/x/time/rate"
 )
 
-// Exchange broadcasts events
+// Exchange broadcasts events.
+//
+// Subscribers are indexed by the namespace and topic predicates of their
+// filters where possible, so publishing only touches the subscribers that
+// can match instead of evaluating every filter against every event.
 type Exchange struct {
-	broadcaster *goevents.Broadcaster
+	mu            sync.RWMutex
+	general       map[*subscription]struct{}
+	byNamespace   map[string]map[*subscription]struct{}
+	byTopic       map[string]map[*subscription]struct{}
+	byTopicPrefix map[string]map[*subscription]struct{}
+
+	limiter       *rate.Limiter
+	coalesceRules []coalesceRule
+	coalesceMu    sync.Mutex
+	coalescing    map[string]*coalesceBucket
+
+	// deliverMu serializes deliveries so every subscriber observes
+	// envelopes in sequence order; sequences counts per namespace.
+	deliverMu        sync.Mutex
+	sequences        map[string]uint64
+	subscriberBuffer int
+}
+
+// WithSubscriberBuffer sets how many envelopes may be pending per
+// subscriber before the subscription is failed with an overflow error
+// (default 4096).
+func WithSubscriberBuffer(n int) Option {
+	return func(e *Exchange) {
+		if n > 0 {
+			e.subscriberBuffer = n
+		}
+	}
+}
+
+// Option configures an Exchange.
+type Option func(*Exchange)
+
+// WithCoalescing batches envelopes whose topic starts with topicPrefix
+// into one CoalescedEvents envelope delivered at most once per interval,
+// bounding subscriber load under exec churn. Subscribers opt out with the
+// pseudo-filter `coalesce=="false"` and receive the raw stream.
+func WithCoalescing(topicPrefix string, interval time.Duration) Option {
+	return func(e *Exchange) {
+		e.coalesceRules = append(e.coalesceRules, coalesceRule{prefix: topicPrefix, interval: interval})
+	}
+}
+
+// WithPublishRateLimit bounds how many events per second publishers may
+// write into the exchange. Publishers over the limit block (shims keep
+// their events, they just slow down) instead of events being dropped.
+func WithPublishRateLimit(eventsPerSecond float64, burst int) Option {
+	return func(e *Exchange) {
+		e.limiter = rate.NewLimiter(rate.Limit(eventsPerSecond), burst)
+	}
 }
 
 // NewExchange returns a new event Exchange
-func NewExchange() *Exchange {
-	return &Exchange{
-		broadcaster: goevents.NewBroadcaster(),
+func NewExchange(opts ...Option) *Exchange {
+	e := &Exchange{
+		general:          make(map[*subscription]struct{}),
+		byNamespace:      make(map[string]map[*subscription]struct{}),
+		byTopic:          make(map[string]map[*subscription]struct{}),
+		byTopicPrefix:    make(map[string]map[*subscription]struct{}),
+		coalescing:       make(map[string]*coalesceBucket),
+		sequences:        make(map[string]uint64),
+		subscriberBuffer: defaultSubscriberBuffer,
+	}
+	for _, o := range opts {
+		o(e)
 	}
+	return e
+}
+
+// subscription is one subscriber's sink plus the index keys it is
+// registered under.
+type subscription struct {
+	sink       goevents.Sink
+	matcher    filters.Filter
+	keys       []indexKey
+	noCoalesce bool
+}
+
+type indexKind int
+
+const (
+	indexGeneral indexKind = iota
+	indexNamespace
+	indexTopic
+	indexTopicPrefix
+)
+
+type indexKey struct {
+	kind  indexKind
+	value string
+}
+
+// clausePattern matches a single namespace or topic predicate inside a
+// filter string for index extraction; anything it cannot understand falls
+// back to the general list.
+var clausePattern = regexp.MustCompile(`^(namespace|topic)(==|~=)(?:"([^"]*)"|([A-Za-z0-9/._-]+))$`)
+
+// indexKeysForFilter inspects one raw filter string and picks the most
+// selective index the subscription can be registered under. Since filters
+// are OR'd together, each filter contributes its own key.
+func indexKeysForFilter(f string) indexKey {
+	var (
+		nsKey     string
+		topicKey  string
+		prefixKey string
+	)
+	for clause := range strings.SplitSeq(f, ",") {
+		m := clausePattern.FindStringSubmatch(strings.TrimSpace(clause))
+		if m == nil {
+			continue
+		}
+		value := m[3]
+		if value == "" {
+			value = m[4]
+		}
+		switch {
+		case m[1] == "namespace" && m[2] == "==":
+			nsKey = value
+		case m[1] == "topic" && m[2] == "==":
+			topicKey = value
+		case m[1] == "topic" && m[2] == "~=":
+			if prefix := literalRegexPrefix(value); prefix != "" {
+				prefixKey = prefix
+			}
+		}
+	}
+	switch {
+	case nsKey != "":
+		return indexKey{kind: indexNamespace, value: nsKey}
+	case topicKey != "":
+		return indexKey{kind: indexTopic, value: topicKey}
+	case prefixKey != "":
+		return indexKey{kind: indexTopicPrefix, value: prefixKey}
+	}
+	return indexKey{kind: indexGeneral}
+}
+
+// literalRegexPrefix returns the literal prefix of an anchored regular
+// expression, e.g. "^/tasks/" -> "/tasks/", or "" when there is none.
+func literalRegexPrefix(pattern string) string {
+	if !strings.HasPrefix(pattern, "^") {
+		return ""
+	}
+	literal := pattern[1:]
+	if i := strings.IndexAny(literal, `.*+?()[]{}|\$`); i >= 0 {
+		literal = literal[:i]
+	}
+	return literal
+}
+
+func (e *Exchange) add(s *subscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, key := range s.keys {
+		var index map[string]map[*subscription]struct{}
+		switch key.kind {
+		case indexGeneral:
+			e.general[s] = struct{}{}
+			continue
+		case indexNamespace:
+			index = e.byNamespace
+		case indexTopic:
+			index = e.byTopic
+		case indexTopicPrefix:
+			index = e.byTopicPrefix
+		}
+		if index[key.value] == nil {
+			index[key.value] = make(map[*subscription]struct{})
+		}
+		index[key.value][s] = struct{}{}
+	}
+}
+
+func (e *Exchange) remove(s *subscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.general, s)
+	for _, key := range s.keys {
+		var index map[string]map[*subscription]struct{}
+		switch key.kind {
+		case indexNamespace:
+			index = e.byNamespace
+		case indexTopic:
+			index = e.byTopic
+		case indexTopicPrefix:
+			index = e.byTopicPrefix
+		default:
+			continue
+		}
+		if set := index[key.value]; set != nil {
+			delete(set, s)
+			if len(set) == 0 {
+				delete(index, key.value)
+			}
+		}
+	}
+}
+
+type deliverMode int
+
+const (
+	deliverAll deliverMode = iota
+	// deliverRaw targets only subscriptions that opted out of
+	// coalescing
+	deliverRaw
+	// deliverCoalesced targets only subscriptions that did not opt out
+	deliverCoalesced
+)
+
+// write routes the envelope: topics under a coalescing rule are batched
+// for regular subscribers and delivered immediately only to subscribers
+// that opted out; everything else is delivered immediately to everyone.
+func (e *Exchange) write(ctx context.Context, envelope *events.Envelope) error {
+	registerEventMetrics()
+	if e.limiter != nil {
+		// backpressure publishers (including shim forwarders) instead of
+		// dropping their events
+		if err := e.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if rule := e.ruleFor(envelope.Topic); rule != nil {
+		e.enqueueCoalesced(rule, envelope)
+		return e.deliver(envelope, deliverRaw)
+	}
+	return e.deliver(envelope, deliverAll)
+}
+
+// deliver sends the envelope to every matching subscription in the mode's
+// target set; each candidate still evaluates its full filter.
+func (e *Exchange) deliver(envelope *events.Envelope, mode deliverMode) error {
+	var candidates []*subscription
+	seen := make(map[*subscription]struct{})
+	collect := func(set map[*subscription]struct{}) {
+		for s := range set {
+			if _, ok := seen[s]; !ok {
+				seen[s] = struct{}{}
+				candidates = append(candidates, s)
+			}
+		}
+	}
+
+	e.mu.RLock()
+	collect(e.general)
+	collect(e.byNamespace[envelope.Namespace])
+	collect(e.byTopic[envelope.Topic])
+	for prefix, set := range e.byTopicPrefix {
+		if strings.HasPrefix(envelope.Topic, prefix) {
+			collect(set)
+		}
+	}
+	e.mu.RUnlock()
+
+	// filter evaluation happens outside the delivery lock; only the
+	// matching targets are enqueued under it
+	targets := candidates[:0]
+	for _, s := range candidates {
+		if mode == deliverRaw && !s.noCoalesce {
+			continue
+		}
+		if mode == deliverCoalesced && s.noCoalesce {
+			continue
+		}
+		if s.matcher != nil && !s.matcher.Match(adapt(envelope)) {
+			continue
+		}
+		targets = append(targets, s)
+	}
+
+	// Stamping and enqueueing happen under one lock so every subscriber
+	// sees envelopes in sequence order; the queue writes below never
+	// block.
+	e.deliverMu.Lock()
+	defer e.deliverMu.Unlock()
+	e.sequences[envelope.Namespace]++
+	envelope.Sequence = e.sequences[envelope.Namespace]
+
+	var errs []error
+	for _, s := range targets {
+		if err := s.sink.Write(envelope); err != nil {
+			if errors.Is(err, goevents.ErrSinkClosed) {
+				// the subscriber is going away; not a publish failure
+				continue
+			}
+			droppedCounter.Inc(1)
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 var _ events.Publisher = &Exchange{}
@@ -71,7 +359,7 @@ func (e *Exchange) Forward(ctx context.Context, envelope *events.Envelope) (err
 		}
 	}()
 
-	return e.broadcaster.Write(envelope)
+	return e.write(ctx, envelope)
 }
 
 // Publish packages and sends an event. The caller will be considered the
@@ -115,7 +403,7 @@ func (e *Exchange) Publish(ctx context.Context, topic string, event events.Event
 		}
 	}()
 
-	return e.broadcaster.Write(&envelope)
+	return e.write(ctx, &envelope)
 }
 
 // Subscribe to events on the exchange. Events are sent through the returned
@@ -127,23 +415,24 @@ func (e *Exchange) Publish(ctx context.Context, topic string, event events.Event
 // the standard containerd filters package syntax.
 func (e *Exchange) Subscribe(ctx context.Context, fs ...string) (ch <-chan *events.Envelope, errs <-chan error) {
 	var (
-		evch                  = make(chan *events.Envelope)
-		errq                  = make(chan error, 1)
-		channel               = goevents.NewChannel(0)
-		queue                 = goevents.NewQueue(channel)
-		dst     goevents.Sink = queue
+		evch  = make(chan *events.Envelope)
+		errq  = make(chan error, 1)
+		queue = newBoundedQueue(e.subscriberBuffer)
+		sub   = &subscription{sink: queue}
 	)
 
 	closeAll := func() {
-		channel.Close()
 		queue.Close()
-		e.broadcaster.Remove(dst)
+		e.remove(sub)
 		close(errq)
 	}
 
 	ch = evch
 	errs = errq
 
+	fs, noCoalesce := StripNoCoalesce(fs)
+	sub.noCoalesce = noCoalesce
+
 	if len(fs) > 0 {
 		filter, err := filters.ParseAll(fs...)
 		if err != nil {
@@ -151,13 +440,15 @@ func (e *Exchange) Subscribe(ctx context.Context, fs ...string) (ch <-chan *even
 			closeAll()
 			return
 		}
-
-		dst = goevents.NewFilter(queue, goevents.MatcherFunc(func(gev goevents.Event) bool {
-			return filter.Match(adapt(gev))
-		}))
+		sub.matcher = filter
+		for _, f := range fs {
+			sub.keys = append(sub.keys, indexKeysForFilter(f))
+		}
+	} else {
+		sub.keys = []indexKey{{kind: indexGeneral}}
 	}
 
-	e.broadcaster.Add(dst)
+	e.add(sub)
 
 	go func() {
 		defer closeAll()
@@ -166,21 +457,22 @@ func (e *Exchange) Subscribe(ctx context.Context, fs ...string) (ch <-chan *even
 	loop:
 		for {
 			select {
-			case ev := <-channel.C:
-				env, ok := ev.(*events.Envelope)
-				if !ok {
-					// TODO(stevvooe): For the most part, we are well protected
-					// from this condition. Both Forward and Publish protect
-					// from this.
-					err = fmt.Errorf("invalid envelope encountered %#v; please file a bug", ev)
-					break
-				}
-
+			case env := <-queue.ch:
 				select {
 				case evch <- env:
+				case <-queue.overflowed:
+					// events were already lost; end the subscription
+					// even though the consumer is mid-read
+					err = fmt.Errorf("event subscriber overflowed its buffer of %d envelopes", cap(queue.ch))
+					break loop
 				case <-ctx.Done():
 					break loop
 				}
+			case <-queue.overflowed:
+				// the subscriber fell too far behind; fail explicitly
+				// instead of silently dropping or reordering
+				err = fmt.Errorf("event subscriber overflowed its buffer of %d envelopes", cap(queue.ch))
+				break loop
 			case <-ctx.Done():
 				break loop
 			}
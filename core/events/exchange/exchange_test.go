@@ -19,6 +19,9 @@ package exchange
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -324,3 +327,199 @@ func TestExchangeValidateTopic(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscribeInvalidRegexp(t *testing.T) {
+	exchange := NewExchange()
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), t.Name()))
+	defer cancel()
+
+	_, errq := exchange.Subscribe(ctx, `topic~="[invalid"`)
+	select {
+	case err := <-errq:
+		if !errdefs.IsInvalidArgument(err) {
+			t.Fatalf("expected invalid argument, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no error for invalid regular expression")
+	}
+}
+
+// benchmarkPublish measures publish cost with 1k subscribers whose
+// filters use the given per-subscriber pattern.
+func benchmarkPublish(b *testing.B, filterFor func(i int) string) {
+	exchange := NewExchange()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := range 1000 {
+		evq, _ := exchange.Subscribe(ctx, filterFor(i))
+		go func() {
+			for range evq {
+			}
+		}()
+	}
+
+	pctx := namespaces.WithNamespace(ctx, "ns-1")
+	event := &eventstypes.ContainerCreate{ID: "bench"}
+	b.ResetTimer()
+	for range b.N {
+		if err := exchange.Publish(pctx, "/containers/create", event); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPublish1kNamespaceSubscribers(b *testing.B) {
+	benchmarkPublish(b, func(i int) string {
+		return fmt.Sprintf(`namespace=="ns-%d"`, i)
+	})
+}
+
+func BenchmarkPublish1kTopicRegexSubscribers(b *testing.B) {
+	benchmarkPublish(b, func(i int) string {
+		return fmt.Sprintf(`topic~="^/stream-%d/"`, i)
+	})
+}
+
+func BenchmarkPublish1kUnindexedSubscribers(b *testing.B) {
+	benchmarkPublish(b, func(i int) string {
+		return fmt.Sprintf(`event.id=="sub-%d"`, i)
+	})
+}
+
+func TestCoalescing(t *testing.T) {
+	exchange := NewExchange(WithCoalescing("/tasks/exec-", 100*time.Millisecond))
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), "coal"))
+	defer cancel()
+
+	batched, _ := exchange.Subscribe(ctx)
+	raw, _ := exchange.Subscribe(ctx, `coalesce=="false"`)
+
+	for i := 0; i < 5; i++ {
+		if err := exchange.Publish(ctx, "/tasks/exec-started", &eventstypes.TaskExecStarted{ExecID: fmt.Sprintf("e%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the opted-out subscriber sees five raw envelopes immediately
+	for i := 0; i < 5; i++ {
+		select {
+		case env := <-raw:
+			if env.Topic != "/tasks/exec-started" {
+				t.Fatalf("unexpected topic %q", env.Topic)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("raw subscriber did not receive event", i)
+		}
+	}
+
+	// the regular subscriber sees one batch after the interval
+	select {
+	case env := <-batched:
+		v, err := typeurl.UnmarshalAny(env.Event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ce, ok := v.(*CoalescedEvents)
+		if !ok {
+			t.Fatalf("expected CoalescedEvents, got %T", v)
+		}
+		if ce.Count != 5 || len(ce.Events) != 5 {
+			t.Fatalf("expected 5 coalesced events, got %d", ce.Count)
+		}
+		if env.Topic != "/tasks/exec-started" || env.Namespace != "coal" {
+			t.Fatalf("batch envelope misaddressed: %s %s", env.Namespace, env.Topic)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no coalesced envelope delivered")
+	}
+
+	// uncovered topics are untouched
+	if err := exchange.Publish(ctx, "/tasks/create", &eventstypes.TaskCreate{ContainerID: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case env := <-batched:
+		if env.Topic != "/tasks/create" {
+			t.Fatalf("unexpected topic %q", env.Topic)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("uncoalesced topic not delivered")
+	}
+}
+
+func TestPublishRateLimit(t *testing.T) {
+	exchange := NewExchange(WithPublishRateLimit(10, 1))
+	ctx := namespaces.WithNamespace(context.Background(), "rate")
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := exchange.Publish(ctx, "/test", &eventstypes.ContainerCreate{ID: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 4 events at 10/s with burst 1 needs ~300ms of waiting
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Fatalf("publishers were not backpressured: %v", elapsed)
+	}
+}
+
+func TestSequenceOrdering(t *testing.T) {
+	exchange := NewExchange()
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), "seq"))
+	defer cancel()
+
+	evq, _ := exchange.Subscribe(ctx, `namespace=="seq"`)
+
+	// hammer from several publishers concurrently
+	var wg sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if err := exchange.Publish(ctx, "/test", &eventstypes.ContainerCreate{ID: "x"}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var last uint64
+	for i := 0; i < 200; i++ {
+		select {
+		case env := <-evq:
+			if env.Sequence != last+1 {
+				t.Fatalf("sequence gap or reorder: got %d after %d", env.Sequence, last)
+			}
+			last = env.Sequence
+		case <-time.After(2 * time.Second):
+			t.Fatalf("missing events after sequence %d", last)
+		}
+	}
+}
+
+func TestSubscriberOverflow(t *testing.T) {
+	exchange := NewExchange(WithSubscriberBuffer(4))
+	ctx, cancel := context.WithCancel(namespaces.WithNamespace(context.Background(), "ovf"))
+	defer cancel()
+
+	// never read from evq so the buffer fills
+	_, errq := exchange.Subscribe(ctx)
+	for i := 0; i < 10; i++ {
+		if err := exchange.Publish(ctx, "/test", &eventstypes.ContainerCreate{ID: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case err := <-errq:
+		if err == nil || !strings.Contains(err.Error(), "overflowed") {
+			t.Fatalf("expected overflow error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no overflow error delivered")
+	}
+}
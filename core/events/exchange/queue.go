@@ -0,0 +1,83 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package exchange
+
+import (
+	"sync"
+
+	goevents "github.com/docker/go-events"
+
+	"github.com/containerd/containerd/v2/core/events"
+)
+
+// defaultSubscriberBuffer bounds how many envelopes may be pending per
+// subscriber, see WithSubscriberBuffer.
+const defaultSubscriberBuffer = 4096
+
+// boundedQueue is a per-subscriber FIFO with a hard capacity. Writes
+// never block delivery: when the subscriber falls behind its buffer, the
+// queue flips to overflowed and the subscription ends with an explicit
+// error, preserving the in-order guarantee instead of dropping or
+// reordering.
+type boundedQueue struct {
+	ch         chan *events.Envelope
+	overflowed chan struct{}
+	once       sync.Once
+	closed     sync.Once
+}
+
+func newBoundedQueue(size int) *boundedQueue {
+	if size <= 0 {
+		size = defaultSubscriberBuffer
+	}
+	return &boundedQueue{
+		ch:         make(chan *events.Envelope, size),
+		overflowed: make(chan struct{}),
+	}
+}
+
+// Write implements goevents.Sink; it never blocks.
+func (q *boundedQueue) Write(ev goevents.Event) error {
+	env, ok := ev.(*events.Envelope)
+	if !ok {
+		return goevents.ErrSinkClosed
+	}
+	select {
+	case <-q.overflowed:
+		return goevents.ErrSinkClosed
+	default:
+	}
+	select {
+	case q.ch <- env:
+		return nil
+	default:
+		q.once.Do(func() {
+			close(q.overflowed)
+		})
+		return goevents.ErrSinkClosed
+	}
+}
+
+// Close implements goevents.Sink.
+func (q *boundedQueue) Close() error {
+	q.closed.Do(func() {
+		q.once.Do(func() {
+			close(q.overflowed)
+		})
+	})
+	return nil
+}
@@ -0,0 +1,239 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package journal persists event envelopes in a bounded bolt database so
+// subscribers that were down can replay what they missed. Delivery from
+// the journal is at-least-once: an event published while a replay is
+// running can be seen both from the journal and live, and consumers
+// should deduplicate on (namespace, topic, timestamp).
+package journal
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/containerd/errdefs"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/containerd/containerd/v2/core/events"
+)
+
+// ReplayEndTopic is the synthetic envelope topic the events service sends
+// after the last replayed envelope, marking the switch to live delivery.
+const ReplayEndTopic = "/journal/replay-end"
+
+var bucketEvents = []byte("events")
+
+// storedEnvelope is the journal's on-disk envelope encoding.
+type storedEnvelope struct {
+	Timestamp time.Time `json:"timestamp"`
+	Namespace string    `json:"namespace"`
+	Topic     string    `json:"topic"`
+	TypeURL   string    `json:"type_url,omitempty"`
+	Value     []byte    `json:"value,omitempty"`
+}
+
+// Position identifies where a replay starts: by sequence when Sequence is
+// non-zero, otherwise by time.
+type Position struct {
+	Sequence uint64
+	Time     time.Time
+}
+
+// Journal is a bounded, persistent log of event envelopes.
+type Journal struct {
+	db         *bolt.DB
+	maxEntries uint64
+	maxAge     time.Duration
+}
+
+// Opt configures a Journal.
+type Opt func(*Journal)
+
+// WithMaxEntries bounds how many envelopes are retained.
+func WithMaxEntries(n uint64) Opt {
+	return func(j *Journal) {
+		j.maxEntries = n
+	}
+}
+
+// WithMaxAge drops envelopes older than d during pruning.
+func WithMaxAge(d time.Duration) Opt {
+	return func(j *Journal) {
+		j.maxAge = d
+	}
+}
+
+// Open opens (creating if needed) a journal at the given path.
+func Open(path string, opts ...Opt) (*Journal, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening event journal: %w", err)
+	}
+	j := &Journal{
+		db:         db,
+		maxEntries: 8192,
+		maxAge:     24 * time.Hour,
+	}
+	for _, o := range opts {
+		o(j)
+	}
+	return j, nil
+}
+
+// Close closes the journal.
+func (j *Journal) Close() error {
+	return j.db.Close()
+}
+
+func encodeSeq(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return b
+}
+
+// Append records the envelope, assigns it the next sequence number and
+// prunes entries beyond the retention bounds.
+func (j *Journal) Append(envelope *events.Envelope) (uint64, error) {
+	stored := storedEnvelope{
+		Timestamp: envelope.Timestamp,
+		Namespace: envelope.Namespace,
+		Topic:     envelope.Topic,
+	}
+	if envelope.Event != nil {
+		stored.TypeURL = envelope.Event.GetTypeUrl()
+		stored.Value = envelope.Event.GetValue()
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return 0, err
+	}
+
+	var seq uint64
+	err = j.db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists(bucketEvents)
+		if err != nil {
+			return err
+		}
+		seq, err = bkt.NextSequence()
+		if err != nil {
+			return err
+		}
+		if err := bkt.Put(encodeSeq(seq), data); err != nil {
+			return err
+		}
+		return j.prune(bkt, seq)
+	})
+	return seq, err
+}
+
+// prune enforces the entry count and age bounds, oldest first.
+func (j *Journal) prune(bkt *bolt.Bucket, newest uint64) error {
+	cutoff := time.Now().Add(-j.maxAge)
+	c := bkt.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		seq := binary.BigEndian.Uint64(k)
+		over := j.maxEntries > 0 && newest-seq >= j.maxEntries
+		if !over && j.maxAge > 0 {
+			var stored storedEnvelope
+			if err := json.Unmarshal(v, &stored); err == nil && stored.Timestamp.Before(cutoff) {
+				over = true
+			}
+		}
+		if !over {
+			break
+		}
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OldestSequence returns the lowest retained sequence, zero when the
+// journal is empty.
+func (j *Journal) OldestSequence() (uint64, error) {
+	var seq uint64
+	err := j.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketEvents)
+		if bkt == nil {
+			return nil
+		}
+		if k, _ := bkt.Cursor().First(); k != nil {
+			seq = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	return seq, err
+}
+
+// Replay streams retained envelopes at or after the position, in order.
+// Asking for a sequence that has already been pruned (or predates the
+// journal) fails with ErrOutOfRange so the consumer knows it has a gap it
+// cannot recover, instead of silently starting later.
+func (j *Journal) Replay(ctx context.Context, from Position, fn func(seq uint64, envelope *events.Envelope) error) error {
+	if from.Sequence > 0 {
+		oldest, err := j.OldestSequence()
+		if err != nil {
+			return err
+		}
+		if oldest == 0 || from.Sequence < oldest {
+			return fmt.Errorf("sequence %d is no longer retained (oldest is %d): %w", from.Sequence, oldest, errdefs.ErrOutOfRange)
+		}
+	}
+	return j.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(bucketEvents)
+		if bkt == nil {
+			return nil
+		}
+		c := bkt.Cursor()
+		var k, v []byte
+		if from.Sequence > 0 {
+			k, v = c.Seek(encodeSeq(from.Sequence))
+		} else {
+			k, v = c.First()
+		}
+		for ; k != nil; k, v = c.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			var stored storedEnvelope
+			if err := json.Unmarshal(v, &stored); err != nil {
+				return err
+			}
+			if from.Sequence == 0 && stored.Timestamp.Before(from.Time) {
+				continue
+			}
+			envelope := &events.Envelope{
+				Timestamp: stored.Timestamp,
+				Namespace: stored.Namespace,
+				Topic:     stored.Topic,
+			}
+			if stored.TypeURL != "" {
+				envelope.Event = &anypb.Any{TypeUrl: stored.TypeURL, Value: stored.Value}
+			}
+			if err := fn(binary.BigEndian.Uint64(k), envelope); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package journal
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/errdefs"
+
+	"github.com/containerd/containerd/v2/core/events"
+)
+
+func open(t *testing.T, opts ...Opt) *Journal {
+	t.Helper()
+	j, err := Open(filepath.Join(t.TempDir(), "events.db"), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+func appendN(t *testing.T, j *Journal, n int, start time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := j.Append(&events.Envelope{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Namespace: "test",
+			Topic:     fmt.Sprintf("/topic/%d", i),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func replayTopics(t *testing.T, j *Journal, from Position) []string {
+	t.Helper()
+	var topics []string
+	if err := j.Replay(context.Background(), from, func(seq uint64, envelope *events.Envelope) error {
+		topics = append(topics, envelope.Topic)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return topics
+}
+
+func TestJournalReplayFromSequence(t *testing.T) {
+	j := open(t)
+	appendN(t, j, 5, time.Now())
+
+	topics := replayTopics(t, j, Position{Sequence: 3})
+	if len(topics) != 3 || topics[0] != "/topic/2" || topics[2] != "/topic/4" {
+		t.Fatalf("unexpected replay %v", topics)
+	}
+}
+
+func TestJournalReplayFromTime(t *testing.T) {
+	j := open(t)
+	start := time.Now().Add(-time.Hour)
+	appendN(t, j, 5, start)
+
+	topics := replayTopics(t, j, Position{Time: start.Add(2500 * time.Millisecond)})
+	if len(topics) != 2 || topics[0] != "/topic/3" {
+		t.Fatalf("unexpected replay %v", topics)
+	}
+}
+
+func TestJournalPruneByCount(t *testing.T) {
+	j := open(t, WithMaxEntries(3))
+	appendN(t, j, 10, time.Now())
+
+	oldest, err := j.OldestSequence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oldest != 8 {
+		t.Fatalf("expected oldest sequence 8, got %d", oldest)
+	}
+	if topics := replayTopics(t, j, Position{Sequence: 8}); len(topics) != 3 {
+		t.Fatalf("unexpected retained %v", topics)
+	}
+}
+
+func TestJournalPruneByAge(t *testing.T) {
+	j := open(t, WithMaxAge(time.Minute))
+	appendN(t, j, 3, time.Now().Add(-time.Hour))
+	if _, err := j.Append(&events.Envelope{Timestamp: time.Now(), Namespace: "test", Topic: "/fresh"}); err != nil {
+		t.Fatal(err)
+	}
+	if topics := replayTopics(t, j, Position{}); len(topics) != 1 || topics[0] != "/fresh" {
+		t.Fatalf("aged entries not pruned: %v", topics)
+	}
+}
+
+func TestJournalTruncatedPosition(t *testing.T) {
+	j := open(t, WithMaxEntries(2))
+	appendN(t, j, 5, time.Now())
+
+	err := j.Replay(context.Background(), Position{Sequence: 1}, func(uint64, *events.Envelope) error { return nil })
+	if !errdefs.IsOutOfRange(err) {
+		t.Fatalf("expected out of range, got %v", err)
+	}
+}
@@ -40,6 +40,7 @@ import (
 )
 
 type exportOptions struct {
+	deterministic      bool
 	manifests          []ocispec.Descriptor
 	platform           platforms.MatchComparer
 	allPlatforms       bool
@@ -51,6 +52,19 @@ type exportOptions struct {
 // ExportOpt defines options for configuring exported descriptors
 type ExportOpt func(context.Context, *exportOptions) error
 
+// WithDeterministicOutput normalizes the export so the same content
+// always yields a byte-identical archive: manifest entries are ordered
+// canonically by digest (independent of the order the image store returned
+// them), and entry names were already written sorted with zeroed
+// timestamps and no non-deterministic pax records. Byte-identical exports
+// make supply-chain attestation of exported bundles possible.
+func WithDeterministicOutput() ExportOpt {
+	return func(ctx context.Context, o *exportOptions) error {
+		o.deterministic = true
+		return nil
+	}
+}
+
 // WithPlatform defines the platform to require manifest lists have
 // not exporting all platforms.
 // Additionally, platform is used to resolve image configs for
@@ -243,6 +257,15 @@ func Export(ctx context.Context, store content.InfoReaderProvider, writer io.Wri
 		}
 		manifests = append(manifests, d)
 	}
+	if eo.deterministic {
+		// order independent of how the image store returned the manifests
+		sort.SliceStable(manifests, func(i, j int) bool {
+			if manifests[i].Digest != manifests[j].Digest {
+				return manifests[i].Digest < manifests[j].Digest
+			}
+			return manifests[i].Annotations[images.AnnotationImageName] < manifests[j].Annotations[images.AnnotationImageName]
+		})
+	}
 
 	algorithms := map[string]struct{}{}
 	dManifests := map[digest.Digest]*exportManifest{}
@@ -0,0 +1,97 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+)
+
+func writeBlob(t *testing.T, cs content.Store, mediaType string, data []byte) ocispec.Descriptor {
+	t.Helper()
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	if err := content.WriteBlob(context.Background(), cs, "export-"+desc.Digest.Encoded(), bytes.NewReader(data), desc); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+func buildManifest(t *testing.T, cs content.Store, arch string) ocispec.Descriptor {
+	t.Helper()
+	layer := writeBlob(t, cs, ocispec.MediaTypeImageLayerGzip, []byte("layer for "+arch))
+	config := writeBlob(t, cs, ocispec.MediaTypeImageConfig, []byte(fmt.Sprintf(`{"architecture":%q,"os":"linux"}`, arch)))
+	mb, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := writeBlob(t, cs, ocispec.MediaTypeImageManifest, mb)
+	desc.Platform = &ocispec.Platform{OS: "linux", Architecture: arch}
+	return desc
+}
+
+// TestDeterministicExport shows the same multi-manifest content exported
+// twice, with the manifests supplied in different orders, produces byte
+// identical archives when deterministic output is requested.
+func TestDeterministicExport(t *testing.T) {
+	ctx := context.Background()
+
+	cs, err := local.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amd := buildManifest(t, cs, "amd64")
+	arm := buildManifest(t, cs, "arm64")
+	amd.Annotations = map[string]string{"io.containerd.image.name": "registry.example.com/app:amd"}
+	arm.Annotations = map[string]string{"io.containerd.image.name": "registry.example.com/app:arm"}
+
+	export := func(descs ...ocispec.Descriptor) []byte {
+		var buf bytes.Buffer
+		opts := []ExportOpt{WithDeterministicOutput(), WithAllPlatforms(), WithSkipDockerManifest()}
+		for _, d := range descs {
+			opts = append(opts, WithManifest(d))
+		}
+		if err := Export(ctx, cs, &buf, opts...); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	first := export(amd, arm)
+	second := export(arm, amd)
+
+	if digest.FromBytes(first) != digest.FromBytes(second) {
+		t.Fatalf("exports differ across runs: %s != %s", digest.FromBytes(first), digest.FromBytes(second))
+	}
+}
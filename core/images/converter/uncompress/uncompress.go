@@ -47,6 +47,9 @@ func LayerConvertFunc(ctx context.Context, cs content.Store, desc ocispec.Descri
 	if err != nil {
 		return nil, err
 	}
+	// The blob is decompressed sequentially, batch the small reads
+	// through a readahead window.
+	readerAt = content.NewReadaheadReaderAt(readerAt, 0)
 	defer readerAt.Close()
 	sr := io.NewSectionReader(readerAt, 0, desc.Size)
 	newR, err := compression.DecompressStream(sr)
@@ -83,6 +83,8 @@ func adaptContainer(o any) filters.Adaptor {
 			}
 		case "image":
 			return obj.Image, len(obj.Image) > 0
+		case "sandbox":
+			return obj.SandboxID, len(obj.SandboxID) > 0
 		case "labels":
 			return checkMap(fieldpath[1:], obj.Labels)
 		}
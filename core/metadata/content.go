@@ -39,6 +39,10 @@ import (
 	"github.com/containerd/containerd/v2/pkg/namespaces"
 )
 
+// defaultWalkPageSize bounds how many blob records a Walk reads per
+// transaction when the caller does not set a page size on the context.
+const defaultWalkPageSize = 1024
+
 type contentStore struct {
 	content.Store
 	db     *DB
@@ -159,43 +163,76 @@ func (cs *contentStore) Walk(ctx context.Context, fn content.WalkFunc, fs ...str
 		return err
 	}
 
-	// TODO: Batch results to keep from reading all info into memory
-	var infos []content.Info
-	if err := view(ctx, cs.db, func(tx *bolt.Tx) error {
-		bkt := getBlobsBucket(tx, ns)
-		if bkt == nil {
-			return nil
-		}
+	pageSize := content.WalkPageSize(ctx)
+	if pageSize <= 0 {
+		pageSize = defaultWalkPageSize
+	}
 
-		return bkt.ForEach(func(k, v []byte) error {
-			dgst, err := digest.Parse(string(k))
-			if err != nil {
-				// Not a digest, skip
-				return nil
-			}
-			bbkt := bkt.Bucket(k)
-			if bbkt == nil {
+	// Filters are evaluated inside the transaction so only matching info
+	// records are buffered, and reads are paged so walking a huge store
+	// does not hold all records in memory at once.
+	var (
+		infos []content.Info
+		last  string
+		more  = true
+	)
+	for more {
+		if err := view(ctx, cs.db, func(tx *bolt.Tx) error {
+			infos = infos[:0]
+			more = false
+
+			bkt := getBlobsBucket(tx, ns)
+			if bkt == nil {
 				return nil
 			}
-			info := content.Info{
-				Digest: dgst,
-			}
-			if err := readInfo(&info, bkt.Bucket(k)); err != nil {
-				return err
+
+			cur := bkt.Cursor()
+			k, v := cur.First()
+			if last != "" {
+				if k, v = cur.Seek([]byte(last)); string(k) == last {
+					k, v = cur.Next()
+				}
 			}
-			if filter.Match(content.AdaptInfo(info)) {
-				infos = append(infos, info)
+			for scanned := 0; k != nil; k, v = cur.Next() {
+				if v != nil {
+					continue
+				}
+				if scanned >= pageSize {
+					more = true
+					return nil
+				}
+				scanned++
+				last = string(k)
+
+				dgst, err := digest.Parse(string(k))
+				if err != nil {
+					// Not a digest, skip
+					continue
+				}
+				bbkt := bkt.Bucket(k)
+				if bbkt == nil {
+					continue
+				}
+				info := content.Info{
+					Digest: dgst,
+				}
+				if err := readInfo(&info, bbkt); err != nil {
+					return err
+				}
+				if filter.Match(content.AdaptInfo(info)) {
+					infos = append(infos, info)
+				}
 			}
 			return nil
-		})
-	}); err != nil {
-		return err
-	}
-
-	for _, info := range infos {
-		if err := fn(info); err != nil {
+		}); err != nil {
 			return err
 		}
+
+		for _, info := range infos {
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -242,6 +279,57 @@ func (cs *contentStore) Delete(ctx context.Context, dgst digest.Digest) error {
 	return nil
 }
 
+// DeleteAll removes the content for each of the provided digests in a single
+// transaction. The transaction is atomic: on error nothing is removed and the
+// returned slice is empty.
+func (cs *contentStore) DeleteAll(ctx context.Context, dgsts []digest.Digest) ([]digest.Digest, error) {
+	ns, err := namespaces.NamespaceRequired(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.l.RLock()
+	defer cs.l.RUnlock()
+
+	deleted := make([]digest.Digest, 0, len(dgsts))
+	if err := update(ctx, cs.db, func(tx *bolt.Tx) error {
+		deleted = deleted[:0]
+		for _, dgst := range dgsts {
+			bkt := getBlobBucket(tx, ns, dgst)
+			if bkt == nil {
+				return fmt.Errorf("content digest %v: %w", dgst, errdefs.ErrNotFound)
+			}
+
+			if err := getBlobsBucket(tx, ns).DeleteBucket([]byte(dgst.String())); err != nil {
+				return err
+			}
+			if err := removeContentLease(ctx, tx, dgst); err != nil {
+				return err
+			}
+			deleted = append(deleted, dgst)
+		}
+
+		// Mark content store as dirty for triggering garbage collection
+		cs.db.dirty.Add(1)
+		cs.db.dirtyCS = true
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if publisher := cs.db.Publisher(ctx); publisher != nil {
+		for _, dgst := range deleted {
+			if err := publisher.Publish(ctx, "/content/delete", &eventstypes.ContentDelete{
+				Digest: dgst.String(),
+			}); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	return deleted, nil
+}
+
 func (cs *contentStore) ListStatuses(ctx context.Context, fs ...string) ([]content.Status, error) {
 	ns, err := namespaces.NamespaceRequired(ctx)
 	if err != nil {
@@ -663,6 +751,16 @@ func (nw *namespacedWriter) commit(ctx context.Context, tx *bolt.Tx, size int64,
 		}
 		return "", 0, err
 	}
+	if mt := nw.desc.MediaType; mt != "" {
+		// Record the media type so walks can filter on it without
+		// resolving it from manifests.
+		if _, ok := base.Labels[labels.LabelMediaType]; !ok {
+			if base.Labels == nil {
+				base.Labels = map[string]string{}
+			}
+			base.Labels[labels.LabelMediaType] = mt
+		}
+	}
 
 	var actual digest.Digest
 	if nw.w == nil {
@@ -912,12 +1010,10 @@ func (cs *contentStore) garbageCollect(ctx context.Context) (d time.Duration, er
 		return 0, err
 	}
 
+	var unreferenced []digest.Digest
 	err = cs.Store.Walk(ctx, func(info content.Info) error {
 		if _, ok := contentSeen[info.Digest.String()]; !ok {
-			if err := cs.Store.Delete(ctx, info.Digest); err != nil {
-				return err
-			}
-			log.G(ctx).WithField("digest", info.Digest).Debug("removed content")
+			unreferenced = append(unreferenced, info.Digest)
 		}
 		return nil
 	})
@@ -925,6 +1021,26 @@ func (cs *contentStore) garbageCollect(ctx context.Context) (d time.Duration, er
 		return
 	}
 
+	// Batch the removal when the underlying store supports it, otherwise
+	// fall back to deleting one blob at a time.
+	if bd, ok := cs.Store.(content.BatchDeleter); ok {
+		var removed []digest.Digest
+		removed, err = bd.DeleteAll(ctx, unreferenced)
+		for _, dgst := range removed {
+			log.G(ctx).WithField("digest", dgst).Debug("removed content")
+		}
+		if err != nil {
+			return
+		}
+	} else {
+		for _, dgst := range unreferenced {
+			if err = cs.Store.Delete(ctx, dgst); err != nil {
+				return
+			}
+			log.G(ctx).WithField("digest", dgst).Debug("removed content")
+		}
+	}
+
 	// If the content store has implemented a more efficient walk function
 	// then use that else fallback to reading all statuses which may
 	// cause reading of unneeded metadata.
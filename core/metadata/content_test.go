@@ -94,6 +94,108 @@ func TestContent(t *testing.T) {
 		}...))
 }
 
+func TestContentDeleteAll(t *testing.T) {
+	ctx, db := testDB(t)
+
+	cs := db.ContentStore()
+
+	var dgsts []digest.Digest
+	for i := 0; i < 3; i++ {
+		blob := []byte(fmt.Sprintf("batch delete content %d", i))
+		dgst := digest.FromBytes(blob)
+		if err := content.WriteBlob(ctx, cs, fmt.Sprintf("batch-%d", i), bytes.NewReader(blob),
+			ocispec.Descriptor{Size: int64(len(blob)), Digest: dgst}); err != nil {
+			t.Fatal(err)
+		}
+		dgsts = append(dgsts, dgst)
+	}
+
+	bd, ok := cs.(content.BatchDeleter)
+	if !ok {
+		t.Fatal("metadata content store should implement content.BatchDeleter")
+	}
+
+	// A missing digest fails the whole transaction and nothing is removed.
+	missing := digest.FromBytes([]byte("not written"))
+	deleted, err := bd.DeleteAll(ctx, []digest.Digest{dgsts[0], missing})
+	if !errdefs.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Fatalf("expected no deletions on error, got %v", deleted)
+	}
+	if _, err := cs.Info(ctx, dgsts[0]); err != nil {
+		t.Fatalf("content should still exist after failed batch: %v", err)
+	}
+
+	deleted, err = bd.DeleteAll(ctx, dgsts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != len(dgsts) {
+		t.Fatalf("expected %d deletions, got %v", len(dgsts), deleted)
+	}
+	for _, dgst := range dgsts {
+		if _, err := cs.Info(ctx, dgst); !errdefs.IsNotFound(err) {
+			t.Fatalf("expected %v to be deleted, got %v", dgst, err)
+		}
+	}
+}
+
+func BenchmarkContentWalkFiltered(b *testing.B) {
+	ctx := namespaces.WithNamespace(context.Background(), "testing")
+
+	dirname := b.TempDir()
+	lcs, err := local.NewStore(filepath.Join(dirname, "content"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	bdb, err := bolt.Open(filepath.Join(dirname, "metadata.db"), 0644, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer bdb.Close()
+	cs := NewDB(bdb, lcs, nil).ContentStore()
+
+	kinds := []string{"even", "odd"}
+	for i := 0; i < 2000; i++ {
+		blob := fmt.Appendf(nil, "filtered walk content %d", i)
+		if err := content.WriteBlob(ctx, cs, fmt.Sprintf("walk-%d", i), bytes.NewReader(blob),
+			ocispec.Descriptor{Size: int64(len(blob)), Digest: digest.FromBytes(blob)},
+			content.WithLabels(map[string]string{"kind": kinds[i%2]})); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, bench := range []struct {
+		name     string
+		pageSize int
+	}{
+		{name: "DefaultPage"},
+		{name: "Page100", pageSize: 100},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			ctx := ctx
+			if bench.pageSize > 0 {
+				ctx = content.WalkWithPageSize(ctx, bench.pageSize)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var matched int
+				if err := cs.Walk(ctx, func(content.Info) error {
+					matched++
+					return nil
+				}, `labels.kind==odd`); err != nil {
+					b.Fatal(err)
+				}
+				if matched != 1000 {
+					b.Fatalf("expected 1000 matches, got %d", matched)
+				}
+			}
+		})
+	}
+}
+
 func TestContentLeased(t *testing.T) {
 	ctx, db := testDB(t)
 
@@ -70,10 +70,50 @@ func WithEventsPublisher(p events.Publisher) DBOpt {
 	}
 }
 
+// WithCleanupTimeout bounds how long each snapshotter's and the content
+// store's cleanup pass may run after a garbage collection. Zero, the
+// default, leaves cleanup unbounded.
+func WithCleanupTimeout(d time.Duration) DBOpt {
+	return func(o *dbOptions) {
+		o.cleanupTimeout = d
+	}
+}
+
+// WithCleanupConcurrency limits how many snapshotter cleanups run at the
+// same time after a garbage collection. Zero, the default, runs them all
+// concurrently.
+func WithCleanupConcurrency(n int) DBOpt {
+	return func(o *dbOptions) {
+		o.cleanupConcurrency = n
+	}
+}
+
+// WithSnapshotRetention keeps unreferenced snapshots collectible only
+// after they have been idle for the given duration, based on the last time
+// they were prepared from. Zero, the default, collects unreferenced
+// snapshots immediately. A single collection can bypass retention through
+// WithFullCollection on the context.
+func WithSnapshotRetention(d time.Duration) DBOpt {
+	return func(o *dbOptions) {
+		o.snapshotRetention = d
+	}
+}
+
+// WithoutExpiration disables enforcement of the expiration label during
+// garbage collection. Expired content and snapshots keep their root
+// behavior and are never collected solely because their expiration passed.
+func WithoutExpiration(o *dbOptions) {
+	o.noExpiration = true
+}
+
 // dbOptions configure db options.
 type dbOptions struct {
-	shared    bool
-	publisher events.Publisher
+	shared             bool
+	publisher          events.Publisher
+	noExpiration       bool
+	cleanupTimeout     time.Duration
+	cleanupConcurrency int
+	snapshotRetention  time.Duration
 }
 
 // DB represents a metadata database backed by a bolt
@@ -356,6 +396,8 @@ func (m *DB) publishEvents(events []namespacedEvent) {
 				topic = "/images/delete"
 			case *eventstypes.SnapshotRemove:
 				topic = "/snapshot/remove"
+			case *eventstypes.ContentDelete:
+				topic = "/content/delete"
 			default:
 				log.G(ctx).WithField("event", ne.event).Debug("unhandled event type from garbage collection removal")
 				continue
@@ -367,6 +409,20 @@ func (m *DB) publishEvents(events []namespacedEvent) {
 	}
 }
 
+type fullCollectionKey struct{}
+
+// WithFullCollection marks the garbage collection triggered with this
+// context as a full one: the snapshot retention policy is bypassed and
+// every unreferenced resource is collected immediately.
+func WithFullCollection(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fullCollectionKey{}, true)
+}
+
+func fullCollection(ctx context.Context) bool {
+	v, _ := ctx.Value(fullCollectionKey{}).(bool)
+	return v
+}
+
 // GCStats holds the duration for the different phases of the garbage collector
 type GCStats struct {
 	MetaD     time.Duration
@@ -379,11 +435,21 @@ func (s GCStats) Elapsed() time.Duration {
 	return s.MetaD
 }
 
+// SnapshotDurations reports how long each snapshotter's cleanup took, for
+// the gc scheduler's per-snapshotter metrics.
+func (s GCStats) SnapshotDurations() map[string]time.Duration {
+	return s.SnapshotD
+}
+
 // GarbageCollect removes resources (snapshots, contents, ...) that are no longer used.
 func (m *DB) GarbageCollect(ctx context.Context) (gc.Stats, error) {
 	m.wlock.Lock()
 	t1 := time.Now()
-	c := startGCContext(ctx, m.collectors)
+	retention := m.dbopts.snapshotRetention
+	if fullCollection(ctx) {
+		retention = 0
+	}
+	c := startGCContext(ctx, m.collectors, !m.dbopts.noExpiration, retention)
 
 	marked, err := m.getMarked(ctx, c) // Pass in gc context
 	if err != nil {
@@ -413,6 +479,16 @@ func (m *DB) GarbageCollect(ctx context.Context) (gc.Stats, error) {
 			}
 
 			event, err := c.remove(ctx, tx, n)
+			if event == nil && err == nil && n.Type == ResourceContent {
+				// Expired content is collected without an explicit
+				// delete call, emit an event so the collection can
+				// be audited.
+				if _, expired := c.expired[n]; expired {
+					event = &eventstypes.ContentDelete{
+						Digest: n.Key,
+					}
+				}
+			}
 			if event != nil && err == nil {
 				events = append(events,
 					namespacedEvent{
@@ -447,20 +523,29 @@ func (m *DB) GarbageCollect(ctx context.Context) (gc.Stats, error) {
 	m.dirty.Store(0)
 
 	if len(m.dirtySS) > 0 {
-		var sl sync.Mutex
+		var (
+			sl  sync.Mutex
+			sem chan struct{}
+		)
+		if n := m.dbopts.cleanupConcurrency; n > 0 && n < len(m.dirtySS) {
+			sem = make(chan struct{}, n)
+		}
 		stats.SnapshotD = map[string]time.Duration{}
 		wg.Add(len(m.dirtySS))
 		for snapshotterName := range m.dirtySS {
 			log.G(ctx).WithField("snapshotter", snapshotterName).Debug("schedule snapshotter cleanup")
 			go func(snapshotterName string) {
+				defer wg.Done()
+				if sem != nil {
+					sem <- struct{}{}
+					defer func() { <-sem }()
+				}
 				st1 := time.Now()
 				m.cleanupSnapshotter(ctx, snapshotterName)
 
 				sl.Lock()
 				stats.SnapshotD[snapshotterName] = time.Since(st1)
 				sl.Unlock()
-
-				wg.Done()
 			}(snapshotterName)
 		}
 		m.dirtySS = map[string]struct{}{}
@@ -537,6 +622,13 @@ func (m *DB) getMarked(ctx context.Context, c *gcContext) (map[gc.Node]struct{},
 
 func (m *DB) cleanupSnapshotter(ctx context.Context, name string) (time.Duration, error) {
 	ctx = context.WithoutCancel(ctx)
+	if m.dbopts.cleanupTimeout > 0 {
+		// cleanup IO runs outside the metadata lock, the deadline keeps a
+		// hung snapshotter from stalling the garbage collector forever
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.dbopts.cleanupTimeout)
+		defer cancel()
+	}
 	sn, ok := m.ss[name]
 	if !ok {
 		return 0, nil
@@ -554,6 +646,11 @@ func (m *DB) cleanupSnapshotter(ctx context.Context, name string) (time.Duration
 
 func (m *DB) cleanupContent(ctx context.Context) (time.Duration, error) {
 	ctx = context.WithoutCancel(ctx)
+	if m.dbopts.cleanupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.dbopts.cleanupTimeout)
+		defer cancel()
+	}
 	if m.cs == nil {
 		return 0, nil
 	}
@@ -556,7 +556,7 @@ func TestMetadataCollector(t *testing.T) {
 			actual = append(actual, node)
 			return nil
 		}
-		cc := startGCContext(ctx, mdb.collectors)
+		cc := startGCContext(ctx, mdb.collectors, true, 0)
 		return cc.scanAll(ctx, tx, scanFn)
 	}); err != nil {
 		t.Fatal(err)
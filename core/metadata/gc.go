@@ -94,6 +94,14 @@ var (
 	// Expected format is RFC 3339
 	labelGCExpire = []byte("containerd.io/gc.expire")
 
+	// labelGCLastUsed records when a snapshot was last prepared from,
+	// updated by the metadata store on Prepare. Together with a configured
+	// retention period it keeps recently used layers collectible only
+	// after they have been idle, so alternating pulls do not constantly
+	// re-unpack the same layers.
+	// Expected format is RFC 3339
+	labelGCLastUsed = []byte("containerd.io/gc.last-used")
+
 	// labelGCFlat indicates that a lease is flat and only intends to
 	// lease the referenced objects, not their references. This can be
 	// used to avoid leasing an entire tree of objects when only the root
@@ -207,6 +215,18 @@ type gcContext struct {
 	// repeated type assertion when called by references() after the
 	// built-in core type switch.
 	refContexts map[gc.ResourceType]collectionWithReferences
+
+	// enforceExpiration controls whether the expiration label on content
+	// and snapshots is honored during root scanning.
+	enforceExpiration bool
+
+	// snapshotRetention keeps unreferenced snapshots as roots until they
+	// have been idle for the given duration, zero disables retention.
+	snapshotRetention time.Duration
+
+	// expired records content and snapshot nodes whose expiration has
+	// passed, so their collection can be reported for auditing.
+	expired map[gc.Node]struct{}
 }
 
 type referenceLabelHandler struct {
@@ -220,7 +240,7 @@ type referenceLabelHandler struct {
 	conditionalV func(string, []byte, []byte, func(conditionalValue))
 }
 
-func startGCContext(ctx context.Context, collectors map[gc.ResourceType]Collector) *gcContext {
+func startGCContext(ctx context.Context, collectors map[gc.ResourceType]Collector, enforceExpiration bool, snapshotRetention time.Duration) *gcContext {
 	var contexts map[gc.ResourceType]CollectionContext
 	labelHandlers := []referenceLabelHandler{
 		{
@@ -439,10 +459,13 @@ func startGCContext(ctx context.Context, collectors map[gc.ResourceType]Collecto
 		})
 	}
 	return &gcContext{
-		labelHandlers: labelHandlers,
-		contexts:      contexts,
-		backRefs:      make(map[gc.Node][]gc.Node),
-		refContexts:   refContexts,
+		labelHandlers:     labelHandlers,
+		contexts:          contexts,
+		backRefs:          make(map[gc.Node][]gc.Node),
+		refContexts:       refContexts,
+		enforceExpiration: enforceExpiration,
+		snapshotRetention: snapshotRetention,
+		expired:           make(map[gc.Node]struct{}),
 	}
 }
 
@@ -729,15 +752,29 @@ func (c *gcContext) scanRoots(ctx context.Context, tx *bolt.Tx, nc chan<- gc.Nod
 						return nil
 					}
 
+					node := gcnode(ResourceContent, ns, string(k))
+					expSet, expired := c.expiration(ctx, k, cbkt.Bucket(k), expThreshold)
+					if expSet {
+						if expired {
+							c.expired[node] = struct{}{}
+						} else {
+							// Objects with an expiration are treated as
+							// root objects until their expiration passes.
+							fn(node)
+						}
+					}
+
 					return c.sendLabelRefs(ns, cbkt.Bucket(k), labelRefCallbacks{
 						bref: func(n gc.Node) {
-							bref(n, gcnode(ResourceContent, ns, string(k)))
+							bref(n, node)
 						},
 						root: func() {
-							fn(gcnode(ResourceContent, ns, string(k)))
+							if !expired {
+								fn(node)
+							}
 						},
 						cond: func(n gc.Node, cv func(conditionalValue) bool) {
-							addCond(gcnode(ResourceContent, ns, string(k)), n, cv)
+							addCond(node, n, cv)
 						},
 					})
 				}); err != nil {
@@ -778,15 +815,34 @@ func (c *gcContext) scanRoots(ctx context.Context, tx *bolt.Tx, nc chan<- gc.Nod
 						return nil
 					}
 
+					node := gcnode(ResourceSnapshot, ns, fmt.Sprintf("%s/%s", sk, k))
+					expSet, expired := c.expiration(ctx, k, snbkt.Bucket(k), expThreshold)
+					if expSet {
+						if expired {
+							c.expired[node] = struct{}{}
+						} else {
+							// Objects with an expiration are treated as
+							// root objects until their expiration passes.
+							fn(node)
+						}
+					}
+					if c.retained(ctx, snbkt.Bucket(k), expThreshold) {
+						// Recently used snapshots stay roots for the
+						// retention period even when unreferenced.
+						fn(node)
+					}
+
 					return c.sendLabelRefs(ns, snbkt.Bucket(k), labelRefCallbacks{
 						bref: func(n gc.Node) {
-							bref(n, gcnode(ResourceSnapshot, ns, fmt.Sprintf("%s/%s", sk, k)))
+							bref(n, node)
 						},
 						root: func() {
-							fn(gcnode(ResourceSnapshot, ns, fmt.Sprintf("%s/%s", sk, k)))
+							if !expired {
+								fn(node)
+							}
 						},
 						condVal: func(v conditionalValue) {
-							addCondVal(gcnode(ResourceSnapshot, ns, fmt.Sprintf("%s/%s", sk, k)), v)
+							addCondVal(node, v)
 						},
 					})
 				})
@@ -1133,6 +1189,51 @@ func (c *gcContext) sendLabelRefs(ns string, bkt *bolt.Bucket, cb labelRefCallba
 	return nil
 }
 
+// retained reports whether the snapshot was built upon recently enough for
+// the retention policy to keep it around.
+func (c *gcContext) retained(ctx context.Context, bkt *bolt.Bucket, expThreshold time.Time) bool {
+	if c.snapshotRetention <= 0 {
+		return false
+	}
+	lbkt := bkt.Bucket(bucketKeyObjectLabels)
+	if lbkt == nil {
+		return false
+	}
+	lu := lbkt.Get(labelGCLastUsed)
+	if lu == nil {
+		return false
+	}
+	lastUsed, err := time.Parse(time.RFC3339, string(lu))
+	if err != nil {
+		log.G(ctx).WithError(err).Infof("ignoring invalid last-used value %q", string(lu))
+		return false
+	}
+	return expThreshold.Before(lastUsed.Add(c.snapshotRetention))
+}
+
+// expiration reads the expiration label of an object, reporting whether the
+// label is set and whether it has passed. It reports the label as unset when
+// enforcement is disabled.
+func (c *gcContext) expiration(ctx context.Context, k []byte, bkt *bolt.Bucket, expThreshold time.Time) (set, expired bool) {
+	if !c.enforceExpiration {
+		return false, false
+	}
+	lbkt := bkt.Bucket(bucketKeyObjectLabels)
+	if lbkt == nil {
+		return false, false
+	}
+	el := lbkt.Get(labelGCExpire)
+	if el == nil {
+		return false, false
+	}
+	exp, err := time.Parse(time.RFC3339, string(el))
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("key", string(k)).Infof("ignoring invalid expiration value %q", string(el))
+		return false, false
+	}
+	return true, expThreshold.After(exp)
+}
+
 func isExpiredImage(ctx context.Context, k []byte, bkt *bolt.Bucket, expTheshold time.Time) bool {
 	lbkt := bkt.Bucket(bucketKeyObjectLabels)
 	if lbkt != nil {
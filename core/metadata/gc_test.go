@@ -77,6 +77,10 @@ func TestGCRoots(t *testing.T) {
 		addContent("ns2", dgst(2), labelmap(string(labelGCRoot), "always")),
 		addContent("ns2", dgst(8), nil),
 		addContent("ns2", dgst(9), nil),
+		addContent("ns2", dgst(20), labelmap(string(labelGCExpire), time.Now().Add(time.Hour).Format(time.RFC3339))),
+		addContent("ns2", dgst(21), labelmap(
+			string(labelGCExpire), time.Now().Format(time.RFC3339),
+			string(labelGCRoot), "always")), // expired, no longer treated as root
 		addIngest("ns1", "ingest-1", "", nil),       // will be seen as expired
 		addIngest("ns1", "ingest-2", "", timeIn(0)), // expired
 		addIngest("ns1", "ingest-3", "", timeIn(time.Hour)),
@@ -93,6 +97,10 @@ func TestGCRoots(t *testing.T) {
 		addSnapshot("ns1", "overlay", "sn7", "", nil),
 		addSnapshot("ns1", "overlay", "sn8", "", nil),
 		addSnapshot("ns1", "overlay", "sn9", "", nil),
+		addSnapshot("ns1", "overlay", "sn20", "", labelmap(string(labelGCExpire), time.Now().Add(time.Hour).Format(time.RFC3339))),
+		addSnapshot("ns1", "overlay", "sn21", "", labelmap(
+			string(labelGCExpire), time.Now().Format(time.RFC3339),
+			string(labelGCRoot), "always")), // expired, no longer treated as root
 		addLeaseSnapshot("ns2", "l1", "overlay", "sn5"),
 		addLeaseSnapshot("ns2", "l2", "overlay", "sn6"),
 		addLeaseContent("ns2", "l1", dgst(4)),
@@ -122,6 +130,8 @@ func TestGCRoots(t *testing.T) {
 
 	expected := []gc.Node{
 		gcnode(ResourceContent, "ns2", dgst(2).String()),
+		gcnode(ResourceContent, "ns2", dgst(20).String()),
+		gcnode(ResourceSnapshot, "ns1", "overlay/sn20"),
 		gcnode(ResourceContent, "ns2", dgst(4).String()),
 		gcnode(ResourceContent, "ns2", dgst(5).String()),
 		gcnode(ResourceContent, "ns2", dgst(6).String()),
@@ -169,7 +179,46 @@ func TestGCRoots(t *testing.T) {
 	ctx := context.Background()
 
 	checkNodeC(ctx, t, db, expected, func(ctx context.Context, tx *bolt.Tx, nc chan<- gc.Node) error {
-		return startGCContext(ctx, nil).scanRoots(ctx, tx, nc)
+		return startGCContext(ctx, nil, true, 0).scanRoots(ctx, tx, nc)
+	})
+}
+
+func TestGCRootsWithRetention(t *testing.T) {
+	db, err := newDatabase(t)
+	require.NoError(t, err)
+
+	alters := []alterFunc{
+		addSnapshot("ns1", "overlay", "recent", "", labelmap(string(labelGCLastUsed), time.Now().Format(time.RFC3339))),
+		addSnapshot("ns1", "overlay", "stale", "", labelmap(string(labelGCLastUsed), time.Now().Add(-2*time.Hour).Format(time.RFC3339))),
+		addSnapshot("ns1", "overlay", "untracked", "", nil),
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		v1bkt, err := tx.CreateBucketIfNotExists(bucketKeyVersion)
+		if err != nil {
+			return err
+		}
+		for _, alter := range alters {
+			if err := alter(v1bkt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Update failed: %+v", err)
+	}
+
+	ctx := context.Background()
+
+	// With retention only the recently used snapshot stays a root.
+	checkNodeC(ctx, t, db, []gc.Node{
+		gcnode(ResourceSnapshot, "ns1", "overlay/recent"),
+	}, func(ctx context.Context, tx *bolt.Tx, nc chan<- gc.Node) error {
+		return startGCContext(ctx, nil, true, time.Hour).scanRoots(ctx, tx, nc)
+	})
+
+	// Without retention none of them are roots.
+	checkNodeC(ctx, t, db, nil, func(ctx context.Context, tx *bolt.Tx, nc chan<- gc.Node) error {
+		return startGCContext(ctx, nil, true, 0).scanRoots(ctx, tx, nc)
 	})
 }
 
@@ -241,7 +290,7 @@ func TestGCRemove(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	c := startGCContext(ctx, nil)
+	c := startGCContext(ctx, nil, true, 0)
 
 	checkNodes(ctx, t, db, all, func(ctx context.Context, tx *bolt.Tx, fn func(context.Context, gc.Node) error) error {
 		return c.scanAll(ctx, tx, fn)
@@ -474,7 +523,7 @@ func TestGCRefs(t *testing.T) {
 	}
 
 	ctx := logtest.WithT(context.Background(), t)
-	c := startGCContext(ctx, nil)
+	c := startGCContext(ctx, nil, true, 0)
 	if err := db.View(func(tx *bolt.Tx) error {
 		rctx, rcancel := context.WithCancel(ctx)
 		// Use canceled context to avoid blocking on nil chan
@@ -598,7 +647,7 @@ func TestCollectibleResources(t *testing.T) {
 	ctx := context.Background()
 	c := startGCContext(ctx, map[gc.ResourceType]Collector{
 		testResource: collector,
-	})
+	}, true, 0)
 
 	checkNodeC(ctx, t, db, roots, func(ctx context.Context, tx *bolt.Tx, nc chan<- gc.Node) error {
 		return c.scanRoots(ctx, tx, nc)
@@ -680,7 +729,7 @@ func TestCollectionWithReferences(t *testing.T) {
 	ctx := context.Background()
 	c := startGCContext(ctx, map[gc.ResourceType]Collector{
 		testResource: collector,
-	})
+	}, true, 0)
 
 	// The external resource type should emit forward references via collectionWithReferences.
 	checkNodeC(ctx, t, db, []gc.Node{refNode}, func(ctx context.Context, tx *bolt.Tx, nc chan<- gc.Node) error {
@@ -713,7 +762,7 @@ func TestCollectionWithReferences(t *testing.T) {
 	}
 	c2 := startGCContext(ctx, map[gc.ResourceType]Collector{
 		testResource: unknownCollector,
-	})
+	}, true, 0)
 	checkNodeC(ctx, t, db, nil, func(ctx context.Context, tx *bolt.Tx, nc chan<- gc.Node) error {
 		return c2.references(ctx, tx, unknownNode, func(n gc.Node) {
 			select {
@@ -189,19 +189,23 @@ func (s *snapshotter) Update(ctx context.Context, info snapshots.Info, fieldpath
 					}
 
 					key := strings.TrimPrefix(path, "labels.")
-					local.Labels[key] = info.Labels[key]
+					if v, ok := info.Labels[key]; ok && v != "" {
+						local.Labels[key] = v
+					} else {
+						delete(local.Labels, key)
+					}
 					continue
 				}
 
 				switch path {
 				case "labels":
-					local.Labels = info.Labels
+					local.Labels = snapshots.MergeLabels(local.Labels, info.Labels)
 				default:
 					return fmt.Errorf("cannot update %q field on snapshot %q: %w", path, info.Name, errdefs.ErrInvalidArgument)
 				}
 			}
 		} else {
-			local.Labels = info.Labels
+			local.Labels = snapshots.MergeLabels(local.Labels, info.Labels)
 		}
 		if err := validateSnapshot(&local); err != nil {
 			return err
@@ -355,6 +359,15 @@ func (s *snapshotter) createSnapshot(ctx context.Context, key, parent string, re
 				return fmt.Errorf("parent snapshot %v does not exist: %w", parent, errdefs.ErrNotFound)
 			}
 			bparent = string(pbkt.Get(bucketKeyName))
+
+			// Track when the parent was last built upon so the garbage
+			// collector's retention policy can keep recently used layers
+			// around after they become unreferenced.
+			if lbkt, err := pbkt.CreateBucketIfNotExists(bucketKeyObjectLabels); err == nil {
+				if err := lbkt.Put(labelGCLastUsed, []byte(time.Now().UTC().Format(time.RFC3339))); err != nil {
+					return err
+				}
+			}
 		}
 
 		sid, err := bkt.NextSequence()
@@ -540,6 +553,7 @@ func (s *snapshotter) Commit(ctx context.Context, name, key string, opts ...snap
 	var (
 		bname   string
 		bparent string
+		pname   string
 		rebase  bool
 		rerr    error
 	)
@@ -610,6 +624,7 @@ func (s *snapshotter) Commit(ctx context.Context, name, key string, opts ...snap
 			}
 
 			bparent = string(pbkt.Get(bucketKeyName))
+			pname = string(parent)
 		}
 		ts := time.Now().UTC()
 		if err := boltutil.WriteTimestamps(bbkt, ts, ts); err != nil {
@@ -673,6 +688,15 @@ func (s *snapshotter) Commit(ctx context.Context, name, key string, opts ...snap
 			}); err != nil {
 				return err
 			}
+			// additive typed event carrying the parent key, which the
+			// protobuf event cannot
+			if err := publisher.Publish(ctx, snapshots.SnapshotCommittedTopic, &snapshots.SnapshotCommitted{
+				Snapshotter: s.name,
+				Key:         name,
+				Parent:      pname,
+			}); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -756,10 +780,14 @@ func (s *snapshotter) Walk(ctx context.Context, fn snapshots.WalkFunc, fs ...str
 		return err
 	}
 
-	var (
+	batchSize := snapshots.WalkPageSize(ctx)
+	if batchSize <= 0 {
 		batchSize = 100
-		pairs     = []infoPair{}
-		lastKey   string
+	}
+
+	var (
+		pairs   = []infoPair{}
+		lastKey string
 	)
 
 	filter, err := filters.ParseAll(fs...)
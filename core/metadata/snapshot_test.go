@@ -457,3 +457,69 @@ func (s *tmpSnapshotter) Walk(ctx context.Context, fn snapshots.WalkFunc, fs ...
 func (s *tmpSnapshotter) Close() error {
 	return nil
 }
+
+func TestSnapshotLabelUpdates(t *testing.T) {
+	ctx, db := testDB(t)
+	sn := db.Snapshotter("native")
+
+	if _, err := sn.Prepare(ctx, "labeled", "", snapshots.WithLabels(map[string]string{
+		"containerd.io/gc.root": "keep",
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent fine-grained updates to different keys must not clobber
+	// each other.
+	var wg sync.WaitGroup
+	for _, key := range []string{"alpha", "beta"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := snapshots.UpdateLabel(ctx, sn, "labeled", key, fmt.Sprintf("%s-%d", key, i)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	info, err := sn.Stat(ctx, "labeled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Labels["alpha"] != "alpha-49" || info.Labels["beta"] != "beta-49" {
+		t.Fatalf("concurrent label updates clobbered each other: %v", info.Labels)
+	}
+
+	// A replace-all keeps reserved gc labels it does not address.
+	if _, err := sn.Update(ctx, snapshots.Info{
+		Name:   "labeled",
+		Labels: map[string]string{"only": "this"},
+	}, "labels"); err != nil {
+		t.Fatal(err)
+	}
+	info, err = sn.Stat(ctx, "labeled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Labels["containerd.io/gc.root"] != "keep" {
+		t.Fatalf("replace-all dropped the gc label: %v", info.Labels)
+	}
+	if _, ok := info.Labels["alpha"]; ok {
+		t.Fatalf("replace-all should drop unreserved labels: %v", info.Labels)
+	}
+
+	// An empty value removes the label.
+	if _, err := snapshots.UpdateLabel(ctx, sn, "labeled", "only", ""); err != nil {
+		t.Fatal(err)
+	}
+	info, err = sn.Stat(ctx, "labeled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := info.Labels["only"]; ok {
+		t.Fatalf("empty value should remove the label: %v", info.Labels)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package mount
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultHelperTimeout bounds how long a mount helper binary may run
+// before it is killed; a hung fuse helper must not stall task start
+// forever.
+const defaultHelperTimeout = 30 * time.Second
+
+var (
+	helperTimeoutsMu sync.RWMutex
+	helperTimeouts   = map[string]time.Duration{}
+)
+
+// SetHelperTimeout overrides the timeout applied to the mount helper for
+// the given mount type (e.g. "fuse3.fuse-overlayfs" or "fuse-overlayfs").
+// A zero or negative duration restores the default.
+func SetHelperTimeout(mountType string, d time.Duration) {
+	helperTimeoutsMu.Lock()
+	defer helperTimeoutsMu.Unlock()
+	if d <= 0 {
+		delete(helperTimeouts, mountType)
+		return
+	}
+	helperTimeouts[mountType] = d
+}
+
+// helperTimeout returns the timeout for the given mount type, falling
+// back to the package default.
+func helperTimeout(mountType string) time.Duration {
+	helperTimeoutsMu.RLock()
+	defer helperTimeoutsMu.RUnlock()
+	if d, ok := helperTimeouts[mountType]; ok {
+		return d
+	}
+	return defaultHelperTimeout
+}
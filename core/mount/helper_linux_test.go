@@ -0,0 +1,79 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package mount
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeHelper(t *testing.T, script string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "mount.helper")
+	if err := os.WriteFile(p, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestMountHelperTimeout(t *testing.T) {
+	helper := writeHelper(t, "sleep 10")
+	SetHelperTimeout("fuse3.hang", 500*time.Millisecond)
+	defer SetHelperTimeout("fuse3.hang", 0)
+
+	m := Mount{Type: "fuse3.hang", Source: "none"}
+	start := time.Now()
+	err := m.mountWithHelper(helper, "fuse3.", t.TempDir())
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("helper was not killed at the timeout: %v", elapsed)
+	}
+}
+
+func TestMountHelperLiveness(t *testing.T) {
+	// the helper exits zero without mounting anything; success must not
+	// be reported
+	helper := writeHelper(t, "exit 0")
+	m := Mount{Type: "fuse3.noop", Source: "none"}
+	err := m.mountWithHelper(helper, "fuse3.", t.TempDir())
+	if err == nil {
+		t.Fatal("expected a liveness error")
+	}
+	if !strings.Contains(err.Error(), "never appeared in mountinfo") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMountHelperOutputInError(t *testing.T) {
+	helper := writeHelper(t, "echo transport endpoint is not connected >&2; exit 1")
+	m := Mount{Type: "fuse3.broken", Source: "none"}
+	err := m.mountWithHelper(helper, "fuse3.", t.TempDir())
+	if err == nil {
+		t.Fatal("expected a failure")
+	}
+	if !strings.Contains(err.Error(), "transport endpoint is not connected") {
+		t.Fatalf("helper output missing from error: %v", err)
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -45,9 +47,17 @@ type LoopParams struct {
 	Autoclear bool
 	// Use direct IO to access the loop backing file
 	Direct bool
+	// Exclusive opens the loop device with O_EXCL while configuring it,
+	// so a concurrent losetup racing for the same free device fails fast
+	// with EBUSY instead of stealing the attach. The exclusive claim is
+	// dropped once the device is configured so it can still be mounted.
+	Exclusive bool
 	// TODO: Support block size, offset, sizelimit
 }
 
+// LoopOpts controls AttachLoopDevice.
+type LoopOpts = LoopParams
+
 func getFreeLoopDev() (uint32, error) {
 	ctrl, err := os.OpenFile(loopControlPath, os.O_RDWR, 0)
 	if err != nil {
@@ -77,7 +87,11 @@ func setupLoopDev(backingFile, loopDev string, param LoopParams) (_ *os.File, re
 	}
 	defer back.Close()
 
-	loop, err := os.OpenFile(loopDev, flags, 0)
+	loopFlags := flags
+	if param.Exclusive {
+		loopFlags |= os.O_EXCL
+	}
+	loop, err := os.OpenFile(loopDev, loopFlags, 0)
 	if err != nil {
 		return nil, fmt.Errorf("could not open loop device: %s: %w", loopDev, err)
 	}
@@ -110,7 +124,7 @@ func setupLoopDev(backingFile, loopDev string, param LoopParams) (_ *os.File, re
 			return nil, fmt.Errorf("failed to configure loop device: %s: %w", loopDev, err)
 		}
 
-		return loop, nil
+		return demoteExclusive(loop, loopDev, flags, param.Exclusive)
 	}
 
 	// 2. Set FD
@@ -148,7 +162,25 @@ func setupLoopDev(backingFile, loopDev string, param LoopParams) (_ *os.File, re
 		}
 	}
 
-	return loop, nil
+	return demoteExclusive(loop, loopDev, flags, param.Exclusive)
+}
+
+// demoteExclusive swaps an O_EXCL configure handle for a plain one so the
+// attached device can still be claimed by mount; the plain handle is
+// opened before the exclusive one is closed so an autoclear device is
+// never left without an opener.
+func demoteExclusive(loop *os.File, loopDev string, flags int, exclusive bool) (*os.File, error) {
+	if !exclusive {
+		return loop, nil
+	}
+	keeper, err := os.OpenFile(loopDev, flags, 0)
+	if err != nil {
+		_ = unix.IoctlSetInt(int(loop.Fd()), unix.LOOP_CLR_FD, 0)
+		loop.Close()
+		return nil, fmt.Errorf("could not reopen loop device: %s: %w", loopDev, err)
+	}
+	loop.Close()
+	return keeper, nil
 }
 
 // SetupLoop looks for (and possibly creates) a free loop device, and
@@ -235,13 +267,62 @@ func AttachLoopDevice(backingFile string) (string, error) {
 	return file.Name(), nil
 }
 
-// DetachLoopDevice detaches the provided loop devices
+// AttachLoopDeviceExclusive attaches backingFile to a free loop device,
+// holding an exclusive claim while configuring so concurrent attaches
+// cannot race on the same device. It returns the device path and a
+// cleanup detaching the device; cleanup tolerates a device that was
+// already detached, e.g. by LO_FLAGS_AUTOCLEAR on last close.
+func AttachLoopDeviceExclusive(backingFile string, opts LoopOpts) (string, func() error, error) {
+	opts.Exclusive = true
+	file, err := SetupLoop(backingFile, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	device := file.Name()
+	cleanup := func() error {
+		file.Close()
+		return DetachLoopDevice(device)
+	}
+	return device, cleanup, nil
+}
+
+// DetachLoopDevice detaches the provided loop devices. Devices that are
+// already detached, or gone entirely, are skipped.
 func DetachLoopDevice(devices ...string) error {
 	for _, dev := range devices {
 		if err := removeLoop(dev); err != nil {
+			if errors.Is(err, unix.ENXIO) || errors.Is(err, os.ErrNotExist) {
+				continue
+			}
 			return fmt.Errorf("failed to remove loop device: %s: %w", dev, err)
 		}
 	}
 
 	return nil
 }
+
+// LeakedLoopDevices lists attached loop devices whose backing file lives
+// under dir, to find devices leaked when a mount failed between attach
+// and cleanup.
+func LeakedLoopDevices(dir string) ([]string, error) {
+	backingFiles, err := filepath.Glob("/sys/block/loop*/loop/backing_file")
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Clean(dir) + string(os.PathSeparator)
+	var devices []string
+	for _, p := range backingFiles {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			// device detached while walking
+			continue
+		}
+		backing := strings.TrimSuffix(strings.TrimSpace(string(b)), " (deleted)")
+		if strings.HasPrefix(backing, prefix) {
+			// /sys/block/loopN/loop/backing_file -> /dev/loopN
+			devices = append(devices, "/dev/"+filepath.Base(filepath.Dir(filepath.Dir(p))))
+		}
+	}
+	sort.Strings(devices)
+	return devices, nil
+}
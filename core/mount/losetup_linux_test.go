@@ -101,6 +101,44 @@ func TestAttachDetachLoopDevice(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAttachLoopDeviceExclusive(t *testing.T) {
+	testutil.RequiresRoot(t)
+
+	path := createTempFile(t)
+
+	dev, cleanup, err := AttachLoopDeviceExclusive(path, LoopOpts{})
+	require.NoError(t, err)
+
+	err = DetachLoopDevice(dev)
+	require.NoError(t, err)
+
+	// cleanup after an explicit detach must not fail
+	require.NoError(t, cleanup())
+}
+
+func TestLeakedLoopDevices(t *testing.T) {
+	testutil.RequiresRoot(t)
+
+	path := createTempFile(t)
+
+	dev, cleanup, err := AttachLoopDeviceExclusive(path, LoopOpts{})
+	require.NoError(t, err)
+	defer cleanup()
+
+	leaked, err := LeakedLoopDevices(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Contains(t, leaked, dev)
+
+	leaked, err = LeakedLoopDevices(t.TempDir())
+	require.NoError(t, err)
+	require.NotContains(t, leaked, dev)
+
+	require.NoError(t, cleanup())
+	leaked, err = LeakedLoopDevices(filepath.Dir(path))
+	require.NoError(t, err)
+	require.NotContains(t, leaked, dev)
+}
+
 func TestAutoclearTrueLoop(t *testing.T) {
 	testutil.RequiresRoot(t)
 
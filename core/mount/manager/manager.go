@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strconv"
 	"strings"
@@ -32,6 +33,8 @@ import (
 
 	bolt "go.etcd.io/bbolt"
 
+	"github.com/moby/sys/mountinfo"
+
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 
@@ -803,6 +806,134 @@ func (mm *mountManager) Deactivate(ctx context.Context, name string) error {
 	return nil
 }
 
+// Sync reconciles the recorded mount state with the system after an
+// unclean shutdown. Records whose filesystem mounts have disappeared are
+// dropped so they no longer pin snapshots, and target directories with no
+// owning record are unmounted and removed. Only paths under the manager's
+// own target root are ever touched.
+func (mm *mountManager) Sync(ctx context.Context) error {
+	tx, ok := boltutil.Transaction(ctx)
+	if !ok {
+		return mm.db.Update(func(tx *bolt.Tx) error {
+			return mm.sync(ctx, tx)
+		})
+	}
+	return mm.sync(ctx, tx)
+}
+
+func (mm *mountManager) sync(ctx context.Context, tx *bolt.Tx) error {
+	remaining := map[uint64]struct{}{}
+	if v1bkt := tx.Bucket([]byte("v1")); v1bkt != nil {
+		nsc := v1bkt.Cursor()
+		for nsk, nsv := nsc.First(); nsk != nil; nsk, nsv = nsc.Next() {
+			if nsv != nil {
+				continue
+			}
+			nsbkt := v1bkt.Bucket(nsk)
+			msbkt := nsbkt.Bucket(bucketKeyMounts)
+			if msbkt == nil {
+				continue
+			}
+			var stale [][]byte
+			msc := msbkt.Cursor()
+			for msk, msv := msc.First(); msk != nil; msk, msv = msc.Next() {
+				if msv != nil {
+					continue
+				}
+				if mm.recordStale(msbkt.Bucket(msk)) {
+					stale = append(stale, slices.Clone(msk))
+				} else {
+					remaining[readID(msbkt.Bucket(msk))] = struct{}{}
+				}
+			}
+			lsbkt := nsbkt.Bucket(bucketKeyLeases)
+			for _, msk := range stale {
+				log.G(ctx).WithFields(log.Fields{
+					"namespace": string(nsk),
+					"name":      string(msk),
+				}).Warn("removing stale mount record")
+				if lid := msbkt.Bucket(msk).Get(bucketKeyLease); len(lid) > 0 && lsbkt != nil {
+					if lbkt := lsbkt.Bucket(lid); lbkt != nil {
+						if err := lbkt.Delete(msk); err != nil {
+							return err
+						}
+						if k, _ := lbkt.Cursor().First(); k == nil {
+							if err := lsbkt.DeleteBucket(lid); err != nil {
+								return err
+							}
+						}
+					}
+				}
+				if err := msbkt.DeleteBucket(msk); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// unmount and remove target directories with no owning record
+	fd, err := mm.targets.Open(".")
+	if err != nil {
+		return err
+	}
+	dirs, err := fd.Readdirnames(0)
+	fd.Close()
+	if err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		id, err := strconv.ParseUint(d, 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, ok := remaining[id]; ok {
+			continue
+		}
+		dir := filepath.Join(mm.targets.Name(), d)
+		if err := unmountAll(ctx, dir, mm.handlers); err != nil {
+			log.G(ctx).WithError(err).WithField("dir", dir).Error("failed to unmount leaked mount target")
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.G(ctx).WithError(err).WithField("dir", dir).Error("failed to remove leaked mount target")
+		}
+	}
+	return nil
+}
+
+// recordStale reports whether any of the activation's filesystem mounts
+// under the target root have disappeared from the system. Handler managed
+// mounts are opaque here and left to their handler.
+func (mm *mountManager) recordStale(bkt *bolt.Bucket) bool {
+	if runtime.GOOS == "windows" {
+		// mountinfo cannot answer here, never treat records as stale
+		return false
+	}
+	abkt := bkt.Bucket(bucketKeyActive)
+	if abkt == nil {
+		return false
+	}
+	prefix := mm.targets.Name() + string(os.PathSeparator)
+	var stale bool
+	abkt.ForEachBucket(func(k []byte) error {
+		active, err := readActiveMount(abkt.Bucket(k))
+		if err != nil {
+			return nil
+		}
+		if _, ok := mm.handlers[active.Type]; ok {
+			return nil
+		}
+		if active.MountPoint == "" || !strings.HasPrefix(active.MountPoint, prefix) {
+			return nil
+		}
+		if mounted, err := mountinfo.Mounted(active.MountPoint); err == nil && !mounted {
+			stale = true
+		}
+		return nil
+	})
+	return stale
+}
+
 func (mm *mountManager) Info(ctx context.Context, name string) (mount.ActivationInfo, error) {
 	namespace, err := namespaces.NamespaceRequired(ctx)
 	if err != nil {
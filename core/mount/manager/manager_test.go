@@ -601,7 +601,73 @@ func TestActivateConcurrentSameName(t *testing.T) {
 }
 
 // TODO: Test deactivate
-// TODO: Test Sync
+
+func TestSync(t *testing.T) {
+	td := t.TempDir()
+	metadb := filepath.Join(td, "mounts.db")
+	targetdir := filepath.Join(td, "m")
+	db, err := bolt.Open(metadb, 0600, nil)
+	require.NoError(t, err)
+	ctx := namespaces.WithNamespace(context.Background(), "test")
+
+	mountC := new(atomic.Int32)
+	m, err := NewManager(db, targetdir, WithMountHandler("noop", &noopHandler{mounts: mountC}))
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, m.(io.Closer).Close()) })
+
+	// a healthy handler-managed activation survives the sync
+	_, err = m.Activate(ctx, "keep", []mount.Mount{{Type: "noop"}})
+	require.NoError(t, err)
+
+	// a record whose filesystem mount disappeared with an unclean
+	// shutdown
+	staleMP := filepath.Join(targetdir, "2", "rootfs")
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.Bucket([]byte("v1")).Bucket([]byte("test")).Bucket(bucketKeyMounts).CreateBucket([]byte("stale"))
+		if err != nil {
+			return err
+		}
+		idb, err := encodeID(2)
+		if err != nil {
+			return err
+		}
+		if err := bkt.Put(bucketKeyID, idb); err != nil {
+			return err
+		}
+		abkt, err := bkt.CreateBucket(bucketKeyActive)
+		if err != nil {
+			return err
+		}
+		obkt, err := abkt.CreateBucket([]byte("0"))
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		return putActiveMount(obkt, mount.ActiveMount{
+			Mount:      mount.Mount{Type: "bind"},
+			MountPoint: staleMP,
+			MountedAt:  &now,
+		})
+	}))
+	require.NoError(t, os.MkdirAll(staleMP, 0700))
+
+	// a leaked target directory with no record at all
+	require.NoError(t, os.MkdirAll(filepath.Join(targetdir, "99", "rootfs"), 0700))
+
+	require.NoError(t, m.(BoltManager).Sync(ctx))
+
+	_, err = m.Info(ctx, "keep")
+	require.NoError(t, err)
+
+	_, err = m.Info(ctx, "stale")
+	require.True(t, errdefs.IsNotFound(err), "stale record not removed: %v", err)
+
+	for _, d := range []string{"2", "99"} {
+		if _, err := os.Stat(filepath.Join(targetdir, d)); !os.IsNotExist(err) {
+			t.Fatalf("leaked target directory %q not cleaned up: %v", d, err)
+		}
+	}
+}
 
 func TestClose(t *testing.T) {
 	td := t.TempDir()
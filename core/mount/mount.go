@@ -22,11 +22,52 @@ import (
 	"runtime"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/containerd/containerd/api/types"
 	"github.com/containerd/continuity/fs"
 )
 
+// UnmountOpt configures how UnmountRecursive deals with busy mounts.
+type UnmountOpt func(*unmountOptions)
+
+type unmountOptions struct {
+	retries    int
+	backoff    time.Duration
+	lazyAfter  int
+	reportBusy bool
+}
+
+// WithUnmountRetries retries a busy unmount up to n more times, sleeping
+// backoff between attempts.
+func WithUnmountRetries(n int, backoff time.Duration) UnmountOpt {
+	return func(o *unmountOptions) {
+		o.retries = n
+		o.backoff = backoff
+	}
+}
+
+// WithLazyFallback escalates to a lazy unmount (MNT_DETACH) after the
+// given number of failed attempts, so the mount at least disappears from
+// the namespace while the kernel keeps it alive for its users.
+func WithLazyFallback(afterAttempts int) UnmountOpt {
+	return func(o *unmountOptions) {
+		if afterAttempts < 1 {
+			afterAttempts = 1
+		}
+		o.lazyAfter = afterAttempts
+	}
+}
+
+// WithBusyReporting scans the process table when the unmount finally
+// fails and names the processes keeping the mount busy in the returned
+// error, turning a bare EBUSY into something actionable.
+func WithBusyReporting() UnmountOpt {
+	return func(o *unmountOptions) {
+		o.reportBusy = true
+	}
+}
+
 // HasBindMounts This is a flag to conditionally disable code that relies on working bind-mount support, so such code is easier to find across codebase.
 const HasBindMounts = runtime.GOOS != "darwin" && runtime.GOOS != "openbsd"
 
@@ -108,9 +149,9 @@ func (m *Mount) Mount(target string) error {
 	return m.mount(target)
 }
 
-// readonlyMounts modifies the received mount options
+// ReadonlyMounts modifies the received mount options
 // to make them readonly
-func readonlyMounts(mounts []Mount) []Mount {
+func ReadonlyMounts(mounts []Mount) []Mount {
 	for i, m := range mounts {
 		if m.Type == "overlay" {
 			mounts[i].Options = readonlyOverlay(m.Options)
@@ -17,6 +17,7 @@
 package mount
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -24,8 +25,46 @@ import (
 	"syscall"
 
 	"golang.org/x/sys/unix"
+
+	kernel "github.com/containerd/containerd/v2/pkg/kernelversion"
+)
+
+var (
+	// ErrIDMapKernelUnsupported is returned when the running kernel
+	// predates mount_setattr with MOUNT_ATTR_IDMAP (5.12).
+	ErrIDMapKernelUnsupported = errors.New("kernel does not support idmapped mounts")
+	// ErrIDMapFilesystemUnsupported is returned when the kernel supports
+	// idmapped mounts but the mounted filesystem does not.
+	ErrIDMapFilesystemUnsupported = errors.New("filesystem does not support idmapped mounts")
 )
 
+// IDMapSupported reports whether the running kernel can create idmapped
+// mounts at all, returning ErrIDMapKernelUnsupported when it cannot.
+// Filesystem support is only known once mount_setattr is attempted.
+func IDMapSupported() error {
+	fiveDotTwelve := kernel.KernelVersion{Kernel: 5, Major: 12}
+	if ok, err := kernel.GreaterEqualThan(fiveDotTwelve); err != nil || !ok {
+		return fmt.Errorf("idmapped mounts require kernel 5.12 or newer: %w", ErrIDMapKernelUnsupported)
+	}
+	return nil
+}
+
+// classifyIDMapErr maps a mount_setattr failure to the sentinel errors so
+// callers can tell a kernel that is too old from a filesystem that cannot
+// be idmapped.
+func classifyIDMapErr(err error) error {
+	if errors.Is(err, unix.ENOSYS) {
+		return fmt.Errorf("%v: %w", err, ErrIDMapKernelUnsupported)
+	}
+	if errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) {
+		if kerr := IDMapSupported(); kerr != nil {
+			return kerr
+		}
+		return fmt.Errorf("%v: %w", err, ErrIDMapFilesystemUnsupported)
+	}
+	return err
+}
+
 func parseIDMapping(mapping string) (syscall.SysProcIDMap, error) {
 	var retval syscall.SysProcIDMap
 
@@ -100,7 +139,7 @@ func IDMapMountWithAttrs(source, target string, usernsFd int, attrSet uint64, at
 
 	defer unix.Close(dFd)
 	if err = unix.MountSetattr(dFd, "", unix.AT_EMPTY_PATH|unix.AT_RECURSIVE, &attr); err != nil {
-		return fmt.Errorf("unable to shift GID/UID or set mount attrs for %s: %w", target, err)
+		return fmt.Errorf("unable to shift GID/UID or set mount attrs for %s: %w", target, classifyIDMapErr(err))
 	}
 
 	if err = unix.MoveMount(dFd, "", -int(unix.EBADF), target, unix.MOVE_MOUNT_F_EMPTY_PATH); err != nil {
@@ -109,6 +148,39 @@ func IDMapMountWithAttrs(source, target string, usernsFd int, attrSet uint64, at
 	return nil
 }
 
+// formatIDMapping is the inverse of parseIDMapping.
+func formatIDMapping(m syscall.SysProcIDMap) string {
+	return fmt.Sprintf("%d:%d:%d", m.ContainerID, m.HostID, m.Size)
+}
+
+func formatIDMappingList(mappings []syscall.SysProcIDMap) string {
+	formatted := make([]string, 0, len(mappings))
+	for _, m := range mappings {
+		formatted = append(formatted, formatIDMapping(m))
+	}
+	return strings.Join(formatted, ",")
+}
+
+// ApplyIDMapping records the uid/gid mappings on the mount's options so
+// the regular mount path applies them through open_tree/mount_setattr
+// (or remapped lowerdirs for overlay) when the mount is made, the shared
+// primitive for snapshotters and spec opts needing idmapped mounts. It
+// fails up front with ErrIDMapKernelUnsupported on kernels without
+// mount_setattr support.
+func ApplyIDMapping(m *Mount, uidmap, gidmap []syscall.SysProcIDMap) error {
+	if err := IDMapSupported(); err != nil {
+		return err
+	}
+	if len(uidmap) == 0 || len(gidmap) == 0 {
+		return errors.New("both uid and gid mappings are required")
+	}
+	m.Options = append(m.Options,
+		fmt.Sprintf("uidmap=%s", formatIDMappingList(uidmap)),
+		fmt.Sprintf("gidmap=%s", formatIDMappingList(gidmap)),
+	)
+	return nil
+}
+
 // GetUsernsFD forks the current process and creates a user namespace using the specified mappings.
 // Expected syntax of ID mapping parameter is "%d:%d:%d[,%d:%d:%d,...]"
 func GetUsernsFD(uidmap, gidmap string) (_usernsFD *os.File, _ error) {
@@ -17,6 +17,7 @@
 package mount
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -89,6 +90,8 @@ func TestIdmappedMount(t *testing.T) {
 	t.Run("IDMapMount", testIDMapMount)
 
 	t.Run("IDMapMountWithAttrs", testIDMapMountWithAttrs)
+
+	t.Run("ApplyIDMapping", testApplyIDMapping)
 }
 
 func testGetUsernsFD(t *testing.T) {
@@ -253,3 +256,40 @@ func initIDMappedChecker(t *testing.T, uidMaps, gidMaps []syscall.SysProcIDMap,
 		}
 	}
 }
+
+func testApplyIDMapping(t *testing.T) {
+	srcDir, checkFunc := initIDMappedChecker(t, testUIDMaps, testGIDMaps, true)
+	destDir := t.TempDir()
+	defer func() {
+		require.NoError(t, UnmountAll(destDir, 0))
+	}()
+
+	m := Mount{
+		Type:    "bind",
+		Source:  srcDir,
+		Options: []string{"rbind"},
+	}
+	require.NoError(t, ApplyIDMapping(&m, testUIDMaps, testGIDMaps))
+	require.NoError(t, m.Mount(destDir))
+	checkFunc(destDir)
+
+	// both halves of the mapping are required
+	require.Error(t, ApplyIDMapping(&Mount{}, testUIDMaps, nil))
+}
+
+func TestClassifyIDMapErr(t *testing.T) {
+	if err := IDMapSupported(); err != nil {
+		t.Skipf("kernel without idmapped mounts: %v", err)
+	}
+	if err := classifyIDMapErr(unix.ENOSYS); !errors.Is(err, ErrIDMapKernelUnsupported) {
+		t.Fatalf("ENOSYS not classified as kernel support: %v", err)
+	}
+	for _, errno := range []error{unix.EINVAL, unix.EOPNOTSUPP} {
+		if err := classifyIDMapErr(errno); !errors.Is(err, ErrIDMapFilesystemUnsupported) {
+			t.Fatalf("%v not classified as filesystem support: %v", errno, err)
+		}
+	}
+	if err := classifyIDMapErr(unix.EPERM); !errors.Is(err, unix.EPERM) {
+		t.Fatalf("unrelated errno rewritten: %v", err)
+	}
+}
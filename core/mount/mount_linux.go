@@ -17,6 +17,7 @@
 package mount
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -25,12 +26,49 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/containerd/log"
 	"github.com/moby/sys/userns"
 	"golang.org/x/sys/unix"
 )
 
+// ErrMountOptionsTooLong is returned by Validate when a mount's options
+// exceed the kernel's one-page argument limit and cannot be reduced
+// automatically; callers can react by flattening snapshots.
+var ErrMountOptionsTooLong = errors.New("mount options exceed the kernel's page size limit")
+
+// ParseMountOptions parses mount options into mount(2) flags and leftover
+// data options, the canonical parser for code inspecting mounts instead of
+// ad-hoc scans.
+func ParseMountOptions(options []string) (flags int, data []string, err error) {
+	opt, err := parseMountOptions(options)
+	if err != nil {
+		return 0, nil, err
+	}
+	return opt.flags, opt.data, nil
+}
+
+// Validate parses the mount's options and checks their combined size
+// against the kernel's one-page limit. Overlay mounts whose lowerdir
+// chains exceed the limit are accepted when the mount path can compact
+// them automatically (see compactLowerdirOption); everything else over
+// the limit fails with ErrMountOptionsTooLong.
+func (m *Mount) Validate() error {
+	if _, err := parseMountOptions(m.Options); err != nil {
+		return err
+	}
+	if optionsSize(m.Options) < pagesize-512 {
+		return nil
+	}
+	if m.Type == "overlay" {
+		if _, compacted := compactLowerdirOption(m.Options); optionsSize(compacted) < pagesize-512 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v: %w", m.Type, ErrMountOptionsTooLong)
+}
+
 type mountOpt struct {
 	flags   int
 	data    []string
@@ -76,8 +114,16 @@ func prepareIDMappedOverlay(usernsFd int, options []string) ([]string, func(), e
 // Mount to the provided target path.
 //
 // If m.Type starts with "fuse." or "fuse3.", "mount.fuse" or "mount.fuse3"
-// helper binary is called.
+// helper binary is called. The plain "fuse-overlayfs" type is an alias for
+// "fuse3.fuse-overlayfs". Helpers run under the per-type timeout (see
+// SetHelperTimeout) and the mount is verified against mountinfo before
+// success is reported.
 func (m *Mount) mount(target string) (err error) {
+	if m.Type == "fuse-overlayfs" {
+		// first-class alias so snapshotters do not need to spell out
+		// which mount.fuse flavor handles fuse-overlayfs
+		return m.mountWithHelper("mount.fuse3", "fuse3.", target)
+	}
 	for _, helperBinary := range allowedHelperBinaries {
 		// helperBinary = "mount.fuse", typePrefix = "fuse."
 		typePrefix := strings.TrimPrefix(helperBinary, "mount.") + "."
@@ -563,15 +609,38 @@ func (m *Mount) mountWithHelper(helperBinary, typePrefix, target string) error {
 		return err
 	}
 
+	timeout := helperTimeout(m.Type)
+
 	// cmd.CombinedOutput() may intermittently return ECHILD because of our signal handling in shim.
 	// See #4387 and wait(2).
 	const retriesOnECHILD = 10
 	for range retriesOnECHILD {
-		cmd := exec.Command(helperBinary, args...)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		cmd := exec.CommandContext(ctx, helperBinary, args...)
+		// fuse helpers daemonize and hand their stdio to children; don't
+		// let an inherited pipe extend the wait past the deadline
+		cmd.WaitDelay = time.Second
 		out, err := cmd.CombinedOutput()
+		cancel()
 		if err == nil {
+			// the helper exiting zero does not prove the mount exists;
+			// verify it actually appeared before reporting success
+			infoAfterMount, err := Lookup(target)
+			if err != nil {
+				return err
+			}
+			if infoAfterMount.ID == infoBeforeMount.ID {
+				return fmt.Errorf("mount helper [%s %v] reported success but %s never appeared in mountinfo: %q", helperBinary, args, target, string(out))
+			}
 			return nil
 		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// the helper was killed; undo whatever half of the mount made it through
+			if infoAfterMount, lerr := Lookup(target); lerr == nil && infoAfterMount.ID != infoBeforeMount.ID {
+				_ = unmount(target, 0)
+			}
+			return fmt.Errorf("mount helper [%s %v] timed out after %v: %q", helperBinary, args, timeout, string(out))
+		}
 		if !errors.Is(err, unix.ECHILD) {
 			return fmt.Errorf("mount helper [%s %v] failed: %q: %w", helperBinary, args, string(out), err)
 		}
@@ -588,3 +657,12 @@ func (m *Mount) mountWithHelper(helperBinary, typePrefix, target string) error {
 	}
 	return fmt.Errorf("mount helper [%s %v] failed with ECHILD (retried %d times)", helperBinary, args, retriesOnECHILD)
 }
+
+// setTempMountPrivate marks a freshly created temp mount private so its
+// submounts cannot propagate into peer mount namespaces and pin it.
+func setTempMountPrivate(root string) error {
+	return unix.Mount("", root, "", unix.MS_PRIVATE|unix.MS_REC, "")
+}
+
+// mntDetach is the lazy unmount flag used when escalating busy unmounts.
+const mntDetach = unix.MNT_DETACH
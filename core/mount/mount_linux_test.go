@@ -17,6 +17,8 @@
 package mount
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -717,3 +719,58 @@ func TestXContainerdOptionsFiltered(t *testing.T) {
 	err := m.Mount(target)
 	require.Error(t, err, "X-containerd.* options should cause an error")
 }
+
+func TestValidateMountOptions(t *testing.T) {
+	ok := Mount{Type: "bind", Source: "/src", Options: []string{"rbind", "ro"}}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("valid mount rejected: %v", err)
+	}
+
+	flags, data, err := ParseMountOptions([]string{"rbind", "ro", "size=64m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags&unix.MS_RDONLY == 0 || flags&unix.MS_BIND == 0 {
+		t.Fatalf("expected ro+bind flags, got %x", flags)
+	}
+	if len(data) != 1 || data[0] != "size=64m" {
+		t.Fatalf("unexpected data options %v", data)
+	}
+
+	// an over-long non-overlay mount is rejected with the typed error
+	long := Mount{Type: "tmpfs", Source: "tmpfs", Options: []string{"size=" + strings.Repeat("x", pagesize)}}
+	if err := long.Validate(); !errors.Is(err, ErrMountOptionsTooLong) {
+		t.Fatalf("expected ErrMountOptionsTooLong, got %v", err)
+	}
+
+	// an overlay with a huge but compactable lowerdir chain passes
+	lowers := make([]string, 0, 512)
+	for i := 0; i < 512; i++ {
+		lowers = append(lowers, fmt.Sprintf("/var/lib/containerd/snapshots/%d/fs", i))
+	}
+	overlay := Mount{Type: "overlay", Source: "overlay", Options: []string{
+		"workdir=/w", "upperdir=/u", "lowerdir=" + strings.Join(lowers, ":"),
+	}}
+	if err := overlay.Validate(); err != nil {
+		t.Fatalf("compactable overlay rejected: %v", err)
+	}
+}
+
+func TestWithTempMountPanicCleanup(t *testing.T) {
+	testutil.RequiresRoot(t)
+	src := t.TempDir()
+	mounts := []Mount{{Type: "bind", Source: src, Options: []string{"rbind", "rw"}}}
+
+	var root string
+	err := WithTempMount(context.Background(), mounts, func(r string) error {
+		root = r
+		panic("callback exploded")
+	})
+	if err == nil || !strings.Contains(err.Error(), "panicked") {
+		t.Fatalf("expected panic to surface as error, got %v", err)
+	}
+	// the mount must be gone and the temp dir removed
+	if _, serr := os.Stat(root); !os.IsNotExist(serr) {
+		t.Fatalf("temp mount dir leaked: %v", serr)
+	}
+}
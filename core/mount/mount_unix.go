@@ -19,9 +19,12 @@
 package mount
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/moby/sys/mountinfo"
@@ -29,8 +32,14 @@ import (
 )
 
 // UnmountRecursive unmounts the target and all mounts underneath, starting
-// with the deepest mount first.
-func UnmountRecursive(target string, flags int) error {
+// with the deepest mount first. Options add bounded retries with backoff,
+// escalation to lazy unmounts, and busy-process reporting for mounts that
+// stay pinned.
+func UnmountRecursive(target string, flags int, opts ...UnmountOpt) error {
+	var o unmountOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 	if target == "" {
 		return nil
 	}
@@ -64,15 +73,90 @@ func UnmountRecursive(target string, flags int) error {
 	})
 
 	for i, target := range targets {
-		if err := UnmountAll(target, flags); err != nil {
-			if i == len(targets)-1 { // last mount
-				return err
+		err := unmountWithOptions(target, flags, &o)
+		if err != nil && i == len(targets)-1 { // last mount
+			if o.reportBusy {
+				if pids := busyProcesses(target); pids != "" {
+					return fmt.Errorf("%w (held busy by %s)", err, pids)
+				}
 			}
+			return err
 		}
 	}
 	return nil
 }
 
+// unmountWithOptions unmounts one mount point, retrying busy mounts and
+// escalating to a lazy unmount when configured.
+func unmountWithOptions(target string, flags int, o *unmountOptions) error {
+	var err error
+	for attempt := 0; attempt <= o.retries; attempt++ {
+		if o.lazyAfter > 0 && attempt >= o.lazyAfter {
+			flags |= mntDetach
+		}
+		if err = UnmountAll(target, flags); err == nil {
+			return nil
+		}
+		if !errors.Is(err, unix.EBUSY) || o.backoff <= 0 {
+			break
+		}
+		time.Sleep(o.backoff)
+	}
+	// one final lazy attempt when escalation is configured but was never
+	// reached because retries ran out first
+	if err != nil && o.lazyAfter > 0 && flags&mntDetach == 0 {
+		if lerr := UnmountAll(target, flags|mntDetach); lerr == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// busyProcesses names processes holding files, their working directory or
+// their root below target, best effort.
+func busyProcesses(target string) string {
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return ""
+	}
+	prefix := target + "/"
+	holds := func(link string) bool {
+		dest, err := os.Readlink(link)
+		if err != nil {
+			return false
+		}
+		return dest == target || strings.HasPrefix(dest, prefix)
+	}
+
+	var holders []string
+	for _, p := range procs {
+		pid := p.Name()
+		if pid[0] < '0' || pid[0] > '9' {
+			continue
+		}
+		proc := filepath.Join("/proc", pid)
+		busy := holds(filepath.Join(proc, "cwd")) || holds(filepath.Join(proc, "root")) || holds(filepath.Join(proc, "exe"))
+		if !busy {
+			if fds, err := os.ReadDir(filepath.Join(proc, "fd")); err == nil {
+				for _, fd := range fds {
+					if holds(filepath.Join(proc, "fd", fd.Name())) {
+						busy = true
+						break
+					}
+				}
+			}
+		}
+		if busy {
+			comm, _ := os.ReadFile(filepath.Join(proc, "comm"))
+			holders = append(holders, fmt.Sprintf("%s[%s]", strings.TrimSpace(string(comm)), pid))
+			if len(holders) >= 8 {
+				break
+			}
+		}
+	}
+	return strings.Join(holders, ", ")
+}
+
 func unmount(target string, flags int) error {
 	if isFUSE(target) {
 		// TODO: Why error is ignored?
@@ -36,6 +36,6 @@ func UnmountAll(mount string, flags int) error {
 }
 
 // UnmountRecursive is not implemented on this platform
-func UnmountRecursive(mount string, flags int) error {
+func UnmountRecursive(mount string, flags int, opts ...UnmountOpt) error {
 	return errdefs.ErrNotImplemented
 }
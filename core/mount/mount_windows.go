@@ -28,12 +28,84 @@ import (
 
 	"github.com/Microsoft/go-winio/pkg/bindfilter"
 	"github.com/Microsoft/hcsshim"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 	"golang.org/x/sys/windows"
 )
 
 const sourceStreamName = "containerd.io-source"
 
+// layerAPI is the slice of hcsshim and bind filter functionality the
+// mount path uses, separated so tests can substitute a fake layer
+// provider.
+type layerAPI interface {
+	ActivateLayer(di hcsshim.DriverInfo, layerID string) error
+	PrepareLayer(di hcsshim.DriverInfo, layerID string, parentLayerPaths []string) error
+	UnprepareLayer(di hcsshim.DriverInfo, layerID string) error
+	DeactivateLayer(di hcsshim.DriverInfo, layerID string) error
+	GetLayerMountPath(di hcsshim.DriverInfo, layerID string) (string, error)
+	ApplyFileBinding(root, target string, readOnly bool) error
+	RemoveFileBinding(root string) error
+}
+
+// layers is swapped for a fake in tests.
+var layers layerAPI = hcsLayers{}
+
+// hcsLayers is the production layerAPI backed by HCS, with errors mapped
+// onto errdefs so callers can react without knowing win32 codes.
+type hcsLayers struct{}
+
+func (hcsLayers) ActivateLayer(di hcsshim.DriverInfo, layerID string) error {
+	return mapHCSError(hcsshim.ActivateLayer(di, layerID))
+}
+
+func (hcsLayers) PrepareLayer(di hcsshim.DriverInfo, layerID string, parentLayerPaths []string) error {
+	return mapHCSError(hcsshim.PrepareLayer(di, layerID, parentLayerPaths))
+}
+
+func (hcsLayers) UnprepareLayer(di hcsshim.DriverInfo, layerID string) error {
+	return mapHCSError(hcsshim.UnprepareLayer(di, layerID))
+}
+
+func (hcsLayers) DeactivateLayer(di hcsshim.DriverInfo, layerID string) error {
+	return mapHCSError(hcsshim.DeactivateLayer(di, layerID))
+}
+
+func (hcsLayers) GetLayerMountPath(di hcsshim.DriverInfo, layerID string) (string, error) {
+	p, err := hcsshim.GetLayerMountPath(di, layerID)
+	return p, mapHCSError(err)
+}
+
+func (hcsLayers) ApplyFileBinding(root, target string, readOnly bool) error {
+	return bindfilter.ApplyFileBinding(root, target, readOnly)
+}
+
+func (hcsLayers) RemoveFileBinding(root string) error {
+	return bindfilter.RemoveFileBinding(root)
+}
+
+// mapHCSError attaches the matching errdefs sentinel to an HCS failure,
+// keeping the original error in the chain.
+func mapHCSError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, windows.ERROR_FILE_NOT_FOUND),
+		errors.Is(err, windows.ERROR_PATH_NOT_FOUND),
+		errors.Is(err, windows.ERROR_NOT_FOUND):
+		return fmt.Errorf("%w: %w", errdefs.ErrNotFound, err)
+	case errors.Is(err, windows.ERROR_ALREADY_EXISTS),
+		errors.Is(err, windows.ERROR_FILE_EXISTS):
+		return fmt.Errorf("%w: %w", errdefs.ErrAlreadyExists, err)
+	case errors.Is(err, windows.ERROR_ACCESS_DENIED):
+		return fmt.Errorf("%w: %w", errdefs.ErrPermissionDenied, err)
+	case errors.Is(err, windows.ERROR_INVALID_PARAMETER):
+		return fmt.Errorf("%w: %w", errdefs.ErrInvalidArgument, err)
+	default:
+		return err
+	}
+}
+
 // Mount to the provided target.
 func (m *Mount) mount(target string) (retErr error) {
 	if m.Type != "windows-layer" {
@@ -51,30 +123,30 @@ func (m *Mount) mount(target string) (retErr error) {
 		HomeDir: home,
 	}
 
-	if err := hcsshim.ActivateLayer(di, layerID); err != nil {
+	if err := layers.ActivateLayer(di, layerID); err != nil {
 		return fmt.Errorf("failed to activate layer %s: %w", m.Source, err)
 	}
 	defer func() {
 		if retErr != nil {
-			if layerErr := hcsshim.DeactivateLayer(di, layerID); layerErr != nil {
+			if layerErr := layers.DeactivateLayer(di, layerID); layerErr != nil {
 				log.G(context.TODO()).WithError(layerErr).Error("failed to deactivate layer during mount failure cleanup")
 			}
 		}
 	}()
 
-	if err := hcsshim.PrepareLayer(di, layerID, parentLayerPaths); err != nil {
+	if err := layers.PrepareLayer(di, layerID, parentLayerPaths); err != nil {
 		return fmt.Errorf("failed to prepare layer %s: %w", m.Source, err)
 	}
 
 	defer func() {
 		if retErr != nil {
-			if layerErr := hcsshim.UnprepareLayer(di, layerID); layerErr != nil {
+			if layerErr := layers.UnprepareLayer(di, layerID); layerErr != nil {
 				log.G(context.TODO()).WithError(layerErr).Error("failed to unprepare layer during mount failure cleanup")
 			}
 		}
 	}()
 
-	volume, err := hcsshim.GetLayerMountPath(di, layerID)
+	volume, err := layers.GetLayerMountPath(di, layerID)
 	if err != nil {
 		return fmt.Errorf("failed to get volume path for layer %s: %w", m.Source, err)
 	}
@@ -87,12 +159,12 @@ func (m *Mount) mount(target string) (retErr error) {
 			return fmt.Errorf("no Files folder in layer %s", layerID)
 		}
 	}
-	if err := bindfilter.ApplyFileBinding(target, volume, m.ReadOnly()); err != nil {
+	if err := layers.ApplyFileBinding(target, volume, m.ReadOnly()); err != nil {
 		return fmt.Errorf("failed to set volume mount path for layer %s: %w", m.Source, err)
 	}
 	defer func() {
 		if retErr != nil {
-			if bindErr := bindfilter.RemoveFileBinding(target); bindErr != nil {
+			if bindErr := layers.RemoveFileBinding(target); bindErr != nil {
 				log.G(context.TODO()).WithError(bindErr).Error("failed to remove binding during mount failure cleanup")
 			}
 		}
@@ -140,7 +212,7 @@ func Unmount(mount string, flags int) error {
 		layerPath = string(layerPathb)
 	}
 
-	if err := bindfilter.RemoveFileBinding(mount); err != nil {
+	if err := layers.RemoveFileBinding(mount); err != nil {
 		if errors.Is(err, windows.ERROR_INVALID_PARAMETER) || errors.Is(err, windows.ERROR_NOT_FOUND) {
 			// not a mount point
 			return nil
@@ -156,11 +228,11 @@ func Unmount(mount string, flags int) error {
 			}
 		)
 
-		if err := hcsshim.UnprepareLayer(di, layerID); err != nil {
+		if err := layers.UnprepareLayer(di, layerID); err != nil {
 			return fmt.Errorf("failed to unprepare layer %s: %w", mount, err)
 		}
 
-		if err := hcsshim.DeactivateLayer(di, layerID); err != nil {
+		if err := layers.DeactivateLayer(di, layerID); err != nil {
 			return fmt.Errorf("failed to deactivate layer %s: %w", mount, err)
 		}
 	}
@@ -181,7 +253,7 @@ func UnmountAll(mount string, flags int) error {
 }
 
 // UnmountRecursive unmounts from the provided path
-func UnmountRecursive(mount string, flags int) error {
+func UnmountRecursive(mount string, flags int, opts ...UnmountOpt) error {
 	return UnmountAll(mount, flags)
 }
 
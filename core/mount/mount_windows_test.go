@@ -0,0 +1,161 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package mount
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Microsoft/hcsshim"
+	"github.com/containerd/errdefs"
+	"golang.org/x/sys/windows"
+)
+
+// fakeLayers is a layerAPI recording calls instead of talking to HCS.
+type fakeLayers struct {
+	calls      []string
+	volume     string
+	prepareErr error
+	bindErr    error
+}
+
+func (f *fakeLayers) record(call string) {
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeLayers) ActivateLayer(di hcsshim.DriverInfo, layerID string) error {
+	f.record("activate:" + layerID)
+	return nil
+}
+
+func (f *fakeLayers) PrepareLayer(di hcsshim.DriverInfo, layerID string, parentLayerPaths []string) error {
+	f.record("prepare:" + layerID)
+	return f.prepareErr
+}
+
+func (f *fakeLayers) UnprepareLayer(di hcsshim.DriverInfo, layerID string) error {
+	f.record("unprepare:" + layerID)
+	return nil
+}
+
+func (f *fakeLayers) DeactivateLayer(di hcsshim.DriverInfo, layerID string) error {
+	f.record("deactivate:" + layerID)
+	return nil
+}
+
+func (f *fakeLayers) GetLayerMountPath(di hcsshim.DriverInfo, layerID string) (string, error) {
+	f.record("volume:" + layerID)
+	return f.volume, nil
+}
+
+func (f *fakeLayers) ApplyFileBinding(root, target string, readOnly bool) error {
+	f.record("bind:" + root)
+	return f.bindErr
+}
+
+func (f *fakeLayers) RemoveFileBinding(root string) error {
+	f.record("unbind:" + root)
+	return nil
+}
+
+func withFakeLayers(t *testing.T, f *fakeLayers) {
+	t.Helper()
+	orig := layers
+	layers = f
+	t.Cleanup(func() {
+		layers = orig
+	})
+}
+
+func expectCalls(t *testing.T, f *fakeLayers, want ...string) {
+	t.Helper()
+	if len(f.calls) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, f.calls)
+	}
+	for i := range want {
+		if f.calls[i] != want[i] {
+			t.Fatalf("expected calls %v, got %v", want, f.calls)
+		}
+	}
+}
+
+func TestWindowsLayerMount(t *testing.T) {
+	volume := t.TempDir()
+	if err := os.Mkdir(filepath.Join(volume, "Files"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeLayers{volume: volume}
+	withFakeLayers(t, f)
+
+	target := t.TempDir()
+	m := &Mount{
+		Type:   "windows-layer",
+		Source: `C:\layers\base`,
+	}
+	if err := m.Mount(target); err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, f, "activate:base", "prepare:base", "volume:base", "bind:"+target)
+
+	// unmount unwinds the bind and the layer state
+	f.calls = nil
+	if err := Unmount(target, 0); err != nil {
+		t.Fatal(err)
+	}
+	expectCalls(t, f, "unbind:"+filepath.Clean(target), "unprepare:base", "deactivate:base")
+}
+
+func TestWindowsLayerMountFailureCleanup(t *testing.T) {
+	f := &fakeLayers{prepareErr: errors.New("prepare failed")}
+	withFakeLayers(t, f)
+
+	m := &Mount{
+		Type:   "windows-layer",
+		Source: `C:\layers\broken`,
+	}
+	if err := m.Mount(t.TempDir()); err == nil {
+		t.Fatal("expected mount failure")
+	}
+	// the activated layer must not leak
+	expectCalls(t, f, "activate:broken", "prepare:broken", "deactivate:broken")
+}
+
+func TestMapHCSError(t *testing.T) {
+	for _, tc := range []struct {
+		in   error
+		want error
+	}{
+		{windows.ERROR_FILE_NOT_FOUND, errdefs.ErrNotFound},
+		{windows.ERROR_ALREADY_EXISTS, errdefs.ErrAlreadyExists},
+		{windows.ERROR_ACCESS_DENIED, errdefs.ErrPermissionDenied},
+		{windows.ERROR_INVALID_PARAMETER, errdefs.ErrInvalidArgument},
+	} {
+		err := mapHCSError(tc.in)
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%v not mapped to %v: %v", tc.in, tc.want, err)
+		}
+		// the original win32 error stays in the chain
+		if !errors.Is(err, tc.in) {
+			t.Fatalf("original error lost from %v", err)
+		}
+	}
+	if err := mapHCSError(nil); err != nil {
+		t.Fatalf("nil mapped to %v", err)
+	}
+}
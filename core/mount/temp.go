@@ -67,10 +67,25 @@ func WithTempMount(ctx context.Context, mounts []Mount, f func(root string) erro
 	if uerr = All(RemoveVolatileOption(mounts), root); uerr != nil {
 		return fmt.Errorf("failed to mount %s: %w", root, uerr)
 	}
-	if err := f(root); err != nil {
-		return fmt.Errorf("mount callback failed on %s: %w", root, err)
+	// keep the temp mount out of peer namespaces so nothing else can pin it
+	if perr := setTempMountPrivate(root); perr != nil {
+		log.G(ctx).WithError(perr).WithField("dir", root).Debug("failed to make temp mount private")
 	}
-	return nil
+
+	// A panicking callback must not leak the mount: turn the panic into an
+	// error so the unmount and removal defers above still run, then let
+	// the caller see what happened.
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("mount callback panicked on %s: %v", root, r)
+			}
+		}()
+		if ferr := f(root); ferr != nil {
+			err = fmt.Errorf("mount callback failed on %s: %w", root, ferr)
+		}
+	}()
+	return err
 }
 
 // RemoveVolatileOption copies and remove the volatile option for overlay
@@ -135,7 +150,7 @@ func copyMounts(in []Mount) []Mount {
 // and pass the temp dir to f. The mounts are valid during the call to the f.
 // Finally we will unmount and remove the temp dir regardless of the result of f.
 func WithReadonlyTempMount(ctx context.Context, mounts []Mount, f func(root string) error) (err error) {
-	return WithTempMount(ctx, readonlyMounts(mounts), f)
+	return WithTempMount(ctx, ReadonlyMounts(mounts), f)
 }
 
 func getTempDir() string {
@@ -19,8 +19,10 @@
 package mount
 
 import (
+	"fmt"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/moby/sys/mountinfo"
 )
@@ -48,8 +50,15 @@ func CleanupTempMounts(flags int) (warnings []error, err error) {
 		return len(mounts[i].Mountpoint) > len(mounts[j].Mountpoint)
 	})
 	for _, mount := range mounts {
-		if err := UnmountAll(mount.Mountpoint, flags); err != nil {
-			warnings = append(warnings, err)
+		// stale temp mounts may still be pinned briefly by exiting
+		// processes: retry with backoff and fall back to a lazy unmount
+		// so a leftover cannot wedge startup
+		if err := unmountWithOptions(mount.Mountpoint, flags, &unmountOptions{
+			retries:   3,
+			backoff:   100 * time.Millisecond,
+			lazyAfter: 3,
+		}); err != nil {
+			warnings = append(warnings, fmt.Errorf("failed to unmount temp mount %s: %w", mount.Mountpoint, err))
 			continue
 		}
 		if err := os.Remove(mount.Mountpoint); err != nil {
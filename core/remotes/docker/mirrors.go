@@ -0,0 +1,194 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/log"
+)
+
+// MirrorSet is an ordered list of mirror base URLs consulted before the
+// origin registry, with optional periodic health checking that removes
+// unresponsive mirrors from the active list until they recover.
+type MirrorSet struct {
+	// state is separate from the handle so the health check goroutine
+	// only keeps the state alive: dropping every MirrorSet reference
+	// triggers the cleanup that stops the checker.
+	state *mirrorState
+}
+
+type mirrorState struct {
+	client     *http.Client
+	authorizer Authorizer
+
+	mu        sync.Mutex
+	mirrors   []RegistryHost
+	unhealthy map[string]bool
+	checking  bool
+	stop      context.CancelFunc
+}
+
+// NewMirrorSet parses the mirror base URLs (https is assumed when no
+// scheme is given, a path prefix is kept) into a mirror set. The image
+// reference's path is preserved when a mirror is consulted, only the host
+// is exchanged. Mirrors and the origin answer token challenges through a
+// default docker authorizer.
+func NewMirrorSet(mirrors []string) (*MirrorSet, error) {
+	s := &mirrorState{
+		client:     http.DefaultClient,
+		authorizer: NewDockerAuthorizer(),
+		unhealthy:  map[string]bool{},
+	}
+	for _, m := range mirrors {
+		if !strings.Contains(m, "://") {
+			m = "https://" + m
+		}
+		u, err := url.Parse(m)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mirror %q: %w", m, err)
+		}
+		s.mirrors = append(s.mirrors, RegistryHost{
+			Client:       s.client,
+			Authorizer:   s.authorizer,
+			Host:         u.Host,
+			Scheme:       u.Scheme,
+			Path:         path.Join("/", u.Path, "v2"),
+			Capabilities: HostCapabilityPull | HostCapabilityResolve,
+		})
+	}
+	set := &MirrorSet{state: s}
+	runtime.AddCleanup(set, func(st *mirrorState) {
+		st.mu.Lock()
+		if st.stop != nil {
+			st.stop()
+		}
+		st.mu.Unlock()
+	}, s)
+	return set, nil
+}
+
+// Hosts returns a registry hosts function which tries every healthy mirror
+// in order before the origin registry; the resolver's host iteration takes
+// care of falling back on errors. The origin keeps full capabilities so
+// pushes bypass the mirrors.
+func (ms *MirrorSet) Hosts() RegistryHosts {
+	s := ms.state
+	return func(host string) ([]RegistryHost, error) {
+		originHost, err := DefaultHost(host)
+		if err != nil {
+			return nil, err
+		}
+		origin := RegistryHost{
+			Client:       s.client,
+			Authorizer:   s.authorizer,
+			Host:         originHost,
+			Scheme:       "https",
+			Path:         "/v2",
+			Capabilities: HostCapabilityPull | HostCapabilityResolve | HostCapabilityPush,
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		hosts := make([]RegistryHost, 0, len(s.mirrors)+1)
+		for _, m := range s.mirrors {
+			if s.unhealthy[m.Host] {
+				continue
+			}
+			hosts = append(hosts, m)
+		}
+		return append(hosts, origin), nil
+	}
+}
+
+// StartHealthCheck probes every mirror's /v2/ endpoint at the given
+// interval, dropping mirrors that do not answer (or answer with a server
+// error) from the active list and restoring them when they recover. It
+// runs until the context ends and is idempotent.
+func (ms *MirrorSet) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	s := ms.state
+	s.mu.Lock()
+	if s.checking {
+		s.mu.Unlock()
+		return
+	}
+	s.checking = true
+	// the checker also stops when the MirrorSet becomes unreachable, so
+	// per-operation sets cannot leak goroutines
+	ctx, s.stop = context.WithCancel(ctx)
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				s.mu.Lock()
+				s.checking = false
+				s.mu.Unlock()
+				return
+			case <-ticker.C:
+			}
+
+			for _, m := range s.snapshotMirrors() {
+				healthy := s.probe(ctx, m)
+				s.mu.Lock()
+				if !healthy && !s.unhealthy[m.Host] {
+					log.G(ctx).WithField("mirror", m.Host).Warn("registry mirror unhealthy, removing from rotation")
+				} else if healthy && s.unhealthy[m.Host] {
+					log.G(ctx).WithField("mirror", m.Host).Info("registry mirror recovered")
+				}
+				s.unhealthy[m.Host] = !healthy
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+func (s *mirrorState) snapshotMirrors() []RegistryHost {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RegistryHost(nil), s.mirrors...)
+}
+
+// probe checks the mirror's registry API root; authentication challenges
+// still prove the mirror is alive.
+func (s *mirrorState) probe(ctx context.Context, m RegistryHost) bool {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	u := fmt.Sprintf("%s://%s%s/", m.Scheme, m.Host, m.Path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusInternalServerError
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMirrorSetHosts(t *testing.T) {
+	set, err := NewMirrorSet([]string{"mirror-a.example.com", "https://mirror-b.example.com/prefix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, err := set.Hosts()("docker.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 3 {
+		t.Fatalf("expected 2 mirrors + origin, got %d", len(hosts))
+	}
+	if hosts[0].Host != "mirror-a.example.com" || hosts[0].Scheme != "https" || hosts[0].Path != "/v2" {
+		t.Fatalf("unexpected first mirror %+v", hosts[0])
+	}
+	if hosts[1].Host != "mirror-b.example.com" || hosts[1].Path != "/prefix/v2" {
+		t.Fatalf("mirror path prefix lost: %+v", hosts[1])
+	}
+	if hosts[2].Host != "registry-1.docker.io" {
+		t.Fatalf("expected docker.io origin last, got %+v", hosts[2])
+	}
+	if hosts[0].Capabilities.Has(HostCapabilityPush) {
+		t.Fatal("mirrors must not advertise push")
+	}
+	if !hosts[2].Capabilities.Has(HostCapabilityPush) {
+		t.Fatal("origin should keep push capability")
+	}
+}
+
+func TestMirrorSetHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(false)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthy.Load() {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	set, err := NewMirrorSet([]string{srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	set.StartHealthCheck(ctx, 10*time.Millisecond)
+
+	mirrorCount := func() int {
+		hosts, err := set.Hosts()("example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(hosts) - 1 // minus origin
+	}
+
+	waitFor := func(expected int) {
+		t.Helper()
+		deadline := time.Now().Add(3 * time.Second)
+		for time.Now().Before(deadline) {
+			if mirrorCount() == expected {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("expected %d active mirrors, got %d", expected, mirrorCount())
+	}
+
+	// unhealthy mirror leaves the rotation, and returns when it recovers
+	waitFor(0)
+	healthy.Store(true)
+	waitFor(1)
+}
@@ -0,0 +1,119 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/containerd/log"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/time/rate"
+
+	"github.com/containerd/containerd/v2/core/remotes"
+	remoteerrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	"github.com/distribution/reference"
+)
+
+const (
+	rateLimitInitialBackoff = 500 * time.Millisecond
+	rateLimitMaxBackoff     = 30 * time.Second
+)
+
+// NewRateLimitedResolver wraps a resolver so fetches against the given
+// registry hostname are throttled to rps requests per second with the
+// given burst, and 429 responses are retried internally with exponential
+// backoff instead of being returned to the caller. The caller's context
+// deadline stays the outer bound for the retries. Refs on other hosts pass
+// through untouched.
+func NewRateLimitedResolver(resolver remotes.Resolver, registry string, rps float64, burst int) remotes.Resolver {
+	return &rateLimitedResolver{
+		Resolver: resolver,
+		host:     registry,
+		limiter:  rate.NewLimiter(rate.Limit(rps), burst),
+	}
+}
+
+type rateLimitedResolver struct {
+	remotes.Resolver
+	host    string
+	limiter *rate.Limiter
+}
+
+func (r *rateLimitedResolver) Fetcher(ctx context.Context, ref string) (remotes.Fetcher, error) {
+	fetcher, err := r.Resolver.Fetcher(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil || reference.Domain(named) != r.host {
+		return fetcher, nil
+	}
+	return &rateLimitedFetcher{fetcher: fetcher, limiter: r.limiter}, nil
+}
+
+type rateLimitedFetcher struct {
+	fetcher remotes.Fetcher
+	limiter *rate.Limiter
+}
+
+func (f *rateLimitedFetcher) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	backoff := rateLimitInitialBackoff
+	for {
+		if err := f.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		rc, err := f.fetcher.Fetch(ctx, desc)
+		if err == nil || !isRateLimited(err) {
+			return rc, err
+		}
+
+		log.G(ctx).WithField("digest", desc.Digest).WithField("backoff", backoff).Debug("registry rate limited the fetch, backing off")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > rateLimitMaxBackoff {
+			backoff = rateLimitMaxBackoff
+		}
+	}
+}
+
+// isRateLimited reports whether the registry answered with HTTP 429.
+func isRateLimited(err error) bool {
+	var unexpected remoteerrors.ErrUnexpectedStatus
+	if errors.As(err, &unexpected) {
+		return unexpected.StatusCode == http.StatusTooManyRequests
+	}
+	var ec Error
+	if errors.As(err, &ec) {
+		return ec.Code == ErrorCodeTooManyRequests
+	}
+	var ecs Errors
+	if errors.As(err, &ecs) {
+		for _, e := range ecs {
+			if isRateLimited(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/time/rate"
+
+	remoteerrors "github.com/containerd/containerd/v2/core/remotes/errors"
+)
+
+type countingFetcher struct {
+	calls     int
+	failsWith error
+	failCount int
+}
+
+func (f *countingFetcher) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	f.calls++
+	if f.calls <= f.failCount {
+		return nil, f.failsWith
+	}
+	return io.NopCloser(strings.NewReader("data")), nil
+}
+
+func TestRateLimitedFetcherRetries429(t *testing.T) {
+	inner := &countingFetcher{
+		failCount: 2,
+		failsWith: remoteerrors.ErrUnexpectedStatus{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"},
+	}
+	f := &rateLimitedFetcher{
+		fetcher: inner,
+		limiter: rate.NewLimiter(rate.Inf, 1),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rc, err := f.Fetch(ctx, ocispec.Descriptor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+	if inner.calls != 3 {
+		t.Fatalf("expected 2 retries before success, got %d calls", inner.calls)
+	}
+}
+
+func TestRateLimitedFetcherHonorsDeadline(t *testing.T) {
+	inner := &countingFetcher{
+		failCount: 1000,
+		failsWith: remoteerrors.ErrUnexpectedStatus{StatusCode: http.StatusTooManyRequests},
+	}
+	f := &rateLimitedFetcher{
+		fetcher: inner,
+		limiter: rate.NewLimiter(rate.Inf, 1),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := f.Fetch(ctx, ocispec.Descriptor{}); err == nil {
+		t.Fatal("expected deadline to bound the retries")
+	}
+}
+
+func TestRateLimitedFetcherPassesOtherErrors(t *testing.T) {
+	inner := &countingFetcher{
+		failCount: 1,
+		failsWith: remoteerrors.ErrUnexpectedStatus{StatusCode: http.StatusNotFound},
+	}
+	f := &rateLimitedFetcher{
+		fetcher: inner,
+		limiter: rate.NewLimiter(rate.Inf, 1),
+	}
+
+	if _, err := f.Fetch(context.Background(), ocispec.Descriptor{}); err == nil {
+		t.Fatal("expected non-429 errors to pass through")
+	}
+	if inner.calls != 1 {
+		t.Fatalf("expected no retries for non-429 errors, got %d calls", inner.calls)
+	}
+}
@@ -19,6 +19,8 @@ package runtime
 import (
 	"github.com/containerd/containerd/api/events"
 	"github.com/containerd/log"
+
+	"github.com/containerd/containerd/v2/pkg/exitinfo"
 )
 
 const (
@@ -70,6 +72,8 @@ func GetTopic(e any) string {
 		return TaskResumedEventTopic
 	case *events.TaskCheckpointed:
 		return TaskCheckpointedEventTopic
+	case *exitinfo.ExitInfo:
+		return exitinfo.Topic
 	default:
 		log.L.Warnf("no topic for type %#v", e)
 	}
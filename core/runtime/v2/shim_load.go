@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
@@ -143,7 +144,7 @@ func (m *ShimManager) loadShim(ctx context.Context, bundle *Bundle) error {
 		container, err := m.containers.Get(ctx, id)
 		if err != nil {
 			log.G(ctx).WithError(err).Errorf("loading container %s", id)
-			if err := mount.UnmountRecursive(filepath.Join(bundle.Path, "rootfs"), 0); err != nil {
+			if err := mount.UnmountRecursive(filepath.Join(bundle.Path, "rootfs"), 0, mount.WithUnmountRetries(5, 100*time.Millisecond), mount.WithLazyFallback(3), mount.WithBusyReporting()); err != nil {
 				log.G(ctx).WithError(err).Errorf("failed to unmount of rootfs %s", id)
 			}
 			return err
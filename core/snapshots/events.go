@@ -0,0 +1,41 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package snapshots
+
+import "github.com/containerd/typeurl/v2"
+
+func init() {
+	typeurl.Register(&SnapshotCommitted{}, "io.containerd.event.v1.SnapshotCommitted")
+}
+
+// SnapshotCommittedTopic is the additive topic SnapshotCommitted is
+// published on, alongside the protobuf /snapshot/commit event.
+const SnapshotCommittedTopic = "/snapshot/committed"
+
+// SnapshotCommitted is published by the metadata snapshot store when a
+// snapshot is committed; unlike the protobuf SnapshotCommit event it
+// carries the parent key, so consumers can rebuild the snapshot chain
+// without querying the snapshotter.
+type SnapshotCommitted struct {
+	// Snapshotter the snapshot belongs to.
+	Snapshotter string `json:"snapshotter"`
+	// Key is the committed snapshot's name.
+	Key string `json:"key"`
+	// Parent is the committed snapshot's parent key, empty for base
+	// layers.
+	Parent string `json:"parent,omitempty"`
+}
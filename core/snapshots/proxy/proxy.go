@@ -19,8 +19,10 @@ package proxy
 import (
 	"context"
 	"io"
+	"time"
 
 	snapshotsapi "github.com/containerd/containerd/api/services/snapshots/v1"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/errdefs/pkg/errgrpc"
 
 	"github.com/containerd/containerd/v2/core/mount"
@@ -28,67 +30,173 @@ import (
 	protobuftypes "github.com/containerd/containerd/v2/pkg/protobuf/types"
 )
 
+const (
+	// defaultFastTimeout bounds quick metadata operations (Stat, Usage,
+	// Mounts, Update, Walk).
+	defaultFastTimeout = 10 * time.Second
+
+	// defaultSlowTimeout bounds operations that may move data around
+	// (Prepare, View, Commit, Remove, Cleanup).
+	defaultSlowTimeout = 2 * time.Minute
+
+	// defaultRetries is how often retryable operations are attempted
+	// again after an Unavailable or DeadlineExceeded response.
+	defaultRetries = 2
+
+	retryBackoff = 100 * time.Millisecond
+)
+
+// Opt configures the proxy snapshotter client.
+type Opt func(*proxySnapshotter)
+
+// WithFastTimeout bounds quick metadata operations. Zero disables the
+// deadline.
+func WithFastTimeout(d time.Duration) Opt {
+	return func(p *proxySnapshotter) {
+		p.fastTimeout = d
+	}
+}
+
+// WithSlowTimeout bounds Prepare, View, Commit, Remove and Cleanup. Zero
+// disables the deadline.
+func WithSlowTimeout(d time.Duration) Opt {
+	return func(p *proxySnapshotter) {
+		p.slowTimeout = d
+	}
+}
+
+// WithRetries sets how often idempotent read operations are retried after
+// Unavailable or DeadlineExceeded responses.
+func WithRetries(n int) Opt {
+	return func(p *proxySnapshotter) {
+		p.retries = n
+	}
+}
+
 // NewSnapshotter returns a new Snapshotter which communicates over a GRPC
-// connection using the containerd snapshot GRPC API.
-func NewSnapshotter(client snapshotsapi.SnapshotsClient, snapshotterName string) snapshots.Snapshotter {
-	return &proxySnapshotter{
+// connection using the containerd snapshot GRPC API. Every call carries a
+// deadline so a hung external snapshotter fails instead of blocking task
+// creation forever, and idempotent read operations are retried on
+// Unavailable and DeadlineExceeded responses.
+func NewSnapshotter(client snapshotsapi.SnapshotsClient, snapshotterName string, opts ...Opt) snapshots.Snapshotter {
+	p := &proxySnapshotter{
 		client:          client,
 		snapshotterName: snapshotterName,
+		fastTimeout:     defaultFastTimeout,
+		slowTimeout:     defaultSlowTimeout,
+		retries:         defaultRetries,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 type proxySnapshotter struct {
 	client          snapshotsapi.SnapshotsClient
 	snapshotterName string
+
+	fastTimeout time.Duration
+	slowTimeout time.Duration
+	retries     int
 }
 
-func (p *proxySnapshotter) Stat(ctx context.Context, key string) (snapshots.Info, error) {
-	resp, err := p.client.Stat(ctx,
-		&snapshotsapi.StatSnapshotRequest{
-			Snapshotter: p.snapshotterName,
-			Key:         key,
-		})
-	if err != nil {
-		return snapshots.Info{}, errgrpc.ToNative(err)
+// call invokes fn with the given deadline, retrying Unavailable and
+// DeadlineExceeded failures for idempotent operations as long as the
+// caller's own context has not ended.
+func (p *proxySnapshotter) call(ctx context.Context, timeout time.Duration, idempotent bool, fn func(ctx context.Context) error) error {
+	attempts := 1
+	if idempotent {
+		attempts += p.retries
+	}
+	for attempt := 0; ; attempt++ {
+		callCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			callCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		err := fn(callCtx)
+		cancel()
+		if err == nil || attempt >= attempts-1 || ctx.Err() != nil {
+			return err
+		}
+		if !errdefs.IsUnavailable(err) && !errdefs.IsDeadlineExceeded(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt+1) * retryBackoff):
+		}
 	}
-	return InfoFromProto(resp.Info), nil
+}
+
+func (p *proxySnapshotter) Stat(ctx context.Context, key string) (snapshots.Info, error) {
+	var info snapshots.Info
+	err := p.call(ctx, p.fastTimeout, true, func(ctx context.Context) error {
+		resp, err := p.client.Stat(ctx,
+			&snapshotsapi.StatSnapshotRequest{
+				Snapshotter: p.snapshotterName,
+				Key:         key,
+			})
+		if err != nil {
+			return errgrpc.ToNative(err)
+		}
+		info = InfoFromProto(resp.Info)
+		return nil
+	})
+	return info, err
 }
 
 func (p *proxySnapshotter) Update(ctx context.Context, info snapshots.Info, fieldpaths ...string) (snapshots.Info, error) {
-	resp, err := p.client.Update(ctx,
-		&snapshotsapi.UpdateSnapshotRequest{
-			Snapshotter: p.snapshotterName,
-			Info:        InfoToProto(info),
-			UpdateMask: &protobuftypes.FieldMask{
-				Paths: fieldpaths,
-			},
-		})
-	if err != nil {
-		return snapshots.Info{}, errgrpc.ToNative(err)
-	}
-	return InfoFromProto(resp.Info), nil
+	var updated snapshots.Info
+	err := p.call(ctx, p.fastTimeout, false, func(ctx context.Context) error {
+		resp, err := p.client.Update(ctx,
+			&snapshotsapi.UpdateSnapshotRequest{
+				Snapshotter: p.snapshotterName,
+				Info:        InfoToProto(info),
+				UpdateMask: &protobuftypes.FieldMask{
+					Paths: fieldpaths,
+				},
+			})
+		if err != nil {
+			return errgrpc.ToNative(err)
+		}
+		updated = InfoFromProto(resp.Info)
+		return nil
+	})
+	return updated, err
 }
 
 func (p *proxySnapshotter) Usage(ctx context.Context, key string) (snapshots.Usage, error) {
-	resp, err := p.client.Usage(ctx, &snapshotsapi.UsageRequest{
-		Snapshotter: p.snapshotterName,
-		Key:         key,
+	var usage snapshots.Usage
+	err := p.call(ctx, p.fastTimeout, true, func(ctx context.Context) error {
+		resp, err := p.client.Usage(ctx, &snapshotsapi.UsageRequest{
+			Snapshotter: p.snapshotterName,
+			Key:         key,
+		})
+		if err != nil {
+			return errgrpc.ToNative(err)
+		}
+		usage = UsageFromProto(resp)
+		return nil
 	})
-	if err != nil {
-		return snapshots.Usage{}, errgrpc.ToNative(err)
-	}
-	return UsageFromProto(resp), nil
+	return usage, err
 }
 
 func (p *proxySnapshotter) Mounts(ctx context.Context, key string) ([]mount.Mount, error) {
-	resp, err := p.client.Mounts(ctx, &snapshotsapi.MountsRequest{
-		Snapshotter: p.snapshotterName,
-		Key:         key,
+	var mounts []mount.Mount
+	err := p.call(ctx, p.fastTimeout, true, func(ctx context.Context) error {
+		resp, err := p.client.Mounts(ctx, &snapshotsapi.MountsRequest{
+			Snapshotter: p.snapshotterName,
+			Key:         key,
+		})
+		if err != nil {
+			return errgrpc.ToNative(err)
+		}
+		mounts = mount.FromProto(resp.Mounts)
+		return nil
 	})
-	if err != nil {
-		return nil, errgrpc.ToNative(err)
-	}
-	return mount.FromProto(resp.Mounts), nil
+	return mounts, err
 }
 
 func (p *proxySnapshotter) Prepare(ctx context.Context, key, parent string, opts ...snapshots.Opt) ([]mount.Mount, error) {
@@ -98,16 +206,21 @@ func (p *proxySnapshotter) Prepare(ctx context.Context, key, parent string, opts
 			return nil, err
 		}
 	}
-	resp, err := p.client.Prepare(ctx, &snapshotsapi.PrepareSnapshotRequest{
-		Snapshotter: p.snapshotterName,
-		Key:         key,
-		Parent:      parent,
-		Labels:      local.Labels,
+	var mounts []mount.Mount
+	err := p.call(ctx, p.slowTimeout, false, func(ctx context.Context) error {
+		resp, err := p.client.Prepare(ctx, &snapshotsapi.PrepareSnapshotRequest{
+			Snapshotter: p.snapshotterName,
+			Key:         key,
+			Parent:      parent,
+			Labels:      local.Labels,
+		})
+		if err != nil {
+			return errgrpc.ToNative(err)
+		}
+		mounts = mount.FromProto(resp.Mounts)
+		return nil
 	})
-	if err != nil {
-		return nil, errgrpc.ToNative(err)
-	}
-	return mount.FromProto(resp.Mounts), nil
+	return mounts, err
 }
 
 func (p *proxySnapshotter) View(ctx context.Context, key, parent string, opts ...snapshots.Opt) ([]mount.Mount, error) {
@@ -117,16 +230,21 @@ func (p *proxySnapshotter) View(ctx context.Context, key, parent string, opts ..
 			return nil, err
 		}
 	}
-	resp, err := p.client.View(ctx, &snapshotsapi.ViewSnapshotRequest{
-		Snapshotter: p.snapshotterName,
-		Key:         key,
-		Parent:      parent,
-		Labels:      local.Labels,
+	var mounts []mount.Mount
+	err := p.call(ctx, p.slowTimeout, false, func(ctx context.Context) error {
+		resp, err := p.client.View(ctx, &snapshotsapi.ViewSnapshotRequest{
+			Snapshotter: p.snapshotterName,
+			Key:         key,
+			Parent:      parent,
+			Labels:      local.Labels,
+		})
+		if err != nil {
+			return errgrpc.ToNative(err)
+		}
+		mounts = mount.FromProto(resp.Mounts)
+		return nil
 	})
-	if err != nil {
-		return nil, errgrpc.ToNative(err)
-	}
-	return mount.FromProto(resp.Mounts), nil
+	return mounts, err
 }
 
 func (p *proxySnapshotter) Commit(ctx context.Context, name, key string, opts ...snapshots.Opt) error {
@@ -136,25 +254,37 @@ func (p *proxySnapshotter) Commit(ctx context.Context, name, key string, opts ..
 			return err
 		}
 	}
-	_, err := p.client.Commit(ctx, &snapshotsapi.CommitSnapshotRequest{
-		Snapshotter: p.snapshotterName,
-		Name:        name,
-		Key:         key,
-		Parent:      local.Parent,
-		Labels:      local.Labels,
+	return p.call(ctx, p.slowTimeout, false, func(ctx context.Context) error {
+		_, err := p.client.Commit(ctx, &snapshotsapi.CommitSnapshotRequest{
+			Snapshotter: p.snapshotterName,
+			Name:        name,
+			Key:         key,
+			Parent:      local.Parent,
+			Labels:      local.Labels,
+		})
+		return errgrpc.ToNative(err)
 	})
-	return errgrpc.ToNative(err)
 }
 
 func (p *proxySnapshotter) Remove(ctx context.Context, key string) error {
-	_, err := p.client.Remove(ctx, &snapshotsapi.RemoveSnapshotRequest{
-		Snapshotter: p.snapshotterName,
-		Key:         key,
+	return p.call(ctx, p.slowTimeout, false, func(ctx context.Context) error {
+		_, err := p.client.Remove(ctx, &snapshotsapi.RemoveSnapshotRequest{
+			Snapshotter: p.snapshotterName,
+			Key:         key,
+		})
+		return errgrpc.ToNative(err)
 	})
-	return errgrpc.ToNative(err)
 }
 
 func (p *proxySnapshotter) Walk(ctx context.Context, fn snapshots.WalkFunc, fs ...string) error {
+	// Walks deliver results to fn as they stream in, so a retry would
+	// duplicate entries; the slow deadline bounds the whole stream since a
+	// full walk over a large store takes far longer than a single call.
+	if p.slowTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.slowTimeout)
+		defer cancel()
+	}
 	sc, err := p.client.List(ctx, &snapshotsapi.ListSnapshotsRequest{
 		Snapshotter: p.snapshotterName,
 		Filters:     fs,
@@ -186,8 +316,10 @@ func (p *proxySnapshotter) Close() error {
 }
 
 func (p *proxySnapshotter) Cleanup(ctx context.Context) error {
-	_, err := p.client.Cleanup(ctx, &snapshotsapi.CleanupRequest{
-		Snapshotter: p.snapshotterName,
+	return p.call(ctx, p.slowTimeout, false, func(ctx context.Context) error {
+		_, err := p.client.Cleanup(ctx, &snapshotsapi.CleanupRequest{
+			Snapshotter: p.snapshotterName,
+		})
+		return errgrpc.ToNative(err)
 	})
-	return errgrpc.ToNative(err)
 }
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"maps"
+	"strconv"
 	"strings"
 	"time"
 
@@ -50,6 +51,16 @@ const (
 	// LabelSnapshotGIDMapping is the label used for GID mappings
 	LabelSnapshotGIDMapping = "containerd.io/snapshot/gidmapping"
 
+	// LabelSnapshotWindowsSandboxSize sizes a Windows container's scratch
+	// space (sandbox.vhdx) in bytes, set by WithSandboxSize. Only Windows
+	// snapshotters interpret it.
+	LabelSnapshotWindowsSandboxSize = "containerd.io/snapshot/windows/rootfs.sizebytes"
+
+	// LabelSnapshotUVMScratch requests scratch space usable inside a
+	// utility VM for the snapshot, set by WithUVMLayers. Only Windows
+	// snapshotters interpret it.
+	LabelSnapshotUVMScratch = "containerd.io/snapshot/io.microsoft.vm.storage.scratch"
+
 	// LabelSnapshotMaxSize is a hint to the snapshotter that the active
 	// snapshot's filesystem should be limited to the given size, in bytes
 	// (decimal int64 as a string). Snapshotters that back an active
@@ -156,6 +167,83 @@ func (u *Usage) Add(other Usage) {
 // WalkFunc defines the callback for a snapshot walk.
 type WalkFunc func(context.Context, Info) error
 
+// MergeLabels replaces old with new labels for a replace-all update,
+// carrying over containerd-reserved gc labels the new set does not
+// explicitly address so replacing every label cannot race snapshot
+// references away from the garbage collector.
+func MergeLabels(old, new map[string]string) map[string]string {
+	merged := make(map[string]string, len(new))
+	for k, v := range new {
+		merged[k] = v
+	}
+	for k, v := range old {
+		if !strings.HasPrefix(k, "containerd.io/gc.") {
+			continue
+		}
+		if _, addressed := new[k]; !addressed {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// WithSandboxSize sizes the scratch space (sandbox.vhdx) of a Windows
+// container's snapshot in bytes. The Windows snapshotters expand the
+// sandbox accordingly and reject sizes they cannot satisfy; snapshotters
+// on other platforms ignore the label.
+func WithSandboxSize(bytes int64) Opt {
+	return func(info *Info) error {
+		if info.Labels == nil {
+			info.Labels = map[string]string{}
+		}
+		info.Labels[LabelSnapshotWindowsSandboxSize] = strconv.FormatInt(bytes, 10)
+		return nil
+	}
+}
+
+// WithUVMLayers marks the snapshot's layers for use inside a utility VM,
+// creating the scratch space hosted VMs need. Only Windows snapshotters
+// interpret the label.
+func WithUVMLayers() Opt {
+	return func(info *Info) error {
+		if info.Labels == nil {
+			info.Labels = map[string]string{}
+		}
+		info.Labels[LabelSnapshotUVMScratch] = "true"
+		return nil
+	}
+}
+
+// UpdateLabel sets a single label on the snapshot through a fine-grained
+// field mask, leaving every other label untouched so concurrent updates to
+// different keys do not clobber each other. An empty value removes the
+// label.
+func UpdateLabel(ctx context.Context, sn Snapshotter, name, key, value string) (Info, error) {
+	return sn.Update(ctx, Info{
+		Name:   name,
+		Labels: map[string]string{key: value},
+	}, "labels."+key)
+}
+
+type walkPageSizeKey struct{}
+
+// WithWalkPageSize returns a context which instructs Walk implementations
+// to read at most pageSize records per storage transaction, so walking a
+// huge store does not block writers for the whole walk. Implementations
+// without paging support ignore it.
+func WithWalkPageSize(ctx context.Context, pageSize int) context.Context {
+	return context.WithValue(ctx, walkPageSizeKey{}, pageSize)
+}
+
+// WalkPageSize returns the page size set on the context with
+// WithWalkPageSize, or zero when unset.
+func WalkPageSize(ctx context.Context) int {
+	if v, ok := ctx.Value(walkPageSizeKey{}).(int); ok {
+		return v
+	}
+	return 0
+}
+
 // Snapshotter defines the methods required to implement a snapshot snapshotter for
 // allocating, snapshotting and mounting filesystem changesets. The model works
 // by building up sets of changes with parent-child relationships.
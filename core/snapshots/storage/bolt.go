@@ -116,20 +116,24 @@ func UpdateInfo(ctx context.Context, info snapshots.Info, fieldpaths ...string)
 					}
 
 					key := strings.TrimPrefix(path, "labels.")
-					updated.Labels[key] = info.Labels[key]
+					if v, ok := info.Labels[key]; ok && v != "" {
+						updated.Labels[key] = v
+					} else {
+						delete(updated.Labels, key)
+					}
 					continue
 				}
 
 				switch path {
 				case "labels":
-					updated.Labels = info.Labels
+					updated.Labels = snapshots.MergeLabels(updated.Labels, info.Labels)
 				default:
 					return fmt.Errorf("cannot update %q field on snapshot %q: %w", path, info.Name, errdefs.ErrInvalidArgument)
 				}
 			}
 		} else {
 			// Set mutable fields
-			updated.Labels = info.Labels
+			updated.Labels = snapshots.MergeLabels(updated.Labels, info.Labels)
 		}
 		updated.Updated = time.Now().UTC()
 		if err := boltutil.WriteTimestamps(sbkt, updated.Created, updated.Updated); err != nil {
@@ -177,6 +181,88 @@ func WalkInfo(ctx context.Context, fn snapshots.WalkFunc, fs ...string) error {
 	})
 }
 
+// defaultWalkPageSize bounds how many snapshot records WalkInfoPaged reads
+// per transaction when the caller does not set a page size on the context.
+const defaultWalkPageSize = 100
+
+// TransactionManager opens storage transactions, implemented by MetaStore
+// and the snapshotters' metastore wrappers.
+type TransactionManager interface {
+	WithTransaction(ctx context.Context, writable bool, fn TransactionCallback) error
+}
+
+// WalkInfoPaged walks the snapshots like WalkInfo, but manages its own
+// read transactions, holding one per page of results (snapshots.WithWalkPageSize
+// on the context, 100 records by default) instead of for the whole walk.
+// The continue token between pages is the last snapshot name read, and
+// filters are evaluated inside the transaction so the token math stays
+// correct for filtered walks.
+func WalkInfoPaged(ctx context.Context, ms TransactionManager, fn snapshots.WalkFunc, fs ...string) error {
+	filter, err := filters.ParseAll(fs...)
+	if err != nil {
+		return err
+	}
+
+	pageSize := snapshots.WalkPageSize(ctx)
+	if pageSize <= 0 {
+		pageSize = defaultWalkPageSize
+	}
+
+	var (
+		infos []snapshots.Info
+		last  string
+		more  = true
+	)
+	for more {
+		if err := ms.WithTransaction(ctx, false, func(ctx context.Context) error {
+			infos = infos[:0]
+			more = false
+
+			return withBucket(ctx, func(ctx context.Context, bkt, pbkt *bolt.Bucket) error {
+				cur := bkt.Cursor()
+				k, v := cur.First()
+				if last != "" {
+					if k, v = cur.Seek([]byte(last)); string(k) == last {
+						k, v = cur.Next()
+					}
+				}
+				for scanned := 0; k != nil; k, v = cur.Next() {
+					if v != nil {
+						continue
+					}
+					if scanned >= pageSize {
+						more = true
+						return nil
+					}
+					scanned++
+					last = string(k)
+
+					si := snapshots.Info{
+						Name: string(k),
+					}
+					if err := readSnapshot(bkt.Bucket(k), nil, &si); err != nil {
+						return err
+					}
+					if filter.Match(adaptSnapshot(si)) {
+						infos = append(infos, si)
+					}
+				}
+				return nil
+			})
+		}); err != nil {
+			return err
+		}
+
+		for _, si := range infos {
+			if err := fn(ctx, si); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // GetSnapshot returns the metadata for the active or view snapshot transaction
 // referenced by the given key. Requires a context with a storage transaction.
 func GetSnapshot(ctx context.Context, key string) (s Snapshot, err error) {
@@ -0,0 +1,140 @@
+//go:build !windows
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package unpack
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/containerd/log"
+)
+
+// WithDeduplicateHardlinks re-links identical files across the layers of
+// one unpack: after a layer applies, files whose content and metadata
+// match a file from an earlier layer are replaced by hardlinks to it,
+// reclaiming the space images waste when hardlinked files are split
+// across layers. Opt-in since it changes inode sharing between layers,
+// and only effective for snapshotters whose layer directories are visible
+// in their mounts (overlay upperdirs, bind sources) on one filesystem.
+func WithDeduplicateHardlinks() UnpackerOpt {
+	return func(c *unpackerConfig) error {
+		c.dedupHardlinks = true
+		return nil
+	}
+}
+
+// dedupKey identifies identical files: same content hash and identical
+// metadata, since hardlinked files share mode, ownership and timestamps.
+type dedupKey struct {
+	digest string
+	size   int64
+	mode   os.FileMode
+	uid    uint32
+	gid    uint32
+	mtime  int64
+}
+
+// dedupIndex maps file identity to an already-applied path, per unpack.
+type dedupIndex struct {
+	files map[dedupKey]string
+	dev   uint64
+	saved int64
+}
+
+func newDedupIndex() *dedupIndex {
+	return &dedupIndex{files: map[dedupKey]string{}}
+}
+
+// dedupLayer hashes the regular files below root, relinking those whose
+// content and metadata match a file from an earlier layer and recording
+// the rest for the layers that follow. Errors only disable deduplication
+// for the affected file, never fail the unpack.
+func (d *dedupIndex) dedupLayer(ctx context.Context, root string) {
+	filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.Mode().IsRegular() || fi.Size() == 0 {
+			return nil
+		}
+		stat, ok := fi.Sys().(*syscall.Stat_t)
+		if !ok || stat.Nlink > 1 {
+			return nil
+		}
+		if d.dev == 0 {
+			d.dev = uint64(stat.Dev)
+		} else if uint64(stat.Dev) != d.dev {
+			// hardlinks cannot cross filesystems
+			return nil
+		}
+
+		dgst, err := hashFile(path)
+		if err != nil {
+			return nil
+		}
+		key := dedupKey{
+			digest: dgst,
+			size:   fi.Size(),
+			mode:   fi.Mode(),
+			uid:    stat.Uid,
+			gid:    stat.Gid,
+			mtime:  stat.Mtim.Nano(),
+		}
+		target, ok := d.files[key]
+		if !ok {
+			d.files[key] = path
+			return nil
+		}
+		if err := relink(target, path); err != nil {
+			log.G(ctx).WithError(err).WithField("path", path).Debug("failed to deduplicate file")
+			return nil
+		}
+		d.saved += fi.Size()
+		return nil
+	})
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// relink atomically replaces path with a hardlink to target.
+func relink(target, path string) error {
+	tmp := path + ".dedup-tmp"
+	if err := os.Link(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace with hardlink: %w", err)
+	}
+	return nil
+}
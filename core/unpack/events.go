@@ -0,0 +1,45 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package unpack
+
+import (
+	"time"
+
+	"github.com/containerd/typeurl/v2"
+)
+
+func init() {
+	typeurl.Register(&ImageUnpacked{}, "io.containerd.event.v1.ImageUnpacked")
+}
+
+// ImageUnpackedTopic is the additive topic ImageUnpacked is published on.
+const ImageUnpackedTopic = "/images/unpacked"
+
+// ImageUnpacked is published when all of an image's layers have been
+// unpacked into a snapshotter, the signal schedulers need before placing
+// a container instead of polling the snapshotter.
+type ImageUnpacked struct {
+	// Name is the image name when the unpacked manifest carried one in
+	// its annotations; it may be empty.
+	Name string `json:"name,omitempty"`
+	// Snapshotter the layers were unpacked into.
+	Snapshotter string `json:"snapshotter"`
+	// ChainID of the unpacked rootfs.
+	ChainID string `json:"chain_id"`
+	// Duration of the whole unpack.
+	Duration time.Duration `json:"duration"`
+}
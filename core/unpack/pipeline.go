@@ -0,0 +1,230 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package unpack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/containerd/log"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+)
+
+// defaultPipelineBudget bounds how much spooled decompressed data the
+// pipeline may keep on disk at once.
+const defaultPipelineBudget = 512 << 20
+
+// WithDecompressPipeline decompresses up to depth upcoming layers into
+// spooled temporary files while earlier layers are still being applied, so
+// the applier receives a plain tar stream and decompression leaves the
+// critical path. budgetBytes bounds the spooled data on disk, the 512 MiB
+// default applies when zero; layers exceeding the remaining budget fall
+// back to inline decompression. A depth of zero disables the pipeline.
+func WithDecompressPipeline(depth int, budgetBytes int64) UnpackerOpt {
+	return func(c *unpackerConfig) error {
+		if depth < 0 {
+			return fmt.Errorf("pipeline depth cannot be negative")
+		}
+		if budgetBytes == 0 {
+			budgetBytes = defaultPipelineBudget
+		}
+		c.pipelineDepth = depth
+		c.pipelineBudget = budgetBytes
+		return nil
+	}
+}
+
+// spooler pre-decompresses upcoming layers to disk, one temporary file per
+// layer, removed as soon as the stream is handed over or abandoned.
+type spooler struct {
+	cs     content.Provider
+	layers []ocispec.Descriptor
+	depth  int
+
+	mu      sync.Mutex
+	started map[int]chan io.ReadCloser
+	budget  int64
+}
+
+func (u *Unpacker) newSpooler(layers []ocispec.Descriptor) *spooler {
+	if u.pipelineDepth <= 0 {
+		return nil
+	}
+	return &spooler{
+		cs:      u.content,
+		layers:  layers,
+		depth:   u.pipelineDepth,
+		started: map[int]chan io.ReadCloser{},
+		budget:  u.pipelineBudget,
+	}
+}
+
+// ahead starts spooling layers (from, from+depth] that have not been
+// started yet. fetched carries the per-layer fetch completion channels
+// beginning at layer index offset.
+func (s *spooler) ahead(ctx context.Context, from int, fetched []chan struct{}, offset int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for j := from; j < from+s.depth && j < len(s.layers); j++ {
+		if _, ok := s.started[j]; ok {
+			continue
+		}
+		if j < offset || j-offset >= len(fetched) {
+			continue
+		}
+		if !compressedLayer(s.layers[j].MediaType) {
+			continue
+		}
+		ch := make(chan io.ReadCloser, 1)
+		s.started[j] = ch
+		go s.spool(ctx, j, fetched[j-offset], ch)
+	}
+}
+
+// take hands over the spooled stream for layer i, waiting for an
+// in-flight spool to finish. It returns nil when the layer was never
+// spooled or spooling was abandoned, in which case the applier
+// decompresses inline.
+func (s *spooler) take(ctx context.Context, i int) io.ReadCloser {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	ch, ok := s.started[i]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	select {
+	case rc := <-ch:
+		return rc
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func (s *spooler) spool(ctx context.Context, i int, fetched <-chan struct{}, out chan<- io.ReadCloser) {
+	abandon := func() {
+		out <- nil
+		close(out)
+	}
+
+	select {
+	case <-ctx.Done():
+		abandon()
+		return
+	case <-fetched:
+	}
+
+	desc := s.layers[i]
+	size := desc.Size * 3 // uncompressed estimate for budgeting
+	s.mu.Lock()
+	if size > s.budget {
+		s.mu.Unlock()
+		abandon()
+		return
+	}
+	s.budget -= size
+	s.mu.Unlock()
+	release := func() {
+		s.mu.Lock()
+		s.budget += size
+		s.mu.Unlock()
+	}
+
+	rc, err := s.decompressToFile(ctx, desc)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("digest", desc.Digest).Debug("abandoning layer spool")
+		release()
+		abandon()
+		return
+	}
+	out <- &releasingReadCloser{ReadCloser: rc, release: release}
+	close(out)
+}
+
+// decompressToFile decompresses the blob into an unlinked temporary file
+// and returns a reader over it, so the data disappears with the handle.
+func (s *spooler) decompressToFile(ctx context.Context, desc ocispec.Descriptor) (_ io.ReadCloser, retErr error) {
+	ra, err := s.cs.ReaderAt(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer ra.Close()
+
+	dec, err := compression.DecompressStream(content.NewReader(ra))
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	f, err := os.CreateTemp("", "containerd-unpack-spool-")
+	if err != nil {
+		return nil, err
+	}
+	// unlink immediately, the data lives as long as the handle
+	os.Remove(f.Name())
+	defer func() {
+		if retErr != nil {
+			f.Close()
+		}
+	}()
+
+	if _, err := io.Copy(f, dec); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+type releasingReadCloser struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+// Close is once-guarded so the stream may be closed both by an applier
+// that consumed it and by the unpacker afterwards; the second close is a
+// no-op.
+func (r *releasingReadCloser) Close() error {
+	var err error
+	r.once.Do(func() {
+		err = r.ReadCloser.Close()
+		r.release()
+	})
+	return err
+}
+
+// compressedLayer reports whether the media type is a compressed layer
+// worth spooling; plain tars gain nothing from the pipeline.
+func compressedLayer(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "+gzip") || strings.HasSuffix(mediaType, "+zstd") ||
+		mediaType == ocispec.MediaTypeImageLayerGzip || mediaType == ocispec.MediaTypeImageLayerZstd ||
+		mediaType == "application/vnd.docker.image.rootfs.diff.tar.gzip"
+}
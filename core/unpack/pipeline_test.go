@@ -0,0 +1,265 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package unpack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+)
+
+func gzipBlob(t testing.TB, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeLayer(t testing.TB, cs content.Store, data []byte) ocispec.Descriptor {
+	t.Helper()
+	blob := gzipBlob(t, data)
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayerGzip,
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	if err := content.WriteBlob(context.Background(), cs, "spool-"+desc.Digest.Encoded(), bytes.NewReader(blob), desc); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+func TestSpooler(t *testing.T) {
+	ctx := context.Background()
+
+	cs, err := local.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := make([]byte, 256<<10)
+	if _, err := rand.Read(payload); err != nil {
+		t.Fatal(err)
+	}
+	layers := []ocispec.Descriptor{
+		writeLayer(t, cs, []byte("layer zero")),
+		writeLayer(t, cs, payload),
+	}
+
+	s := &spooler{
+		cs:      cs,
+		layers:  layers,
+		depth:   2,
+		started: map[int]chan io.ReadCloser{},
+		budget:  defaultPipelineBudget,
+	}
+
+	fetched := make([]chan struct{}, len(layers))
+	for i := range fetched {
+		fetched[i] = make(chan struct{})
+		close(fetched[i]) // layers already in the store
+	}
+
+	s.ahead(ctx, 0, fetched, 0)
+	for i, expected := range [][]byte{[]byte("layer zero"), payload} {
+		rc := s.take(ctx, i)
+		if rc == nil {
+			t.Fatalf("layer %d was not spooled", i)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Fatalf("layer %d spooled content mismatch", i)
+		}
+	}
+
+	// out of budget: spooling is abandoned, the applier falls back
+	s2 := &spooler{
+		cs:      cs,
+		layers:  layers,
+		depth:   1,
+		started: map[int]chan io.ReadCloser{},
+		budget:  1,
+	}
+	s2.ahead(ctx, 1, fetched, 0)
+	if rc := s2.take(ctx, 1); rc != nil {
+		rc.Close()
+		t.Fatal("expected spooling to be abandoned when over budget")
+	}
+	// never started layers return immediately
+	if rc := s2.take(ctx, 0); rc != nil {
+		rc.Close()
+		t.Fatal("expected no stream for a never-spooled layer")
+	}
+}
+
+// BenchmarkDecompressPipeline contrasts strictly sequential decompression
+// and apply with the pipelined variant where the next layer decompresses
+// while the current one is consumed.
+func BenchmarkDecompressPipeline(b *testing.B) {
+	ctx := context.Background()
+
+	cs, err := local.NewStore(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+	payload := make([]byte, 8<<20)
+	rand.Read(payload)
+	var layers []ocispec.Descriptor
+	for i := 0; i < 6; i++ {
+		layers = append(layers, writeLayer(b, cs, payload))
+	}
+	fetched := make([]chan struct{}, len(layers))
+	for i := range fetched {
+		fetched[i] = make(chan struct{})
+		close(fetched[i])
+	}
+
+	consume := func(r io.Reader) {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("Sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, desc := range layers {
+				s := &spooler{cs: cs, layers: layers, budget: defaultPipelineBudget}
+				rc, err := s.decompressToFile(ctx, desc)
+				if err != nil {
+					b.Fatal(err)
+				}
+				consume(rc)
+				rc.Close()
+			}
+		}
+	})
+
+	b.Run("Pipelined", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s := &spooler{
+				cs:      cs,
+				layers:  layers,
+				depth:   2,
+				started: map[int]chan io.ReadCloser{},
+				budget:  defaultPipelineBudget,
+			}
+			s.ahead(ctx, 0, fetched, 0)
+			for j := range layers {
+				s.ahead(ctx, j+1, fetched, 0)
+				rc := s.take(ctx, j)
+				if rc == nil {
+					b.Fatal("layer not spooled")
+				}
+				consume(rc)
+				rc.Close()
+			}
+		}
+	})
+}
+
+// TestDedupIndex measures the savings of relinking identical files that an
+// image split across layers.
+func TestDedupIndex(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	payload := make([]byte, 128<<10)
+	rand.Read(payload)
+
+	write := func(layer, name string) string {
+		dir := filepath.Join(root, layer)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, payload, 0644); err != nil {
+			t.Fatal(err)
+		}
+		// identical metadata, as hardlinked files would have had
+		mtime := time.Unix(1700000000, 0)
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	first := write("layer1", "libfoo.so")
+	second := write("layer2", "libfoo-copy.so")
+	third := write("layer2", "different.so")
+	other := bytes.Clone(payload)
+	other[len(other)-1] ^= 0xFF
+	if err := os.WriteFile(third, other, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := newDedupIndex()
+	d.dedupLayer(ctx, filepath.Join(root, "layer1"))
+	d.dedupLayer(ctx, filepath.Join(root, "layer2"))
+
+	if d.saved != int64(len(payload)) {
+		t.Fatalf("expected %d bytes saved, got %d", len(payload), d.saved)
+	}
+
+	fi1, err := os.Stat(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi2, err := os.Stat(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(fi1, fi2) {
+		t.Fatal("identical files across layers should share an inode")
+	}
+	data, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Fatal("deduplicated file content changed")
+	}
+
+	fi3, err := os.Stat(third)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(fi1, fi3) {
+		t.Fatal("files with different content must not be linked")
+	}
+}
@@ -23,8 +23,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -39,6 +41,7 @@ import (
 
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/diff"
+	"github.com/containerd/containerd/v2/core/events"
 	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/containerd/v2/core/mount"
 	"github.com/containerd/containerd/v2/core/snapshots"
@@ -66,6 +69,22 @@ type unpackerConfig struct {
 	limiter               Limiter
 	duplicationSuppressor KeyedLocker
 	unpackLimiter         Limiter
+
+	// pipelineDepth and pipelineBudget configure the decompression
+	// pipeline, see WithDecompressPipeline.
+	pipelineDepth  int
+	pipelineBudget int64
+
+	// publisher, when set, receives an ImageUnpacked event per finished
+	// unpack, see WithEventsPublisher.
+	publisher events.Publisher
+
+	// imageName names the image in published events, see WithImageName.
+	imageName string
+
+	// dedupHardlinks re-links identical files across layers, see
+	// WithDeduplicateHardlinks.
+	dedupHardlinks bool
 }
 
 // Platform represents a platform-specific unpack configuration which includes
@@ -153,6 +172,25 @@ func WithUnpackLimiter(l Limiter) UnpackerOpt {
 	})
 }
 
+// WithEventsPublisher publishes an ImageUnpacked event on
+// /images/unpacked when an image finishes unpacking into a snapshotter.
+func WithEventsPublisher(p events.Publisher) UnpackerOpt {
+	return UnpackerOpt(func(c *unpackerConfig) error {
+		c.publisher = p
+		return nil
+	})
+}
+
+// WithImageName names the image in published events; without it the name
+// falls back to the config descriptor's annotations, which pulls leave
+// empty.
+func WithImageName(name string) UnpackerOpt {
+	return UnpackerOpt(func(c *unpackerConfig) error {
+		c.imageName = name
+		return nil
+	})
+}
+
 // Unpacker unpacks images by hooking into the image handler process.
 // Unpacks happen in the backgrounds and waited on to complete.
 type Unpacker struct {
@@ -357,7 +395,14 @@ func (u *Unpacker) unpack(
 		fetchErr    []chan error
 
 		parallel = u.supportParallel(unpack)
+
+		sp = u.newSpooler(layers)
+
+		dedup *dedupIndex
 	)
+	if u.dedupHardlinks {
+		dedup = newDedupIndex()
+	}
 
 	// If there is an early return, ensure any ongoing
 	// fetches get their context cancelled
@@ -537,7 +582,24 @@ func (u *Unpacker) unpack(
 				mounts = bindToOverlay(mounts)
 			}
 
-			diff, err := a.Apply(ctx, desc, mounts, unpack.ApplyOpts...)
+			// While this layer applies, spool decompression of the next
+			// ones so they arrive as plain tar streams.
+			sp.ahead(ctx, i+1, fetchC, fetchOffset)
+			applyOpts := unpack.ApplyOpts
+			var spooled io.ReadCloser
+			if rc := sp.take(ctx, i); rc != nil {
+				spooled = rc
+				applyOpts = append(applyOpts[:len(applyOpts):len(applyOpts)], diff.WithDecompressedStream(rc))
+			}
+
+			diff, err := a.Apply(ctx, desc, mounts, applyOpts...)
+			if spooled != nil {
+				// only some appliers consume (and close) the spooled
+				// stream; the close is once-guarded, so this is a no-op
+				// then and otherwise reclaims the temp file and the
+				// spool budget
+				spooled.Close()
+			}
 			if err != nil {
 				cleanup.Do(ctx, abort)
 				status.err = fmt.Errorf("failed to extract layer (%s %s) to %s as %q: %w", desc.MediaType, desc.Digest, unpack.SnapshotterKey, key, err)
@@ -552,6 +614,15 @@ func (u *Unpacker) unpack(
 				return
 			}
 
+			// Re-link identical files against earlier layers after the
+			// diff id has been verified, so the relinking never affects
+			// digest validation.
+			if dedup != nil {
+				if root := layerRoot(mounts); root != "" {
+					dedup.dedupLayer(ctx, root)
+				}
+			}
+
 			resCh <- status
 		}()
 
@@ -649,6 +720,21 @@ func (u *Unpacker) unpack(
 		"duration": time.Since(unpackStart),
 	}).Debug("image unpacked")
 
+	if u.publisher != nil {
+		name := u.imageName
+		if name == "" {
+			name = config.Annotations[images.AnnotationImageName]
+		}
+		if err := u.publisher.Publish(ctx, ImageUnpackedTopic, &ImageUnpacked{
+			Name:        name,
+			Snapshotter: unpack.SnapshotterKey,
+			ChainID:     chainID,
+			Duration:    time.Since(unpackStart),
+		}); err != nil {
+			log.G(ctx).WithError(err).Warn("failed to publish image unpacked event")
+		}
+	}
+
 	return nil
 }
 
@@ -780,3 +866,23 @@ func bindToOverlay(mounts []mount.Mount) []mount.Mount {
 
 	return []mount.Mount{m}
 }
+
+// layerRoot resolves the directory holding a layer's own files from its
+// mounts, empty when the snapshotter does not expose one.
+func layerRoot(mounts []mount.Mount) string {
+	if len(mounts) != 1 {
+		return ""
+	}
+	m := mounts[0]
+	switch m.Type {
+	case "bind", "rbind":
+		return m.Source
+	case "overlay":
+		for _, opt := range m.Options {
+			if v, ok := strings.CutPrefix(opt, "upperdir="); ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
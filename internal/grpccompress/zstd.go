@@ -0,0 +1,101 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package grpccompress registers the compression algorithms the client's
+// WithGRPCCompression option and the daemon's grpc servers support. gzip
+// comes from grpc itself, zstd is implemented here on top of
+// klauspost/compress. Importing the package is enough to accept compressed
+// messages; senders additionally pass grpc.UseCompressor.
+package grpccompress
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+
+	// register the gzip compressor alongside zstd
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+// ZstdName is the registered name of the zstd compressor, the value
+// accepted by grpc.UseCompressor.
+const ZstdName = "zstd"
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+}
+
+type zstdCompressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+type zstdWriter struct {
+	enc  *zstd.Encoder
+	pool *sync.Pool
+}
+
+func (w *zstdWriter) Write(p []byte) (int, error) {
+	return w.enc.Write(p)
+}
+
+func (w *zstdWriter) Close() error {
+	err := w.enc.Close()
+	w.pool.Put(w.enc)
+	return err
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if enc, ok := c.encoders.Get().(*zstd.Encoder); ok {
+		enc.Reset(w)
+		return &zstdWriter{enc: enc, pool: &c.encoders}, nil
+	}
+	enc, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdWriter{enc: enc, pool: &c.encoders}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	dec, ok := c.decoders.Get().(*zstd.Decoder)
+	if !ok {
+		var err error
+		if dec, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+			return nil, err
+		}
+	}
+	if err := dec.Reset(r); err != nil {
+		c.decoders.Put(dec)
+		return nil, err
+	}
+	// Read the message eagerly so the decoder can be returned to the pool
+	// immediately; grpc messages are bounded by the configured maximum
+	// message size.
+	data, err := io.ReadAll(dec.IOReadCloser())
+	c.decoders.Put(dec)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
+
+func (c *zstdCompressor) Name() string {
+	return ZstdName
+}
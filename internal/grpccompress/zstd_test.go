@@ -0,0 +1,87 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package grpccompress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// payload resembles the repetitive JSON-ish metadata that dominates large
+// containerd responses.
+func payload(n int) []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < n; i++ {
+		fmt.Fprintf(&buf, `{"digest":"sha256:%064d","mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","size":%d}`, i, i*1024)
+	}
+	return buf.Bytes()
+}
+
+func roundTrip(t testing.TB, name string, data []byte) []byte {
+	t.Helper()
+	c := encoding.GetCompressor(name)
+	if c == nil {
+		t.Fatalf("compressor %q not registered", name)
+	}
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.Decompress(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestZstdCompressorRoundTrip(t *testing.T) {
+	data := payload(1 << 20)
+	for i := 0; i < 3; i++ { // exercise the pools
+		if out := roundTrip(t, ZstdName, data); !bytes.Equal(out, data) {
+			t.Fatal("zstd round trip mismatch")
+		}
+	}
+}
+
+func BenchmarkCompressors(b *testing.B) {
+	data := payload(1 << 20)
+	for _, name := range []string{"gzip", ZstdName} {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if out := roundTrip(b, name, data); len(out) != len(data) {
+					b.Fatal("round trip mismatch")
+				}
+			}
+		})
+	}
+}
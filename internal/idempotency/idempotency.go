@@ -0,0 +1,144 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package idempotency deduplicates retried task operations: requests
+// carrying an x-idempotency-key metadata header have their response cached
+// for a bounded time, and a duplicate request returns the cached response
+// instead of re-executing. A retried Start therefore reports the pid of
+// the original start and a retried Exec does not attempt to create the
+// process a second time.
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+)
+
+// MetadataKey is the gRPC metadata header carrying the idempotency key.
+const MetadataKey = "x-idempotency-key"
+
+// ttl is how long processed keys are remembered.
+const ttl = 10 * time.Minute
+
+// dedupedMethods are the task operations where a network-level retry must
+// not execute twice.
+var dedupedMethods = map[string]bool{
+	"/containerd.services.tasks.v1.Tasks/Start":  true,
+	"/containerd.services.tasks.v1.Tasks/Exec":   true,
+	"/containerd.services.tasks.v1.Tasks/Delete": true,
+}
+
+type cachedResponse struct {
+	resp    any
+	err     error
+	stored  time.Time
+	pending chan struct{}
+}
+
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+// UnaryServerInterceptor deduplicates Start, Exec and Delete task requests
+// carrying an idempotency key, scoped per namespace. The first request
+// executes; concurrent and later duplicates wait for and share its
+// response until the key expires after ten minutes.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	c := newCache()
+	return c.intercept
+}
+
+func newCache() *cache {
+	return &cache{entries: map[string]*cachedResponse{}}
+}
+
+func (c *cache) intercept(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !dedupedMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	key := keyFromContext(ctx, info.FullMethod)
+	if key == "" {
+		return handler(ctx, req)
+	}
+
+	c.mu.Lock()
+	c.sweepLocked()
+	if entry, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-entry.pending:
+			return entry.resp, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	entry := &cachedResponse{pending: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	entry.resp, entry.err = handler(ctx, req)
+	if ctx.Err() != nil {
+		// The caller went away mid-operation; the outcome reflects the
+		// dropped connection, not the operation. Forget the key so the
+		// retry the idempotency key exists for re-runs the handler
+		// instead of being served a stale context error.
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+	} else {
+		entry.stored = time.Now()
+	}
+	close(entry.pending)
+	return entry.resp, entry.err
+}
+
+// keyFromContext builds the namespace-scoped cache key, empty when the
+// request carries no idempotency key.
+func keyFromContext(ctx context.Context, method string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(MetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return ""
+	}
+	ns, _ := namespaces.Namespace(ctx)
+	return ns + "/" + method + "/" + values[0]
+}
+
+// sweepLocked drops expired entries; pending entries have no stored time
+// yet and are kept.
+func (c *cache) sweepLocked() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		select {
+		case <-entry.pending:
+		default:
+			continue
+		}
+		if now.Sub(entry.stored) > ttl {
+			delete(c.entries, key)
+		}
+	}
+}
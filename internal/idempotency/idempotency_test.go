@@ -0,0 +1,192 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+)
+
+const startMethod = "/containerd.services.tasks.v1.Tasks/Start"
+
+func requestCtx(key string) context.Context {
+	ctx := namespaces.WithNamespace(context.Background(), "test")
+	if key == "" {
+		return ctx
+	}
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(MetadataKey, key))
+}
+
+func startInfo() *grpc.UnaryServerInfo {
+	return &grpc.UnaryServerInfo{FullMethod: startMethod}
+}
+
+func countingHandler(calls *atomic.Int32, resp any, err error) grpc.UnaryHandler {
+	return func(context.Context, any) (any, error) {
+		calls.Add(1)
+		return resp, err
+	}
+}
+
+func TestDeduplicatesRetries(t *testing.T) {
+	c := newCache()
+	var calls atomic.Int32
+	handler := countingHandler(&calls, "pid-1", nil)
+
+	for i := 0; i < 3; i++ {
+		resp, err := c.intercept(requestCtx("k1"), nil, startInfo(), handler)
+		if err != nil || resp != "pid-1" {
+			t.Fatalf("unexpected outcome %v %v", resp, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+
+	// a different key, namespace or method executes independently
+	if _, err := c.intercept(requestCtx("k2"), nil, startInfo(), handler); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls.Load())
+	}
+
+	// requests without a key are never deduplicated
+	for i := 0; i < 2; i++ {
+		if _, err := c.intercept(requestCtx(""), nil, startInfo(), handler); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls.Load() != 4 {
+		t.Fatalf("handler ran %d times, want 4", calls.Load())
+	}
+}
+
+func TestConcurrentWaitersShareOneExecution(t *testing.T) {
+	c := newCache()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := func(context.Context, any) (any, error) {
+		calls.Add(1)
+		<-release
+		return "shared", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan any, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.intercept(requestCtx("k"), nil, startInfo(), handler)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			results <- resp
+		}()
+	}
+	// let the waiters pile up before releasing the first execution
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+	for resp := range results {
+		if resp != "shared" {
+			t.Fatalf("waiter got %v", resp)
+		}
+	}
+}
+
+func TestExpiredKeysReExecute(t *testing.T) {
+	c := newCache()
+	var calls atomic.Int32
+	handler := countingHandler(&calls, "pid", nil)
+
+	if _, err := c.intercept(requestCtx("k"), nil, startInfo(), handler); err != nil {
+		t.Fatal(err)
+	}
+
+	// age the entry past the ttl; the next request must re-run
+	c.mu.Lock()
+	for _, entry := range c.entries {
+		entry.stored = time.Now().Add(-ttl - time.Minute)
+	}
+	c.mu.Unlock()
+
+	if _, err := c.intercept(requestCtx("k"), nil, startInfo(), handler); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("handler ran %d times after expiry, want 2", calls.Load())
+	}
+}
+
+func TestCancelledOutcomeIsNotCached(t *testing.T) {
+	c := newCache()
+	var calls atomic.Int32
+
+	// the first attempt's caller drops mid-operation
+	cancelledCtx, cancel := context.WithCancel(requestCtx("k"))
+	handler := func(ctx context.Context, _ any) (any, error) {
+		calls.Add(1)
+		cancel()
+		return nil, ctx.Err()
+	}
+	if _, err := c.intercept(cancelledCtx, nil, startInfo(), handler); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	// the retry with the same key re-runs instead of replaying the
+	// stale cancellation
+	resp, err := c.intercept(requestCtx("k"), nil, startInfo(), countingHandler(&calls, "pid", nil))
+	if err != nil || resp != "pid" {
+		t.Fatalf("retry not re-executed: %v %v", resp, err)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("handler ran %d times, want 2", calls.Load())
+	}
+}
+
+func TestGenuineErrorsAreCached(t *testing.T) {
+	c := newCache()
+	var calls atomic.Int32
+	boom := errors.New("boom")
+	handler := countingHandler(&calls, nil, boom)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.intercept(requestCtx("k"), nil, startInfo(), handler); !errors.Is(err, boom) {
+			t.Fatalf("expected cached error, got %v", err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+}
@@ -0,0 +1,176 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+// populate writes the same content tree below dir with deliberately
+// varying timestamps, some newer than the epoch.
+func populate(t *testing.T, dir string, now time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{
+		"a.txt":          "alpha",
+		"b.txt":          "beta",
+		"sub/nested.txt": "nested",
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, now, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func diffDigest(t *testing.T, dir string, epoch time.Time) digest.Digest {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteDiff(context.Background(), &buf, "", dir, WithSourceDateEpoch(&epoch)); err != nil {
+		t.Fatal(err)
+	}
+	return digest.FromBytes(buf.Bytes())
+}
+
+// TestDiffDeterminism shows that diffs of the same content are
+// byte-identical across runs and across different underlying filesystems
+// when a source date epoch is set, with mtimes newer than the epoch
+// clamped.
+func TestDiffDeterminism(t *testing.T) {
+	epoch := time.Unix(1234567890, 0)
+	future := time.Now().Add(time.Hour)
+
+	dirA := filepath.Join(t.TempDir(), "tree")
+	if err := os.Mkdir(dirA, 0755); err != nil {
+		t.Fatal(err)
+	}
+	populate(t, dirA, future)
+
+	d1 := diffDigest(t, dirA, epoch)
+	d2 := diffDigest(t, dirA, epoch)
+	if d1 != d2 {
+		t.Fatalf("diff of unchanged content varied between runs: %s != %s", d1, d2)
+	}
+
+	// same content on a different filesystem (tmpfs) must digest the same
+	if fi, err := os.Stat("/dev/shm"); err == nil && fi.IsDir() && os.Getuid() == 0 {
+		dirB, err := os.MkdirTemp("/dev/shm", "archive-determinism-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dirB)
+		tree := filepath.Join(dirB, "tree")
+		if err := os.Mkdir(tree, 0755); err != nil {
+			t.Fatal(err)
+		}
+		populate(t, tree, future)
+
+		if d3 := diffDigest(t, tree, epoch); d3 != d1 {
+			t.Fatalf("diff digest differs across filesystems: %s != %s", d3, d1)
+		}
+	}
+
+	// every timestamp in the archive is clamped to the epoch
+	var buf bytes.Buffer
+	if err := WriteDiff(context.Background(), &buf, "", dirA, WithSourceDateEpoch(&epoch)); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.ModTime.After(epoch) {
+			t.Fatalf("entry %q mtime %v exceeds the source date epoch", hdr.Name, hdr.ModTime)
+		}
+		if len(hdr.PAXRecords) != 0 {
+			t.Fatalf("entry %q carries pax records %v, breaking determinism", hdr.Name, hdr.PAXRecords)
+		}
+	}
+}
+
+// TestApplyFileWriterHook verifies regular files route through a custom
+// file writer when one is configured.
+func TestApplyFileWriterHook(t *testing.T) {
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "data.txt"), []byte("hooked"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiff(context.Background(), &buf, "", src); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		target = t.TempDir()
+		seen   []string
+	)
+	writer := func(hdr *tar.Header, dst string, r io.Reader) error {
+		seen = append(seen, hdr.Name)
+		f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, r)
+		return err
+	}
+	if _, err := Apply(context.Background(), target, &buf, WithApplyFileWriter(writer)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(seen) != 1 || seen[0] != "data.txt" {
+		t.Fatalf("expected the hook to see data.txt, got %v", seen)
+	}
+	data, err := os.ReadFile(filepath.Join(target, "data.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hooked" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	// metadata still applied by the caller afterwards
+	fi, err := os.Stat(filepath.Join(target, "data.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0644 {
+		t.Fatalf("expected caller-applied mode 0644, got %v", fi.Mode().Perm())
+	}
+}
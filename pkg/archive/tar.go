@@ -302,7 +302,7 @@ func applyNaive(ctx context.Context, root string, r io.Reader, options ApplyOpti
 		srcData := io.Reader(tr)
 		srcHdr := hdr
 
-		if err := createTarFile(ctx, path, root, srcHdr, srcData, options.NoSameOwner); err != nil {
+		if err := createTarFile(ctx, path, root, srcHdr, srcData, options); err != nil {
 			return 0, err
 		}
 
@@ -327,7 +327,8 @@ func applyNaive(ctx context.Context, root string, r io.Reader, options ApplyOpti
 	return size, nil
 }
 
-func createTarFile(ctx context.Context, path, extractDir string, hdr *tar.Header, reader io.Reader, noSameOwner bool) error {
+func createTarFile(ctx context.Context, path, extractDir string, hdr *tar.Header, reader io.Reader, options ApplyOptions) error {
+	noSameOwner := options.NoSameOwner
 	// hdr.Mode is in linux format, which we can use for syscalls,
 	// but for os.Foo() calls we need the mode converted to os.FileMode,
 	// so use hdrInfo.Mode() (they differ for e.g. setuid bits)
@@ -345,6 +346,12 @@ func createTarFile(ctx context.Context, path, extractDir string, hdr *tar.Header
 
 	//nolint:staticcheck // TypeRegA is deprecated but we may still receive an external tar with TypeRegA
 	case tar.TypeReg, tar.TypeRegA:
+		if options.FileWriter != nil {
+			if err := options.FileWriter(hdr, path, reader); err != nil {
+				return err
+			}
+			break
+		}
 		file, err := openFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, hdrInfo.Mode())
 		if err != nil {
 			return err
@@ -419,6 +426,14 @@ func createTarFile(ctx context.Context, path, extractDir string, hdr *tar.Header
 					log.G(ctx).WithError(err).Warnf("ignored xattr %s in archive", key)
 					continue
 				}
+				if options.XattrErrorHandler != nil {
+					if herr := options.XattrErrorHandler(path, key, err); herr == nil {
+						log.G(ctx).WithError(err).Warnf("ignored xattr %s in archive", key)
+						continue
+					} else {
+						err = herr
+					}
+				}
 				return fmt.Errorf("failed to setxattr %q for key %q: %w", path, key, err)
 			}
 		}
@@ -19,7 +19,10 @@ package archive
 import (
 	"archive/tar"
 	"context"
+	"fmt"
 	"io"
+	"path"
+	"strings"
 	"time"
 )
 
@@ -30,9 +33,30 @@ type ApplyOptions struct {
 	Parents         []string        // Parent directories to handle inherited attributes without CoW
 	NoSameOwner     bool            // NoSameOwner will not attempt to preserve the owner specified in the tar archive.
 
+	// XattrErrorHandler is consulted when setting an extended attribute
+	// fails; returning nil skips the attribute instead of failing the
+	// whole apply.
+	XattrErrorHandler XattrErrorHandler
+
+	// FileWriter, when set, materializes regular files instead of the
+	// default buffered write, letting snapshotter specific optimizations
+	// (reflinks, composefs or erofs blobs) intercept file creation.
+	FileWriter FileWriter
+
 	applyFunc func(context.Context, string, io.Reader, ApplyOptions) (int64, error)
 }
 
+// XattrErrorHandler decides what to do when setting the xattr key on path
+// failed with err; returning nil downgrades the failure to a skip.
+type XattrErrorHandler func(path, key string, err error) error
+
+// FileWriter materializes the regular file described by hdr at dst from r,
+// which delivers exactly hdr.Size bytes. Implementations may clone or
+// reflink the data from elsewhere as long as dst ends up with the exact
+// content; permissions, ownership, xattrs and timestamps are applied by
+// the caller afterwards.
+type FileWriter func(hdr *tar.Header, dst string, r io.Reader) error
+
 // ApplyOpt allows setting mutable archive apply properties on creation
 type ApplyOpt func(options *ApplyOptions) error
 
@@ -47,6 +71,106 @@ func all(_ *tar.Header) (bool, error) {
 	return true, nil
 }
 
+// IDMap maps a contiguous range of container ids to host ids, matching
+// the runtime spec's LinuxIDMapping semantics.
+type IDMap struct {
+	ContainerID uint32
+	HostID      uint32
+	Size        uint32
+}
+
+// remapID translates a container id to a host id through the mappings.
+func remapID(id int, mappings []IDMap) (int, error) {
+	if len(mappings) == 0 {
+		return id, nil
+	}
+	for _, m := range mappings {
+		if uint32(id) >= m.ContainerID && uint32(id) < m.ContainerID+m.Size {
+			return int(m.HostID + (uint32(id) - m.ContainerID)), nil
+		}
+	}
+	return 0, fmt.Errorf("id %d has no mapping", id)
+}
+
+// WithRemapIDs rewrites the ownership of extracted entries through the
+// given uid/gid mappings, the explicit fallback for user namespaced
+// containers on systems without idmapped mount support. Targets mounted
+// with idmapped mounts should not combine both, the kernel already maps
+// ownership there.
+func WithRemapIDs(uidMaps, gidMaps []IDMap) ApplyOpt {
+	return func(options *ApplyOptions) error {
+		previous := options.Filter
+		options.Filter = func(hdr *tar.Header) (bool, error) {
+			uid, err := remapID(hdr.Uid, uidMaps)
+			if err != nil {
+				return false, fmt.Errorf("cannot remap uid of %q: %w", hdr.Name, err)
+			}
+			gid, err := remapID(hdr.Gid, gidMaps)
+			if err != nil {
+				return false, fmt.Errorf("cannot remap gid of %q: %w", hdr.Name, err)
+			}
+			hdr.Uid, hdr.Gid = uid, gid
+			if previous != nil {
+				return previous(hdr)
+			}
+			return true, nil
+		}
+		return nil
+	}
+}
+
+// WithApplyFileWriter materializes regular files through the given writer
+// instead of the default buffered copy, so filesystems with cheaper paths
+// (copy_file_range or FICLONERANGE from a content-store-backed source,
+// composefs and erofs blob formats) can intercept file creation without
+// forking the archive package.
+func WithApplyFileWriter(w FileWriter) ApplyOpt {
+	return func(options *ApplyOptions) error {
+		options.FileWriter = w
+		return nil
+	}
+}
+
+// WithXattrErrorHandler lets the handler decide whether a failed xattr
+// write fails the apply, so layers can be extracted onto filesystems
+// rejecting certain attributes (NFS without security.capability, hosts
+// without SELinux) by downgrading those failures to warnings.
+func WithXattrErrorHandler(handler XattrErrorHandler) ApplyOpt {
+	return func(options *ApplyOptions) error {
+		options.XattrErrorHandler = handler
+		return nil
+	}
+}
+
+// WithExcludePatterns skips archive entries matching any of the given
+// path.Match patterns (evaluated against the slash separated, rootless
+// entry name), for example to drop usr/share/doc/* on space constrained
+// devices.
+func WithExcludePatterns(patterns []string) ApplyOpt {
+	return func(options *ApplyOptions) error {
+		// validate up front so a bad pattern fails the apply immediately
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid exclude pattern %q: %w", p, err)
+			}
+		}
+		previous := options.Filter
+		options.Filter = func(hdr *tar.Header) (bool, error) {
+			name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+			for _, p := range patterns {
+				if ok, _ := path.Match(p, name); ok {
+					return false, nil
+				}
+			}
+			if previous != nil {
+				return previous(hdr)
+			}
+			return true, nil
+		}
+		return nil
+	}
+}
+
 // WithFilter uses the filter to select which files are to be extracted.
 func WithFilter(f Filter) ApplyOpt {
 	return func(options *ApplyOptions) error {
@@ -100,6 +100,10 @@ type Streams struct {
 	Stderr   io.Writer
 	Terminal bool
 	FIFODir  string
+
+	// reconnect tracks the wrappers installed by WithAutoReconnectIO so
+	// the resulting IO can implement ReconnectableIO.
+	reconnect []*reconnectWriter
 }
 
 // Opt customize options for creating a Creator or Attach
@@ -189,10 +193,11 @@ func NullIO(_ string) (IO, error) {
 
 // cio is a basic container IO implementation.
 type cio struct {
-	config  Config
-	wg      *sync.WaitGroup
-	closers []io.Closer
-	cancel  context.CancelFunc
+	config    Config
+	wg        *sync.WaitGroup
+	closers   []io.Closer
+	cancel    context.CancelFunc
+	reconnect []*reconnectWriter
 }
 
 func (c *cio) Config() Config {
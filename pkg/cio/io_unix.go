@@ -92,9 +92,10 @@ func copyIO(fifos *FIFOSet, ioset *Streams) (*cio, error) {
 		})
 	}
 	return &cio{
-		config:  fifos.Config,
-		wg:      wg,
-		closers: append(pipes.closers(), fifos),
+		config:    fifos.Config,
+		wg:        wg,
+		reconnect: ioset.reconnect,
+		closers:   append(pipes.closers(), fifos),
 		cancel: func() {
 			cancel()
 			for _, c := range pipes.closers() {
@@ -43,7 +43,7 @@ func NewFIFOSetInDir(_, id string, terminal bool) (*FIFOSet, error) {
 }
 
 func copyIO(fifos *FIFOSet, ioset *Streams) (_ *cio, retErr error) {
-	cios := &cio{config: fifos.Config}
+	cios := &cio{config: fifos.Config, reconnect: ioset.reconnect}
 
 	defer func() {
 		if retErr != nil {
@@ -0,0 +1,228 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cio
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	metrics "github.com/docker/go-metrics"
+	"golang.org/x/time/rate"
+)
+
+// defaultRateLimitBuffer is how many bytes over the rate limit are
+// buffered in drop mode before output is discarded.
+const defaultRateLimitBuffer = 1 << 20
+
+var (
+	droppedBytesCounter metrics.Counter
+	rateMetricOnce      sync.Once
+)
+
+// registerRateLimitMetrics exports the dropped bytes counter on the
+// prometheus default registry (the daemon's metrics endpoint when used in
+// process), deferred until the limiter is first used.
+func registerRateLimitMetrics() {
+	rateMetricOnce.Do(func() {
+		ns := metrics.NewNamespace("containerd", "cio", nil)
+		droppedBytesCounter = ns.NewCounter("output_dropped_bytes", "container output bytes dropped by rate limiting")
+		metrics.Register(ns)
+	})
+}
+
+// RateLimitOpt adjusts WithOutputRateLimit's behavior.
+type RateLimitOpt func(*rateLimitConfig)
+
+type rateLimitConfig struct {
+	drop      bool
+	maxBuffer int
+}
+
+// WithDropOnLimit switches the limiter from blocking the stream to
+// buffering up to the configured maximum and dropping whatever exceeds
+// it, so a flooding container loses output instead of back-pressuring.
+func WithDropOnLimit(drop bool) RateLimitOpt {
+	return func(c *rateLimitConfig) {
+		c.drop = drop
+	}
+}
+
+// WithRateLimitBuffer sets how many bytes over the limit are buffered in
+// drop mode before output is discarded (1 MiB by default).
+func WithRateLimitBuffer(n int) RateLimitOpt {
+	return func(c *rateLimitConfig) {
+		if n > 0 {
+			c.maxBuffer = n
+		}
+	}
+}
+
+// WithOutputRateLimit throttles the task's stdout and stderr to
+// bytesPerSec each, so one container flooding its output cannot starve
+// other streams. By default writers over the limit block; WithDropOnLimit
+// buffers up to the configured maximum and then drops, counting the
+// discarded bytes in the wrapper's Dropped and the
+// containerd_cio_output_dropped_bytes metric. Apply after the option
+// setting the streams. A non-positive rate leaves the streams
+// unlimited.
+func WithOutputRateLimit(bytesPerSec int64, opts ...RateLimitOpt) Opt {
+	config := rateLimitConfig{maxBuffer: defaultRateLimitBuffer}
+	for _, o := range opts {
+		o(&config)
+	}
+	return func(s *Streams) {
+		if bytesPerSec <= 0 {
+			return
+		}
+		if s.Stdout != nil {
+			s.Stdout = newRateLimitedWriter(s.Stdout, bytesPerSec, config)
+		}
+		if s.Stderr != nil {
+			s.Stderr = newRateLimitedWriter(s.Stderr, bytesPerSec, config)
+		}
+	}
+}
+
+// RateLimitedWriter throttles writes to its stream; each stream gets its
+// own limiter.
+type RateLimitedWriter struct {
+	w       io.Writer
+	limiter *rate.Limiter
+	drop    bool
+
+	mu       sync.Mutex
+	buffered int
+	max      int
+	queue    chan []byte
+	dropped  atomic.Int64
+	closed   atomic.Bool
+	once     sync.Once
+}
+
+func newRateLimitedWriter(w io.Writer, bytesPerSec int64, config rateLimitConfig) *RateLimitedWriter {
+	registerRateLimitMetrics()
+	rl := &RateLimitedWriter{
+		w:       w,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSec), int(bytesPerSec)),
+		drop:    config.drop,
+		max:     config.maxBuffer,
+	}
+	if rl.drop {
+		rl.queue = make(chan []byte, 256)
+		go rl.drain()
+	}
+	return rl
+}
+
+// Dropped reports how many bytes were discarded because the stream stayed
+// over its limit with a full buffer.
+func (rl *RateLimitedWriter) Dropped() int64 {
+	return rl.dropped.Load()
+}
+
+// Close stops the drop-mode drain goroutine; buffered chunks still in the
+// queue are delivered before it exits. Writes after Close are counted as
+// dropped.
+func (rl *RateLimitedWriter) Close() error {
+	rl.once.Do(func() {
+		rl.closed.Store(true)
+		if rl.queue != nil {
+			close(rl.queue)
+		}
+	})
+	return nil
+}
+
+func (rl *RateLimitedWriter) Write(p []byte) (int, error) {
+	if !rl.drop {
+		// block the stream until the limiter admits the bytes
+		for off := 0; off < len(p); {
+			chunk := len(p) - off
+			if burst := rl.limiter.Burst(); chunk > burst {
+				chunk = burst
+			}
+			if err := rl.limiter.WaitN(context.Background(), chunk); err != nil {
+				return off, err
+			}
+			n, err := rl.w.Write(p[off : off+chunk])
+			off += n
+			if err != nil {
+				return off, err
+			}
+		}
+		return len(p), nil
+	}
+
+	// drop mode: queue within budget, discard the rest
+	if rl.closed.Load() {
+		rl.dropped.Add(int64(len(p)))
+		droppedBytesCounter.Inc(float64(len(p)))
+		return len(p), nil
+	}
+	rl.mu.Lock()
+	over := rl.buffered+len(p) > rl.max
+	if !over {
+		rl.buffered += len(p)
+	}
+	rl.mu.Unlock()
+	if over {
+		rl.dropped.Add(int64(len(p)))
+		droppedBytesCounter.Inc(float64(len(p)))
+		return len(p), nil
+	}
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+	select {
+	case rl.queue <- chunk:
+	default:
+		rl.mu.Lock()
+		rl.buffered -= len(p)
+		rl.mu.Unlock()
+		rl.dropped.Add(int64(len(p)))
+		droppedBytesCounter.Inc(float64(len(p)))
+	}
+	return len(p), nil
+}
+
+// drain forwards queued chunks at the configured rate. A failing
+// destination only drops the affected chunk; the drain keeps running so
+// output resumes if the destination recovers.
+func (rl *RateLimitedWriter) drain() {
+	for chunk := range rl.queue {
+		for off := 0; off < len(chunk); {
+			n := len(chunk) - off
+			if burst := rl.limiter.Burst(); n > burst {
+				n = burst
+			}
+			if err := rl.limiter.WaitN(context.Background(), n); err != nil {
+				break
+			}
+			written, err := rl.w.Write(chunk[off : off+n])
+			off += written
+			if err != nil {
+				rl.dropped.Add(int64(len(chunk) - off))
+				droppedBytesCounter.Inc(float64(len(chunk) - off))
+				break
+			}
+		}
+		rl.mu.Lock()
+		rl.buffered -= len(chunk)
+		rl.mu.Unlock()
+	}
+}
@@ -0,0 +1,62 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestOutputRateLimitBlocks(t *testing.T) {
+	var out bytes.Buffer
+	s := Streams{Stdout: &out, Stderr: &out}
+	WithOutputRateLimit(1024)(&s)
+
+	start := time.Now()
+	// ~2KiB at 1KiB/s must take at least around a second
+	if _, err := s.Stdout.Write(make([]byte, 2048)); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Fatalf("write finished too fast for the limit: %v", elapsed)
+	}
+	if out.Len() != 2048 {
+		t.Fatalf("expected all bytes written, got %d", out.Len())
+	}
+}
+
+func TestOutputRateLimitDrops(t *testing.T) {
+	var out bytes.Buffer
+	s := Streams{Stdout: &out}
+	WithOutputRateLimit(1024, WithDropOnLimit(true), WithRateLimitBuffer(1024))(&s)
+
+	w := s.Stdout.(*RateLimitedWriter)
+	// flood far over the buffer; writes must return immediately
+	start := time.Now()
+	for i := 0; i < 64; i++ {
+		if _, err := w.Write(make([]byte, 1024)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("drop mode blocked the writer for %v", elapsed)
+	}
+	if w.Dropped() == 0 {
+		t.Fatal("expected dropped bytes under flood")
+	}
+}
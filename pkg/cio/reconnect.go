@@ -0,0 +1,135 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cio
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/containerd/log"
+)
+
+// ReconnectableIO is implemented by the IO returned from a Creator or
+// Attach built with WithAutoReconnectIO; Reconnect injects replacement
+// writers for the streams after the original destinations failed.
+type ReconnectableIO interface {
+	IO
+
+	// Reconnect replaces the stdout and stderr destinations. A nil
+	// writer leaves that stream's destination unchanged.
+	Reconnect(stdout, stderr io.Writer) error
+}
+
+// WithAutoReconnectIO keeps the task's output flowing when the stdout or
+// stderr destination fails (EPIPE, closed connection): the failed stream
+// is drained and discarded so the container never blocks on a full FIFO,
+// and the destination is retried every backoff interval. The gap is
+// logged with its duration and the bytes lost. Callers can also inject a
+// fresh destination through the IO's ReconnectableIO interface. Apply
+// after the option setting the streams.
+func WithAutoReconnectIO(backoff time.Duration) Opt {
+	return func(s *Streams) {
+		if s.Stdout != nil {
+			w := &reconnectWriter{dst: s.Stdout, backoff: backoff, name: "stdout"}
+			s.Stdout = w
+			s.reconnect = append(s.reconnect, w)
+		}
+		if s.Stderr != nil {
+			w := &reconnectWriter{dst: s.Stderr, backoff: backoff, name: "stderr"}
+			s.Stderr = w
+			s.reconnect = append(s.reconnect, w)
+		}
+	}
+}
+
+// Reconnect implements ReconnectableIO.
+func (c *cio) Reconnect(stdout, stderr io.Writer) error {
+	if len(c.reconnect) == 0 {
+		return errors.New("IO was not created with WithAutoReconnectIO")
+	}
+	for _, w := range c.reconnect {
+		switch w.name {
+		case "stdout":
+			w.setWriter(stdout)
+		case "stderr":
+			w.setWriter(stderr)
+		}
+	}
+	return nil
+}
+
+// reconnectWriter forwards to its destination until a write fails, then
+// discards output (counting the loss) while probing the destination each
+// backoff interval. Writes never return an error so the FIFO copy, and
+// with it the container, keeps running.
+type reconnectWriter struct {
+	mu      sync.Mutex
+	dst     io.Writer
+	backoff time.Duration
+	name    string
+
+	downSince time.Time
+	nextTry   time.Time
+	lost      int64
+}
+
+// setWriter injects a replacement destination; if the stream was down it
+// is retried on the next write.
+func (w *reconnectWriter) setWriter(dst io.Writer) {
+	if dst == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dst = dst
+	w.nextTry = time.Time{}
+}
+
+func (w *reconnectWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.downSince.IsZero() {
+		_, err := w.dst.Write(p)
+		if err == nil {
+			return len(p), nil
+		}
+		w.downSince = time.Now()
+		w.nextTry = w.downSince.Add(w.backoff)
+		w.lost = int64(len(p))
+		log.L.WithField("stream", w.name).WithError(err).Warn("IO destination disconnected, draining output")
+		return len(p), nil
+	}
+
+	// down: probe the destination once per backoff interval
+	if time.Now().After(w.nextTry) {
+		if _, err := w.dst.Write(p); err == nil {
+			log.L.WithField("stream", w.name).
+				WithField("gap", time.Since(w.downSince).String()).
+				WithField("bytes-lost", w.lost).
+				Info("IO destination reconnected")
+			w.downSince = time.Time{}
+			w.lost = 0
+			return len(p), nil
+		}
+		w.nextTry = time.Now().Add(w.backoff)
+	}
+	w.lost += int64(len(p))
+	return len(p), nil
+}
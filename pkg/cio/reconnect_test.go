@@ -0,0 +1,104 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakyWriter struct {
+	bytes.Buffer
+	broken bool
+}
+
+func (f *flakyWriter) Write(p []byte) (int, error) {
+	if f.broken {
+		return 0, errors.New("write: broken pipe")
+	}
+	return f.Buffer.Write(p)
+}
+
+func TestAutoReconnectIO(t *testing.T) {
+	fw := &flakyWriter{}
+	s := Streams{Stdout: fw}
+	WithAutoReconnectIO(50 * time.Millisecond)(&s)
+
+	// healthy path
+	if _, err := s.Stdout.Write([]byte("one")); err != nil {
+		t.Fatal(err)
+	}
+
+	// destination breaks: writes must keep succeeding so the fifo copy
+	// never stalls
+	fw.broken = true
+	for i := 0; i < 3; i++ {
+		if _, err := s.Stdout.Write([]byte("lost")); err != nil {
+			t.Fatalf("write returned error while destination down: %v", err)
+		}
+	}
+	w := s.reconnect[0]
+	w.mu.Lock()
+	lost := w.lost
+	w.mu.Unlock()
+	if lost == 0 {
+		t.Fatal("expected lost bytes to be counted")
+	}
+
+	// destination recovers after the backoff elapses
+	fw.broken = false
+	time.Sleep(60 * time.Millisecond)
+	if _, err := s.Stdout.Write([]byte("two")); err != nil {
+		t.Fatal(err)
+	}
+	if got := fw.String(); got != "onetwo" {
+		t.Fatalf("unexpected destination content %q", got)
+	}
+}
+
+func TestReconnectInjectsWriter(t *testing.T) {
+	fw := &flakyWriter{broken: true}
+	s := Streams{Stdout: fw}
+	WithAutoReconnectIO(time.Hour)(&s)
+
+	s.Stdout.Write([]byte("gone"))
+
+	c := &cio{reconnect: s.reconnect}
+	var io2 IO = c
+	r, ok := io2.(ReconnectableIO)
+	if !ok {
+		t.Fatal("cio does not implement ReconnectableIO")
+	}
+	var replacement bytes.Buffer
+	if err := r.Reconnect(&replacement, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Stdout.Write([]byte("back")); err != nil {
+		t.Fatal(err)
+	}
+	if replacement.String() != "back" {
+		t.Fatalf("unexpected replacement content %q", replacement.String())
+	}
+}
+
+func TestReconnectWithoutOption(t *testing.T) {
+	if err := (&cio{}).Reconnect(&bytes.Buffer{}, nil); err == nil {
+		t.Fatal("expected an error for IO without WithAutoReconnectIO")
+	}
+}
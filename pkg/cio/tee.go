@@ -0,0 +1,112 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cio
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// teeBuffer bounds how many pending chunks the tee keeps per stream
+// before dropping.
+const teeBuffer = 64
+
+// Tee mirrors a task's stdout and stderr to a secondary writer without
+// touching the primary destinations, for capturing the output of a
+// misbehaving container as it runs. The mirror never back-pressures the
+// container: chunks the secondary writer cannot keep up with are dropped
+// and counted instead.
+type Tee struct {
+	ch      chan []byte
+	dropped atomic.Int64
+	once    sync.Once
+	done    chan struct{}
+}
+
+// NewTee returns a tee mirroring output to secondary; attach it to a
+// task's IO with WithTeeIO.
+func NewTee(secondary io.Writer) *Tee {
+	t := &Tee{
+		ch:   make(chan []byte, teeBuffer),
+		done: make(chan struct{}),
+	}
+	go func() {
+		defer close(t.done)
+		for chunk := range t.ch {
+			if _, err := secondary.Write(chunk); err != nil {
+				// a broken secondary degrades into counting drops
+				t.dropped.Add(1)
+			}
+		}
+	}()
+	return t
+}
+
+// Dropped reports how many chunks were dropped because the secondary
+// writer could not keep up or failed.
+func (t *Tee) Dropped() int64 {
+	return t.dropped.Load()
+}
+
+// Close stops the mirror after draining buffered chunks. The task's
+// primary IO is unaffected.
+func (t *Tee) Close() error {
+	t.once.Do(func() {
+		close(t.ch)
+	})
+	<-t.done
+	return nil
+}
+
+// write queues a copy of p for the secondary writer without blocking.
+func (t *Tee) write(p []byte) {
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+	select {
+	case t.ch <- chunk:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+type teeWriter struct {
+	primary io.Writer
+	tee     *Tee
+}
+
+func (w teeWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.tee.write(p)
+	}
+	return w.primary.Write(p)
+}
+
+// WithTeeIO mirrors the task's stdout and stderr to the tee's secondary
+// writer in addition to the primary destinations. Apply it after the
+// option setting the streams (e.g. WithStreams); streams that are not set
+// are left untouched.
+func WithTeeIO(tee *Tee) Opt {
+	return func(opt *Streams) {
+		if opt.Stdout != nil {
+			opt.Stdout = teeWriter{primary: opt.Stdout, tee: tee}
+		}
+		if opt.Stderr != nil {
+			opt.Stderr = teeWriter{primary: opt.Stderr, tee: tee}
+		}
+	}
+}
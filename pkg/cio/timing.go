@@ -0,0 +1,61 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cio
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// FirstByteTimer records when the first byte of task output passes through
+// the writers it wraps, used to measure how long a task takes from start
+// to producing output.
+type FirstByteTimer struct {
+	at atomic.Int64 // unix nanoseconds, zero until the first byte
+}
+
+// Wrap returns a writer recording the arrival of the first byte before
+// forwarding to w. Wrapping nil returns nil, so optional streams can be
+// passed through unconditionally.
+func (t *FirstByteTimer) Wrap(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+	return &timedWriter{timer: t, w: w}
+}
+
+// FirstByteAt reports when the first byte of output was seen, zero while
+// the task has not produced any output.
+func (t *FirstByteTimer) FirstByteAt() time.Time {
+	if ns := t.at.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return time.Time{}
+}
+
+type timedWriter struct {
+	timer *FirstByteTimer
+	w     io.Writer
+}
+
+func (tw *timedWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		tw.timer.at.CompareAndSwap(0, time.Now().UnixNano())
+	}
+	return tw.w.Write(p)
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package exitinfo defines the enriched task exit event the runc shim
+// publishes alongside TaskExit, carrying the exit reason and a final
+// resource snapshot collected just before the cgroup is reaped. Shims
+// that do not publish it simply leave consumers with the plain TaskExit.
+package exitinfo
+
+import "github.com/containerd/typeurl/v2"
+
+func init() {
+	typeurl.Register(&ExitInfo{}, "io.containerd.event.v1.TaskExitInfo")
+}
+
+// Topic is the additive topic ExitInfo is published on.
+const Topic = "/tasks/exit-info"
+
+// ExitInfo enriches a TaskExit with why the process died and what it
+// consumed, turning post-mortem debugging into a single event read.
+type ExitInfo struct {
+	// ContainerID of the exited task.
+	ContainerID string `json:"container_id"`
+	// ID of the process; equals ContainerID for the init process.
+	ID string `json:"id"`
+	// Pid of the exited process.
+	Pid uint32 `json:"pid"`
+	// ExitStatus mirrors the TaskExit status.
+	ExitStatus uint32 `json:"exit_status"`
+	// Signal names the terminating signal, derived from the 128+n exit
+	// status convention; empty for plain exits.
+	Signal string `json:"signal,omitempty"`
+	// OOMKilled reports whether the cgroup recorded an OOM kill.
+	OOMKilled bool `json:"oom_killed,omitempty"`
+	// MemoryPeakBytes is the cgroup's peak memory usage; zero when the
+	// kernel does not expose it.
+	MemoryPeakBytes uint64 `json:"memory_peak_bytes,omitempty"`
+	// CPUTotalUsec is the total CPU time consumed, in microseconds.
+	CPUTotalUsec uint64 `json:"cpu_total_usec,omitempty"`
+}
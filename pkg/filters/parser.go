@@ -19,6 +19,7 @@ package filters
 import (
 	"fmt"
 	"io"
+	"regexp"
 
 	"github.com/containerd/errdefs"
 )
@@ -160,11 +161,23 @@ func (p *parser) selector() (selector, error) {
 		return selector{}, err
 	}
 
-	return selector{
+	s := selector{
 		fieldpath: fieldpath,
 		value:     value,
 		operator:  op,
-	}, nil
+	}
+
+	if op == operatorMatches {
+		// compile eagerly so an invalid pattern fails the parse instead
+		// of silently matching nothing later
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return selector{}, fmt.Errorf("invalid regular expression %q: %w", value, err)
+		}
+		s.re = re
+	}
+
+	return s, nil
 }
 
 func (p *parser) fieldpath() ([]string, error) {
@@ -0,0 +1,57 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package forensics defines the contract of container forensics mode:
+// containers annotated for it have their cgroup frozen by the shim when
+// the task exits non-zero, keeping remaining processes inspectable until
+// the window elapses or the task is deleted.
+package forensics
+
+import (
+	"time"
+
+	"github.com/containerd/typeurl/v2"
+)
+
+const (
+	// ModeAnnotation enables forensics mode when set to "true" on the
+	// container's spec.
+	ModeAnnotation = "io.containerd.forensics.mode"
+
+	// WindowAnnotation overrides how long the crashed container stays
+	// frozen, as a Go duration string.
+	WindowAnnotation = "io.containerd.forensics.window"
+
+	// ReadyEventTopic carries Ready events when a crashed container has
+	// been frozen for analysis.
+	ReadyEventTopic = "/tasks/forensics-ready"
+
+	// DefaultWindow is how long a crashed container stays frozen when no
+	// window annotation is set.
+	DefaultWindow = 5 * time.Minute
+)
+
+// Ready is published when a crashed container has been frozen and can be
+// inspected, for example by attaching a debugger through Task.Exec.
+type Ready struct {
+	ContainerID string `json:"container_id"`
+	ExitStatus  uint32 `json:"exit_status"`
+	Window      string `json:"window"`
+}
+
+func init() {
+	typeurl.Register(&Ready{}, "github.com/containerd/containerd/v2/pkg/forensics", "Ready")
+}
@@ -32,6 +32,11 @@ const CRIContainerdPrefix = "io.cri-containerd"
 // The value is digest of the uncompressed content.
 const LabelUncompressed = "containerd.io/uncompressed"
 
+// LabelMediaType is added to content at commit time when the media type of
+// the committed blob is known, so walks can filter on media type without
+// resolving it from manifests.
+const LabelMediaType = "containerd.io/content.mediatype"
+
 // LabelSharedNamespace is added to a namespace to allow that namespaces
 // contents to be shared.
 const LabelSharedNamespace = "containerd.io/namespace.shareable"
@@ -0,0 +1,145 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// SlirpNetworkAnnotation marks a spec whose network namespace is expected
+// to be wired up by slirp4netns after the task starts.
+const SlirpNetworkAnnotation = "containerd.io/network/slirp4netns"
+
+// IsRootless reports whether the current process runs without root
+// privileges.
+func IsRootless() bool {
+	return os.Getuid() != 0
+}
+
+// WithRootless applies the spec changes rootless operation needs in one
+// opt: a user namespace mapped from the current user's subordinate ID
+// ranges, a user-writable rootfs path inside the bundle, mounts that do
+// not require root (binding /sys instead of mounting sysfs, dropping
+// writable kernel sysctls and the cgroup filesystem), a delegated cgroup
+// path, and a fresh network namespace annotated for slirp4netns.
+func WithRootless() SpecOpts {
+	return Compose(
+		withRootlessUserNamespace(),
+		withRootlessRoot(),
+		withRootlessMounts(),
+		withRootlessCgroups(),
+		withRootlessNetwork(),
+	)
+}
+
+// withRootlessUserNamespace maps the container onto the current user's
+// subordinate ID ranges.
+func withRootlessUserNamespace() SpecOpts {
+	return func(ctx context.Context, client Client, c *containers.Container, s *Spec) error {
+		u, err := user.Current()
+		if err != nil {
+			return err
+		}
+		return WithSubUIDMapping(u.Username)(ctx, client, c, s)
+	}
+}
+
+// withRootlessRoot points the rootfs into the bundle, which the invoking
+// user owns, when no rootfs has been chosen yet.
+func withRootlessRoot() SpecOpts {
+	return func(_ context.Context, _ Client, _ *containers.Container, s *Spec) error {
+		if s.Root == nil {
+			s.Root = &specs.Root{}
+		}
+		if s.Root.Path == "" {
+			s.Root.Path = "rootfs"
+		}
+		return nil
+	}
+}
+
+// withRootlessMounts rewrites the mounts an unprivileged user cannot
+// perform: sysfs and cgroupfs become read-only binds from the host, and
+// writable kernel sysctl mounts are dropped.
+func withRootlessMounts() SpecOpts {
+	return func(_ context.Context, _ Client, _ *containers.Container, s *Spec) error {
+		setLinux(s)
+		var mounts []specs.Mount
+		for _, m := range s.Mounts {
+			switch {
+			case m.Type == "sysfs" || m.Destination == "/sys":
+				// mounting sysfs requires owning the network namespace;
+				// bind the host's view instead
+				mounts = append(mounts, specs.Mount{
+					Destination: "/sys",
+					Type:        "none",
+					Source:      "/sys",
+					Options:     []string{"rbind", "nosuid", "noexec", "nodev", "ro"},
+				})
+			case m.Type == "cgroup":
+				mounts = append(mounts, specs.Mount{
+					Destination: m.Destination,
+					Type:        "none",
+					Source:      "/sys/fs/cgroup",
+					Options:     []string{"rbind", "nosuid", "noexec", "nodev", "ro"},
+				})
+			case strings.HasPrefix(m.Destination, "/proc/sys/kernel"):
+				// requires root to mount over
+			default:
+				mounts = append(mounts, m)
+			}
+		}
+		s.Mounts = mounts
+		return nil
+	}
+}
+
+// withRootlessCgroups clears the cgroups path so the runtime falls back
+// to the delegated hierarchy the user session owns.
+func withRootlessCgroups() SpecOpts {
+	return func(_ context.Context, _ Client, _ *containers.Container, s *Spec) error {
+		setLinux(s)
+		s.Linux.CgroupsPath = ""
+		return nil
+	}
+}
+
+// withRootlessNetwork gives the container a fresh network namespace and
+// marks the spec for slirp4netns to attach user-mode networking after
+// start.
+func withRootlessNetwork() SpecOpts {
+	return func(_ context.Context, _ Client, _ *containers.Container, s *Spec) error {
+		setLinux(s)
+		for i, ns := range s.Linux.Namespaces {
+			if ns.Type == specs.NetworkNamespace {
+				// a path would point at a namespace the user may not own
+				s.Linux.Namespaces[i].Path = ""
+			}
+		}
+		if s.Annotations == nil {
+			s.Annotations = map[string]string{}
+		}
+		s.Annotations[SlirpNetworkAnnotation] = "true"
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func TestWithRootless(t *testing.T) {
+	u, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	subuid := filepath.Join(dir, "subuid")
+	subgid := filepath.Join(dir, "subgid")
+	content := fmt.Sprintf("%s:100000:65536\n", u.Username)
+	if err := os.WriteFile(subuid, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(subgid, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	origUID, origGID := subuidPath, subgidPath
+	subuidPath, subgidPath = subuid, subgid
+	defer func() {
+		subuidPath, subgidPath = origUID, origGID
+	}()
+
+	s := Spec{
+		Linux: &specs.Linux{
+			CgroupsPath: "/kubepods/something",
+			Namespaces:  []specs.LinuxNamespace{{Type: specs.NetworkNamespace, Path: "/proc/1/ns/net"}},
+		},
+		Mounts: []specs.Mount{
+			{Destination: "/proc", Type: "proc", Source: "proc"},
+			{Destination: "/sys", Type: "sysfs", Source: "sysfs"},
+			{Destination: "/sys/fs/cgroup", Type: "cgroup", Source: "cgroup"},
+			{Destination: "/proc/sys/kernel/shmmax", Type: "bind", Source: "/dev/null"},
+		},
+	}
+	if err := WithRootless()(context.Background(), nil, nil, &s); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Linux.UIDMappings) != 1 || s.Linux.UIDMappings[0].HostID != 100000 {
+		t.Fatalf("subuid mapping not applied: %v", s.Linux.UIDMappings)
+	}
+	if s.Root == nil || s.Root.Path != "rootfs" {
+		t.Fatalf("rootfs not defaulted into the bundle: %v", s.Root)
+	}
+	if s.Linux.CgroupsPath != "" {
+		t.Fatalf("cgroups path not delegated: %q", s.Linux.CgroupsPath)
+	}
+	if s.Annotations[SlirpNetworkAnnotation] != "true" {
+		t.Fatal("slirp4netns annotation missing")
+	}
+
+	for _, ns := range s.Linux.Namespaces {
+		if ns.Type == specs.NetworkNamespace && ns.Path != "" {
+			t.Fatalf("network namespace still points at %q", ns.Path)
+		}
+	}
+
+	var sawProc, sawSys bool
+	for _, m := range s.Mounts {
+		switch {
+		case m.Destination == "/proc":
+			sawProc = true
+		case m.Destination == "/sys":
+			sawSys = true
+			if m.Type != "none" || m.Source != "/sys" {
+				t.Fatalf("/sys not rebound from the host: %+v", m)
+			}
+		case m.Destination == "/sys/fs/cgroup":
+			if m.Type != "none" || m.Source != "/sys/fs/cgroup" {
+				t.Fatalf("cgroup mount not rebound: %+v", m)
+			}
+		case strings.HasPrefix(m.Destination, "/proc/sys/kernel"):
+			t.Fatalf("root-only mount survived: %+v", m)
+		}
+	}
+	if !sawProc || !sawSys {
+		t.Fatalf("expected /proc and /sys mounts, got %v", s.Mounts)
+	}
+}
+
+func TestIsRootless(t *testing.T) {
+	if IsRootless() != (os.Getuid() != 0) {
+		t.Fatal("IsRootless disagrees with the uid")
+	}
+}
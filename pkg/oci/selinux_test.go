@@ -0,0 +1,84 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withEnforceState points SELinuxEnabled at a fake enforce file.
+func withEnforceState(t *testing.T, state string) {
+	t.Helper()
+	orig := selinuxEnforcePath
+	if state == "" {
+		selinuxEnforcePath = filepath.Join(t.TempDir(), "missing")
+	} else {
+		p := filepath.Join(t.TempDir(), "enforce")
+		if err := os.WriteFile(p, []byte(state), 0644); err != nil {
+			t.Fatal(err)
+		}
+		selinuxEnforcePath = p
+	}
+	t.Cleanup(func() {
+		selinuxEnforcePath = orig
+	})
+}
+
+func TestWithSELinuxLabel(t *testing.T) {
+	withEnforceState(t, "1")
+
+	const label = "system_u:system_r:container_t:s0-s0:c0.c1023"
+	s := Spec{}
+	if err := WithSELinuxLabel(label)(context.Background(), nil, nil, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Process.SelinuxLabel != label {
+		t.Fatalf("process label not set: %q", s.Process.SelinuxLabel)
+	}
+	if err := WithSELinuxMountLabel(label)(context.Background(), nil, nil, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Linux.MountLabel != label {
+		t.Fatalf("mount label not set: %q", s.Linux.MountLabel)
+	}
+
+	for _, bad := range []string{"", "container_t", "u:r:t", "u::t:s0", ":r:t:s0"} {
+		if err := WithSELinuxLabel(bad)(context.Background(), nil, nil, &Spec{}); err == nil {
+			t.Fatalf("label %q accepted", bad)
+		}
+	}
+}
+
+func TestWithSELinuxLabelDisabled(t *testing.T) {
+	for _, state := range []string{"", "0"} {
+		withEnforceState(t, state)
+		if SELinuxEnabled() {
+			t.Fatal("expected SELinux to report disabled")
+		}
+		s := Spec{}
+		// even an invalid label is a no-op off SELinux
+		if err := WithSELinuxLabel("not-a-label")(context.Background(), nil, nil, &s); err != nil {
+			t.Fatal(err)
+		}
+		if s.Process != nil && s.Process.SelinuxLabel != "" {
+			t.Fatal("label set on non-SELinux host")
+		}
+	}
+}
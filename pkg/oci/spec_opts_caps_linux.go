@@ -0,0 +1,82 @@
+//go:build linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/containerd/errdefs"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	"github.com/containerd/containerd/v2/pkg/cap"
+)
+
+// validateCapabilities checks every name against the known capability
+// list, accepting both CAP_SYS_ADMIN and sys_admin spellings and
+// returning the canonical form.
+func validateCapabilities(names []string) ([]string, error) {
+	known := cap.Known()
+	canonical := make([]string, 0, len(names))
+	for _, name := range names {
+		c := strings.ToUpper(name)
+		if !strings.HasPrefix(c, "CAP_") {
+			c = "CAP_" + c
+		}
+		if !capsContain(known, c) {
+			return nil, fmt.Errorf("unknown capability %q: %w", name, errdefs.ErrInvalidArgument)
+		}
+		canonical = append(canonical, c)
+	}
+	return canonical, nil
+}
+
+// WithCapabilitySet adds and drops the named capabilities across every
+// capability set, after validating each name against the kernel's known
+// capabilities so a typo fails the spec instead of silently producing
+// the wrong set. Drops win over adds. Names are accepted with or without
+// the CAP_ prefix, case insensitively.
+func WithCapabilitySet(add, drop []string) SpecOpts {
+	return func(ctx context.Context, client Client, c *containers.Container, s *Spec) error {
+		addCaps, err := validateCapabilities(add)
+		if err != nil {
+			return err
+		}
+		dropCaps, err := validateCapabilities(drop)
+		if err != nil {
+			return err
+		}
+		if err := WithAddedCapabilities(addCaps)(ctx, client, c, s); err != nil {
+			return err
+		}
+		return WithDroppedCapabilities(dropCaps)(ctx, client, c, s)
+	}
+}
+
+// WithAllCapabilities grants every capability the kernel knows about;
+// use sparingly.
+func WithAllCapabilities() SpecOpts {
+	return WithAllKnownCapabilities
+}
+
+// WithNoCapabilities clears every capability set.
+func WithNoCapabilities() SpecOpts {
+	return WithCapabilities(nil)
+}
@@ -0,0 +1,77 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"testing"
+
+	"github.com/containerd/errdefs"
+)
+
+func TestWithCapabilitySet(t *testing.T) {
+	s := Spec{}
+	err := WithCapabilitySet([]string{"CAP_SYS_ADMIN", "net_admin"}, []string{"CAP_NET_RAW"})(context.Background(), nil, nil, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, set := range [][]string{
+		s.Process.Capabilities.Bounding,
+		s.Process.Capabilities.Effective,
+		s.Process.Capabilities.Permitted,
+	} {
+		if !capsContain(set, "CAP_SYS_ADMIN") || !capsContain(set, "CAP_NET_ADMIN") {
+			t.Fatalf("added capabilities missing from %v", set)
+		}
+		if capsContain(set, "CAP_NET_RAW") {
+			t.Fatalf("dropped capability present in %v", set)
+		}
+	}
+
+	// drops win over adds
+	s2 := Spec{}
+	if err := WithCapabilitySet([]string{"CAP_NET_RAW"}, []string{"CAP_NET_RAW"})(context.Background(), nil, nil, &s2); err != nil {
+		t.Fatal(err)
+	}
+	if capsContain(s2.Process.Capabilities.Effective, "CAP_NET_RAW") {
+		t.Fatal("dropped capability survived")
+	}
+
+	// unknown names fail with InvalidArgument
+	for _, bad := range [][]string{{"CAP_NOT_A_THING"}, {"made_up"}} {
+		err := WithCapabilitySet(bad, nil)(context.Background(), nil, nil, &Spec{})
+		if !errdefs.IsInvalidArgument(err) {
+			t.Fatalf("capability %v accepted: %v", bad, err)
+		}
+	}
+}
+
+func TestWithNoCapabilities(t *testing.T) {
+	s := Spec{}
+	if err := WithAllCapabilities()(context.Background(), nil, nil, &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Process.Capabilities.Effective) == 0 {
+		t.Fatal("expected all capabilities")
+	}
+	if err := WithNoCapabilities()(context.Background(), nil, nil, &s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Process.Capabilities.Effective) != 0 || len(s.Process.Capabilities.Bounding) != 0 {
+		t.Fatalf("capabilities not cleared: %+v", s.Process.Capabilities)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+// WithCapabilitySet adds and drops Linux capabilities; a no-op spec
+// change off Linux where capability names cannot be validated.
+func WithCapabilitySet(add, drop []string) SpecOpts {
+	return WithCapabilities(nil)
+}
+
+// WithAllCapabilities grants every capability the kernel knows about.
+func WithAllCapabilities() SpecOpts {
+	return WithAllKnownCapabilities
+}
+
+// WithNoCapabilities clears every capability set.
+func WithNoCapabilities() SpecOpts {
+	return WithCapabilities(nil)
+}
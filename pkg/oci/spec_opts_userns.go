@@ -0,0 +1,177 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/containers"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// IDMapping describes one user namespace ID range mapping.
+type IDMapping struct {
+	// ContainerID is the first ID inside the namespace.
+	ContainerID uint32
+	// HostID is the first ID on the host the range maps to.
+	HostID uint32
+	// Size is the number of IDs in the range.
+	Size uint32
+}
+
+// maxIDMappings is the conservative kernel limit on mapping extents
+// (UID_GID_MAP_MAX_EXTENTS before Linux 4.15); staying under it keeps the
+// spec working on every supported kernel.
+const maxIDMappings = 5
+
+// subuid and subgid paths, overridable in tests.
+var (
+	subuidPath = "/etc/subuid"
+	subgidPath = "/etc/subgid"
+)
+
+// validateIDMappings rejects mapping lists the kernel would refuse:
+// empty ranges, ranges overflowing the ID space, overlapping ranges on
+// either side of the mapping, or more extents than old kernels accept.
+func validateIDMappings(kind string, mappings []IDMapping) error {
+	if len(mappings) == 0 {
+		return fmt.Errorf("at least one %s mapping is required", kind)
+	}
+	if len(mappings) > maxIDMappings {
+		return fmt.Errorf("at most %d %s mappings are supported by the kernel, got %d", maxIDMappings, kind, len(mappings))
+	}
+	for _, m := range mappings {
+		if m.Size == 0 {
+			return fmt.Errorf("%s mapping for container ID %d has zero size", kind, m.ContainerID)
+		}
+		if uint64(m.ContainerID)+uint64(m.Size) > math.MaxUint32+1 {
+			return fmt.Errorf("%s mapping container range %d+%d overflows", kind, m.ContainerID, m.Size)
+		}
+		if uint64(m.HostID)+uint64(m.Size) > math.MaxUint32+1 {
+			return fmt.Errorf("%s mapping host range %d+%d overflows", kind, m.HostID, m.Size)
+		}
+	}
+	for _, side := range []struct {
+		name  string
+		first func(IDMapping) uint32
+	}{
+		{"container", func(m IDMapping) uint32 { return m.ContainerID }},
+		{"host", func(m IDMapping) uint32 { return m.HostID }},
+	} {
+		sorted := make([]IDMapping, len(mappings))
+		copy(sorted, mappings)
+		sort.Slice(sorted, func(i, j int) bool {
+			return side.first(sorted[i]) < side.first(sorted[j])
+		})
+		for i := 1; i < len(sorted); i++ {
+			prev, cur := sorted[i-1], sorted[i]
+			if uint64(side.first(prev))+uint64(prev.Size) > uint64(side.first(cur)) {
+				return fmt.Errorf("%s mappings overlap on the %s side: %d+%d and %d", kind, side.name, side.first(prev), prev.Size, side.first(cur))
+			}
+		}
+	}
+	return nil
+}
+
+func toLinuxIDMappings(mappings []IDMapping) []specs.LinuxIDMapping {
+	out := make([]specs.LinuxIDMapping, 0, len(mappings))
+	for _, m := range mappings {
+		out = append(out, specs.LinuxIDMapping{
+			ContainerID: m.ContainerID,
+			HostID:      m.HostID,
+			Size:        m.Size,
+		})
+	}
+	return out
+}
+
+// WithUserNamespaceMappings validates the mappings and applies them like
+// WithUserNamespace, adding the user namespace to the spec when missing.
+func WithUserNamespaceMappings(uidMappings, gidMappings []IDMapping) SpecOpts {
+	return func(ctx context.Context, client Client, c *containers.Container, s *Spec) error {
+		if err := validateIDMappings("uid", uidMappings); err != nil {
+			return err
+		}
+		if err := validateIDMappings("gid", gidMappings); err != nil {
+			return err
+		}
+		return WithUserNamespace(toLinuxIDMappings(uidMappings), toLinuxIDMappings(gidMappings))(ctx, client, c, s)
+	}
+}
+
+// parseSubIDRange returns the first subordinate ID range registered for
+// the user in a subuid/subgid formatted file.
+func parseSubIDRange(path, username string) (IDMapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return IDMapping{}, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok || name != username {
+			continue
+		}
+		startStr, sizeStr, ok := strings.Cut(rest, ":")
+		if !ok {
+			return IDMapping{}, fmt.Errorf("malformed line %q in %s", line, path)
+		}
+		start, err := strconv.ParseUint(startStr, 10, 32)
+		if err != nil {
+			return IDMapping{}, fmt.Errorf("malformed start in %q: %w", line, err)
+		}
+		size, err := strconv.ParseUint(sizeStr, 10, 32)
+		if err != nil {
+			return IDMapping{}, fmt.Errorf("malformed size in %q: %w", line, err)
+		}
+		return IDMapping{ContainerID: 0, HostID: uint32(start), Size: uint32(size)}, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return IDMapping{}, err
+	}
+	return IDMapping{}, fmt.Errorf("no subordinate ID range for user %q in %s", username, path)
+}
+
+// WithSubUIDMapping maps the container's IDs onto the subordinate ranges
+// registered for the user in /etc/subuid and /etc/subgid, starting at
+// container ID 0.
+func WithSubUIDMapping(username string) SpecOpts {
+	return func(ctx context.Context, client Client, c *containers.Container, s *Spec) error {
+		uid, err := parseSubIDRange(subuidPath, username)
+		if err != nil {
+			return err
+		}
+		gid, err := parseSubIDRange(subgidPath, username)
+		if err != nil {
+			return err
+		}
+		return WithUserNamespaceMappings([]IDMapping{uid}, []IDMapping{gid})(ctx, client, c, s)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+func applyUserns(t *testing.T, opt SpecOpts) (*Spec, error) {
+	t.Helper()
+	s := Spec{}
+	err := opt(context.Background(), nil, nil, &s)
+	return &s, err
+}
+
+func TestWithUserNamespaceMappings(t *testing.T) {
+	single := []IDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}}
+
+	s, err := applyUserns(t, WithUserNamespaceMappings(single, single))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hasUserns bool
+	for _, ns := range s.Linux.Namespaces {
+		if ns.Type == specs.UserNamespace {
+			hasUserns = true
+		}
+	}
+	if !hasUserns {
+		t.Fatal("user namespace not added")
+	}
+	if len(s.Linux.UIDMappings) != 1 || s.Linux.UIDMappings[0].HostID != 100000 {
+		t.Fatalf("unexpected uid mappings %v", s.Linux.UIDMappings)
+	}
+
+	for name, tc := range map[string]struct {
+		uid  []IDMapping
+		want string
+	}{
+		"empty":     {nil, "at least one"},
+		"zero size": {[]IDMapping{{ContainerID: 0, HostID: 1000, Size: 0}}, "zero size"},
+		"overflow":  {[]IDMapping{{ContainerID: 4294967295, HostID: 1000, Size: 2}}, "overflows"},
+		"container overlap": {[]IDMapping{
+			{ContainerID: 0, HostID: 1000, Size: 10},
+			{ContainerID: 5, HostID: 2000, Size: 10},
+		}, "overlap on the container side"},
+		"host overlap": {[]IDMapping{
+			{ContainerID: 0, HostID: 1000, Size: 10},
+			{ContainerID: 100, HostID: 1005, Size: 10},
+		}, "overlap on the host side"},
+		"too many": {[]IDMapping{
+			{0, 1000, 1}, {1, 2000, 1}, {2, 3000, 1}, {3, 4000, 1}, {4, 5000, 1}, {5, 6000, 1},
+		}, "at most 5"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := applyUserns(t, WithUserNamespaceMappings(tc.uid, single))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestWithSubUIDMapping(t *testing.T) {
+	dir := t.TempDir()
+	subuid := filepath.Join(dir, "subuid")
+	subgid := filepath.Join(dir, "subgid")
+	if err := os.WriteFile(subuid, []byte("# comment\nalice:100000:65536\nbob:300000:65536\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(subgid, []byte("alice:200000:65536\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	origUID, origGID := subuidPath, subgidPath
+	subuidPath, subgidPath = subuid, subgid
+	defer func() {
+		subuidPath, subgidPath = origUID, origGID
+	}()
+
+	s, err := applyUserns(t, WithSubUIDMapping("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Linux.UIDMappings[0].HostID != 100000 || s.Linux.GIDMappings[0].HostID != 200000 {
+		t.Fatalf("unexpected mappings uid=%v gid=%v", s.Linux.UIDMappings, s.Linux.GIDMappings)
+	}
+	if s.Linux.UIDMappings[0].Size != 65536 || s.Linux.UIDMappings[0].ContainerID != 0 {
+		t.Fatalf("unexpected uid mapping %v", s.Linux.UIDMappings[0])
+	}
+
+	if _, err := applyUserns(t, WithSubUIDMapping("mallory")); err == nil {
+		t.Fatal("expected error for user without ranges")
+	}
+}
@@ -78,3 +78,10 @@ func isEnabled() bool {
 
 	return enabled
 }
+
+// SyscallNumber resolves a syscall name to its number on the running
+// architecture.
+func SyscallNumber(name string) (uint32, bool) {
+	nr, ok := syscallNumbers[name]
+	return nr, ok
+}
@@ -0,0 +1,27 @@
+//go:build linux && !amd64 && !arm64
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+// SeccompAuditArch is unknown on architectures without a generated
+// syscall table.
+const SeccompAuditArch = 0
+
+// syscallNumbers is empty on architectures without a generated table;
+// lookups fail and callers treat every name as unknown.
+var syscallNumbers = map[string]uint32{}
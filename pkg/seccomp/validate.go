@@ -0,0 +1,69 @@
+//go:build linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package seccomp
+
+import (
+	"fmt"
+
+	"github.com/containerd/errdefs"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// validActions are the seccomp actions a profile may use.
+var validActions = map[specs.LinuxSeccompAction]struct{}{
+	specs.ActKill:        {},
+	specs.ActKillProcess: {},
+	specs.ActKillThread:  {},
+	specs.ActTrap:        {},
+	specs.ActErrno:       {},
+	specs.ActTrace:       {},
+	specs.ActAllow:       {},
+	specs.ActLog:         {},
+	specs.ActNotify:      {},
+}
+
+// ValidateProfile checks the profile's actions and, on architectures
+// with a generated table, its syscall names against the running
+// architecture, so a typo fails before reaching the runtime.
+func ValidateProfile(profile *specs.LinuxSeccomp) error {
+	if profile == nil {
+		return fmt.Errorf("seccomp profile must not be nil: %w", errdefs.ErrInvalidArgument)
+	}
+	if _, ok := validActions[profile.DefaultAction]; !ok {
+		return fmt.Errorf("invalid default action %q: %w", profile.DefaultAction, errdefs.ErrInvalidArgument)
+	}
+	for _, sc := range profile.Syscalls {
+		if len(sc.Names) == 0 {
+			return fmt.Errorf("syscall rule without names: %w", errdefs.ErrInvalidArgument)
+		}
+		if _, ok := validActions[sc.Action]; !ok {
+			return fmt.Errorf("invalid action %q: %w", sc.Action, errdefs.ErrInvalidArgument)
+		}
+		if len(syscallNumbers) == 0 {
+			// no table generated for this architecture
+			continue
+		}
+		for _, name := range sc.Names {
+			if _, ok := syscallNumbers[name]; !ok {
+				return fmt.Errorf("unknown syscall %q on this architecture: %w", name, errdefs.ErrInvalidArgument)
+			}
+		}
+	}
+	return nil
+}
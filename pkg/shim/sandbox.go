@@ -0,0 +1,70 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package shim
+
+// SandboxConfig restricts what the shim process itself can reach, see
+// WithShimSandbox.
+type SandboxConfig struct {
+	// AllowedSyscalls, when non-empty, installs a seccomp filter allowing
+	// only the named syscalls; everything else fails with EPERM.
+	AllowedSyscalls []string
+
+	// ExtraBindMounts are additional host paths made visible inside the
+	// sandbox root, keyed destination to source.
+	ExtraBindMounts map[string]string
+}
+
+// SandboxOpt configures the shim sandbox.
+type SandboxOpt func(*SandboxConfig)
+
+// WithAllowedSyscalls installs a seccomp allow-list for the shim process;
+// syscalls not on the list fail with EPERM. Names are resolved for the
+// shim's own architecture and unknown names are rejected when the sandbox
+// is applied.
+func WithAllowedSyscalls(syscalls []string) SandboxOpt {
+	return func(c *SandboxConfig) {
+		c.AllowedSyscalls = append(c.AllowedSyscalls, syscalls...)
+	}
+}
+
+// WithExtraBindMount makes an additional host path visible at dst inside
+// the sandboxed shim's root.
+func WithExtraBindMount(src, dst string) SandboxOpt {
+	return func(c *SandboxConfig) {
+		if c.ExtraBindMounts == nil {
+			c.ExtraBindMounts = map[string]string{}
+		}
+		c.ExtraBindMounts[dst] = src
+	}
+}
+
+// WithShimSandbox confines the serving shim process to a minimal root
+// containing only the paths it needs — the bundle (container rootfs and
+// sockets), the containerd state directory, cgroups, /proc and /dev, plus
+// any extra bind mounts — and optionally installs a seccomp allow-list.
+// The sandbox is applied after bootstrap, right before the task service
+// starts serving, and is only implemented on Linux; elsewhere it is
+// rejected at startup rather than silently skipped.
+func WithShimSandbox(opts ...SandboxOpt) BinaryOpts {
+	return func(c *Config) {
+		config := &SandboxConfig{}
+		for _, o := range opts {
+			o(config)
+		}
+		c.Sandbox = config
+	}
+}
@@ -0,0 +1,151 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package shim
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"github.com/containerd/log"
+	"golang.org/x/sys/unix"
+
+	"github.com/containerd/containerd/v2/pkg/seccomp"
+)
+
+// applySandbox confines the shim to a minimal root and optionally installs
+// a seccomp allow-list. The root is built under the bundle so it is removed
+// with the bundle, populated with recursive bind mounts of the paths the
+// shim needs, and entered with chroot; the seccomp filter is applied to
+// every thread afterwards.
+func applySandbox(ctx context.Context, config *SandboxConfig, bundlePath string) error {
+	if bundlePath == "" {
+		return fmt.Errorf("shim sandbox requires a bundle path")
+	}
+
+	root := filepath.Join(bundlePath, "sandbox")
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return err
+	}
+
+	binds := map[string]string{
+		bundlePath:        bundlePath,
+		"/run/containerd": "/run/containerd",
+		"/sys/fs/cgroup":  "/sys/fs/cgroup",
+		"/proc":           "/proc",
+		"/dev":            "/dev",
+	}
+	for dst, src := range config.ExtraBindMounts {
+		binds[dst] = src
+	}
+
+	for dst, src := range binds {
+		if _, err := os.Stat(src); err != nil {
+			log.G(ctx).WithError(err).WithField("source", src).Warn("skipping missing sandbox bind mount")
+			continue
+		}
+		target := filepath.Join(root, dst)
+		if err := os.MkdirAll(target, 0700); err != nil {
+			return err
+		}
+		if err := unix.Mount(src, target, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s into the sandbox: %w", src, err)
+		}
+	}
+
+	if err := unix.Chroot(root); err != nil {
+		return fmt.Errorf("failed to enter sandbox root: %w", err)
+	}
+	if err := os.Chdir("/"); err != nil {
+		return err
+	}
+
+	if len(config.AllowedSyscalls) > 0 {
+		if err := applySyscallFilter(config.AllowedSyscalls); err != nil {
+			return fmt.Errorf("failed to apply syscall filter: %w", err)
+		}
+	}
+	return nil
+}
+
+// Classic BPF opcodes and seccomp return values used by the filter.
+const (
+	bpfLD  = 0x00
+	bpfW   = 0x00
+	bpfABS = 0x20
+	bpfJMP = 0x05
+	bpfJEQ = 0x10
+	bpfK   = 0x00
+	bpfRET = 0x06
+
+	seccompRetAllow = 0x7fff0000
+	seccompRetErrno = 0x00050000
+
+	seccompSetModeFilter   = 1
+	seccompFilterFlagTSync = 1
+
+	// offsets into struct seccomp_data
+	seccompDataNr   = 0
+	seccompDataArch = 4
+)
+
+// applySyscallFilter installs a seccomp allow-list for the named syscalls
+// on every thread of the process; everything else fails with EPERM.
+func applySyscallFilter(names []string) error {
+	numbers := make([]uint32, 0, len(names))
+	for _, name := range names {
+		nr, ok := seccomp.SyscallNumber(name)
+		if !ok {
+			return fmt.Errorf("unknown syscall %q", name)
+		}
+		numbers = append(numbers, nr)
+	}
+
+	var filter []unix.SockFilter
+	stmt := func(code uint16, k uint32) {
+		filter = append(filter, unix.SockFilter{Code: code, K: k})
+	}
+	jump := func(code uint16, k uint32, jt, jf uint8) {
+		filter = append(filter, unix.SockFilter{Code: code, K: k, Jt: jt, Jf: jf})
+	}
+
+	// reject syscalls for foreign architectures outright
+	stmt(bpfLD|bpfW|bpfABS, seccompDataArch)
+	jump(bpfJMP|bpfJEQ|bpfK, seccomp.SeccompAuditArch, 1, 0)
+	stmt(bpfRET|bpfK, seccompRetErrno|uint32(unix.EPERM))
+
+	stmt(bpfLD|bpfW|bpfABS, seccompDataNr)
+	for _, nr := range numbers {
+		jump(bpfJMP|bpfJEQ|bpfK, nr, 0, 1)
+		stmt(bpfRET|bpfK, seccompRetAllow)
+	}
+	stmt(bpfRET|bpfK, seccompRetErrno|uint32(unix.EPERM))
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return err
+	}
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_SECCOMP, seccompSetModeFilter, seccompFilterFlagTSync, uintptr(unsafe.Pointer(&prog))); errno != 0 {
+		return errno
+	}
+	return nil
+}
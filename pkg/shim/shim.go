@@ -87,6 +87,8 @@ type Config struct {
 	NoReaper bool
 	// NoSetupLogger disables automatic configuration of logrus to use the shim FIFO
 	NoSetupLogger bool
+	// Sandbox confines the serving shim process, see WithShimSandbox
+	Sandbox *SandboxConfig
 }
 
 type TTRPCService interface {
@@ -328,6 +330,12 @@ func run(ctx context.Context, manager Shim, config Config) error {
 		}
 	}
 
+	if config.Sandbox != nil {
+		if err := applySandbox(ctx, config.Sandbox, bundlePath); err != nil {
+			return fmt.Errorf("failed to apply shim sandbox: %w", err)
+		}
+	}
+
 	registry.Register(&plugin.Registration{
 		Type: plugins.InternalPlugin,
 		ID:   "shutdown",
@@ -0,0 +1,90 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package apparmor re-loads the AppArmor profiles recorded by
+// Client.LoadAppArmorProfile at daemon start, so custom profiles survive
+// a host reboot without operators wiring apparmor_parser into boot.
+package apparmor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/containerd/log"
+	"github.com/containerd/plugin"
+	"github.com/containerd/plugin/registry"
+
+	"github.com/containerd/containerd/v2/contrib/apparmor"
+	aasupport "github.com/containerd/containerd/v2/pkg/apparmor"
+	"github.com/containerd/containerd/v2/plugins"
+)
+
+var profilesBucket = []byte("profiles")
+
+func init() {
+	registry.Register(&plugin.Registration{
+		Type: plugins.InternalPlugin,
+		ID:   "apparmor",
+		InitFn: func(ic *plugin.InitContext) (any, error) {
+			if !aasupport.HostSupports() {
+				return nil, fmt.Errorf("apparmor is not enabled: %w", plugin.ErrSkipPlugin)
+			}
+			r := &reloader{
+				dbPath: filepath.Join(ic.Properties[plugins.PropertyRootDir], "profiles.db"),
+			}
+			if err := r.reload(ic); err != nil {
+				return nil, err
+			}
+			return r, nil
+		},
+	})
+}
+
+type reloader struct {
+	dbPath string
+}
+
+// reload loads every tracked profile back into the kernel. The database
+// is closed again before returning so clients can record new profiles.
+func (r *reloader) reload(ic *plugin.InitContext) error {
+	if _, err := os.Stat(r.dbPath); os.IsNotExist(err) {
+		return nil
+	}
+	db, err := bolt.Open(r.dbPath, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(profilesBucket)
+		if bkt == nil {
+			return nil
+		}
+		return bkt.ForEach(func(k, v []byte) error {
+			if err := apparmor.LoadProfile(string(v)); err != nil {
+				// a single broken profile must not fail daemon start
+				log.G(ic.Context).WithError(err).WithField("profile", string(k)).Error("failed to re-load apparmor profile")
+				return nil
+			}
+			log.G(ic.Context).WithField("profile", string(k)).Debug("re-loaded apparmor profile")
+			return nil
+		})
+	})
+}
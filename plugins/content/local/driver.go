@@ -0,0 +1,61 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"io"
+
+	"github.com/opencontainers/go-digest"
+
+	"github.com/containerd/containerd/v2/core/content"
+)
+
+// BlobDriver stores committed blob data outside the store's root, for
+// example in object storage, while all metadata (labels, ingest state,
+// lease references through the metadata store) stays local.
+//
+// Ingests are staged in the store's ingest directory, which is what makes
+// them resumable, and are handed to the driver only after their digest has
+// been verified at commit time. For every committed blob the store keeps a
+// sparse marker file of the blob's size in its blobs tree so walking and
+// stat-based queries keep working without touching the driver.
+type BlobDriver interface {
+	// Put stores the committed blob for dgst, reading exactly size bytes
+	// from r. The data has already been verified against dgst.
+	Put(ctx context.Context, dgst digest.Digest, r io.Reader, size int64) error
+
+	// ReaderAt opens the committed blob for dgst for random access reads.
+	// Implementations must verify the data against dgst when it is
+	// fetched from untrusted storage.
+	ReaderAt(ctx context.Context, dgst digest.Digest, size int64) (content.ReaderAt, error)
+
+	// Delete removes the committed blob for dgst.
+	Delete(ctx context.Context, dgst digest.Digest) error
+}
+
+// NewDriverStore returns a local content store which keeps committed blob
+// data in the provided driver. The label store may be nil when the store is
+// used underneath a metadata store, same as NewLabeledStore.
+func NewDriverStore(root string, ls LabelStore, driver BlobDriver) (content.Store, error) {
+	cs, err := NewLabeledStore(root, ls)
+	if err != nil {
+		return nil, err
+	}
+	cs.(*store).driver = driver
+	return cs, nil
+}
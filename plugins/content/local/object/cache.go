@@ -0,0 +1,140 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package object
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+// readCache is a size-bounded on-disk LRU cache of fetched blobs. Each blob
+// is kept in a single file named after its digest; eviction drops the least
+// recently used files first. Evicting a file that is still open is safe on
+// platforms where unlinking open files is allowed, readers keep their
+// handle until closed.
+type readCache struct {
+	dir    string
+	budget int64
+
+	mu    sync.Mutex
+	used  int64
+	order []digest.Digest // least recently used first
+	sizes map[digest.Digest]int64
+}
+
+func newReadCache(dir string, budget int64) *readCache {
+	return &readCache{
+		dir:    dir,
+		budget: budget,
+		sizes:  map[digest.Digest]int64{},
+	}
+}
+
+func (c *readCache) path(dgst digest.Digest) string {
+	return filepath.Join(c.dir, dgst.Algorithm().String()+"-"+dgst.Encoded())
+}
+
+// get returns the path of the cached blob, fetching it with fetch on a
+// miss. fetch must write the complete, verified blob to the writer.
+func (c *readCache) get(dgst digest.Digest, fetch func(io.Writer) error) (string, error) {
+	c.mu.Lock()
+	if _, ok := c.sizes[dgst]; ok {
+		c.touch(dgst)
+		c.mu.Unlock()
+		return c.path(dgst), nil
+	}
+	c.mu.Unlock()
+
+	f, err := os.CreateTemp(c.dir, "fetch-")
+	if err != nil {
+		return "", err
+	}
+	err = fetch(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	fi, err := os.Stat(f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	p := c.path(dgst)
+	if err := os.Rename(f.Name(), p); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	c.mu.Lock()
+	if _, ok := c.sizes[dgst]; !ok {
+		c.sizes[dgst] = fi.Size()
+		c.used += fi.Size()
+		c.order = append(c.order, dgst)
+	}
+	c.evictLocked()
+	c.mu.Unlock()
+
+	return p, nil
+}
+
+// remove drops the blob from the cache, used when the blob is deleted from
+// the backing store.
+func (c *readCache) remove(dgst digest.Digest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if size, ok := c.sizes[dgst]; ok {
+		delete(c.sizes, dgst)
+		c.used -= size
+		for i, d := range c.order {
+			if d == dgst {
+				c.order = append(c.order[:i], c.order[i+1:]...)
+				break
+			}
+		}
+		os.Remove(c.path(dgst))
+	}
+}
+
+// touch moves the blob to the most recently used position.
+func (c *readCache) touch(dgst digest.Digest) {
+	for i, d := range c.order {
+		if d == dgst {
+			c.order = append(append(c.order[:i], c.order[i+1:]...), dgst)
+			return
+		}
+	}
+}
+
+// evictLocked removes least recently used entries until the cache is within
+// budget, always keeping the most recent entry so a blob larger than the
+// whole budget can still be read.
+func (c *readCache) evictLocked() {
+	for c.used > c.budget && len(c.order) > 1 {
+		dgst := c.order[0]
+		c.order = c.order[1:]
+		c.used -= c.sizes[dgst]
+		delete(c.sizes, dgst)
+		os.Remove(c.path(dgst))
+	}
+}
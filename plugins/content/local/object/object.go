@@ -0,0 +1,296 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package object implements a BlobDriver for the local content store which
+// keeps committed blob data in an S3-compatible object store. Blobs are
+// uploaded with multipart uploads when they exceed the configured part
+// size, reads go through a small on-disk LRU cache and are verified
+// against their digest when fetched, and abandoned multipart uploads are
+// aborted so no partial data is left behind.
+//
+// Authentication is not implemented here: requests are issued with the
+// configured http.Client, whose transport can add whatever credentials the
+// target service requires (request signing, bearer tokens, ...).
+package object
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/containerd/errdefs"
+	digest "github.com/opencontainers/go-digest"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+)
+
+const (
+	// minPartSize is the smallest part size accepted by S3-compatible
+	// services for all but the last part of a multipart upload.
+	minPartSize = 5 << 20
+
+	defaultPartSize  = 8 << 20
+	defaultCacheSize = 512 << 20
+)
+
+// Config describes the object store holding blob data.
+type Config struct {
+	// Endpoint is the base URL of the S3-compatible service, for example
+	// "http://127.0.0.1:9000".
+	Endpoint string
+
+	// Bucket holding the blobs.
+	Bucket string
+
+	// Prefix is prepended to every object key, may be empty.
+	Prefix string
+
+	// PartSize used for multipart uploads of blobs larger than itself.
+	// Defaults to 8 MiB and must be at least 5 MiB.
+	PartSize int64
+
+	// CacheDir holds the on-disk LRU cache for reads. A temporary
+	// directory is used when empty.
+	CacheDir string
+
+	// CacheSize bounds the read cache in bytes, defaulting to 512 MiB.
+	CacheSize int64
+
+	// Client used for all requests, http.DefaultClient when nil. Set a
+	// custom transport to attach credentials to requests.
+	Client *http.Client
+}
+
+type driver struct {
+	base     *url.URL
+	prefix   string
+	partSize int64
+	client   *http.Client
+	cache    *readCache
+}
+
+// NewDriver returns a BlobDriver storing blob data in an S3-compatible
+// object store.
+func NewDriver(cfg Config) (local.BlobDriver, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("object driver requires an endpoint and bucket: %w", errdefs.ErrInvalidArgument)
+	}
+	base, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object store endpoint %q: %w", cfg.Endpoint, err)
+	}
+	base = base.JoinPath(cfg.Bucket)
+
+	partSize := cfg.PartSize
+	if partSize == 0 {
+		partSize = defaultPartSize
+	}
+	if partSize < minPartSize {
+		return nil, fmt.Errorf("part size %d below the minimum of %d: %w", partSize, int64(minPartSize), errdefs.ErrInvalidArgument)
+	}
+
+	cacheDir := cfg.CacheDir
+	if cacheDir == "" {
+		if cacheDir, err = os.MkdirTemp("", "containerd-object-cache-"); err != nil {
+			return nil, err
+		}
+	} else if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return nil, err
+	}
+	cacheSize := cfg.CacheSize
+	if cacheSize == 0 {
+		cacheSize = defaultCacheSize
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &driver{
+		base:     base,
+		prefix:   cfg.Prefix,
+		partSize: partSize,
+		client:   client,
+		cache:    newReadCache(cacheDir, cacheSize),
+	}, nil
+}
+
+func (d *driver) objectURL(dgst digest.Digest, query string) string {
+	u := d.base.JoinPath(path.Join(d.prefix, dgst.Algorithm().String(), dgst.Encoded()))
+	u.RawQuery = query
+	return u.String()
+}
+
+func (d *driver) do(ctx context.Context, method, url string, body io.Reader, length int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if length >= 0 {
+		req.ContentLength = length
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return resp, nil
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("object store returned 404 for %s: %w", url, errdefs.ErrNotFound)
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("object store returned %s for %s %s", resp.Status, method, url)
+	}
+}
+
+// Put uploads the blob, using a multipart upload when it is larger than
+// the configured part size.
+func (d *driver) Put(ctx context.Context, dgst digest.Digest, r io.Reader, size int64) error {
+	if size <= d.partSize {
+		resp, err := d.do(ctx, http.MethodPut, d.objectURL(dgst, ""), r, size)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+	return d.putMultipart(ctx, dgst, r, size)
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+func (d *driver) putMultipart(ctx context.Context, dgst digest.Digest, r io.Reader, size int64) (retErr error) {
+	resp, err := d.do(ctx, http.MethodPost, d.objectURL(dgst, "uploads"), nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+	var initiated initiateMultipartUploadResult
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("failed to parse multipart initiation response: %w", err)
+	}
+	uploadQuery := "uploadId=" + url.QueryEscape(initiated.UploadID)
+
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		// Abort the upload so abandoned ingests do not leave partial
+		// multipart data behind.
+		if resp, err := d.do(ctx, http.MethodDelete, d.objectURL(dgst, uploadQuery), nil, 0); err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	var (
+		complete completeMultipartUpload
+		buf      = make([]byte, d.partSize)
+		remain   = size
+	)
+	for part := 1; remain > 0; part++ {
+		n := d.partSize
+		if remain < n {
+			n = remain
+		}
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return fmt.Errorf("failed to read part %d: %w", part, err)
+		}
+		resp, err := d.do(ctx, http.MethodPut,
+			d.objectURL(dgst, fmt.Sprintf("partNumber=%d&%s", part, uploadQuery)),
+			bytes.NewReader(buf[:n]), n)
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d: %w", part, err)
+		}
+		etag := resp.Header.Get("ETag")
+		resp.Body.Close()
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: part, ETag: etag})
+		remain -= n
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	resp, err = d.do(ctx, http.MethodPost, d.objectURL(dgst, uploadQuery), bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ReaderAt fetches the blob through the read cache, verifying the data
+// against its digest on the way in.
+func (d *driver) ReaderAt(ctx context.Context, dgst digest.Digest, size int64) (content.ReaderAt, error) {
+	p, err := d.cache.get(dgst, func(w io.Writer) error {
+		resp, err := d.do(ctx, http.MethodGet, d.objectURL(dgst, ""), nil, -1)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		verifier := dgst.Verifier()
+		n, err := io.Copy(io.MultiWriter(w, verifier), resp.Body)
+		if err != nil {
+			return err
+		}
+		if n != size {
+			return fmt.Errorf("fetched %d bytes for blob %v, expected %d", n, dgst, size)
+		}
+		if !verifier.Verified() {
+			return fmt.Errorf("blob %v failed digest verification on fetch", dgst)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return local.OpenReader(p)
+}
+
+// Delete removes the blob from the object store and drops any cached copy.
+func (d *driver) Delete(ctx context.Context, dgst digest.Digest) error {
+	d.cache.remove(dgst)
+	resp, err := d.do(ctx, http.MethodDelete, d.objectURL(dgst, ""), nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
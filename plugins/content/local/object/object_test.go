@@ -0,0 +1,325 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package object
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/containerd/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+)
+
+// fakeObjectStore implements just enough of the S3 API for the driver:
+// GET/PUT/DELETE objects and multipart upload initiation, part upload,
+// completion and abort.
+type fakeObjectStore struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	parts    map[string]map[int][]byte // uploadID -> part number -> data
+	partKeys map[string]string         // uploadID -> object key
+	nextID   int
+}
+
+func newFakeObjectStore() *fakeObjectStore {
+	return &fakeObjectStore{
+		objects:  map[string][]byte{},
+		parts:    map[string]map[int][]byte{},
+		partKeys: map[string]string{},
+	}
+}
+
+func (f *fakeObjectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.parts[id] = map[int][]byte{}
+		f.partKeys[id] = key
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, id)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		id := query.Get("uploadId")
+		if _, ok := f.parts[id]; !ok {
+			http.Error(w, "no such upload", http.StatusNotFound)
+			return
+		}
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		data, _ := io.ReadAll(r.Body)
+		f.parts[id][n] = data
+		w.Header().Set("ETag", fmt.Sprintf("%q", digest.FromBytes(data).Encoded()))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		id := query.Get("uploadId")
+		parts, ok := f.parts[id]
+		if !ok {
+			http.Error(w, "no such upload", http.StatusNotFound)
+			return
+		}
+		nums := make([]int, 0, len(parts))
+		for n := range parts {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+		var data []byte
+		for _, n := range nums {
+			data = append(data, parts[n]...)
+		}
+		f.objects[f.partKeys[id]] = data
+		delete(f.parts, id)
+		delete(f.partKeys, id)
+		fmt.Fprint(w, `<CompleteMultipartUploadResult></CompleteMultipartUploadResult>`)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		id := query.Get("uploadId")
+		delete(f.parts, id)
+		delete(f.partKeys, id)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "no such key", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unhandled", http.StatusBadRequest)
+	}
+}
+
+func (f *fakeObjectStore) pendingUploads() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.parts)
+}
+
+func newTestDriver(t *testing.T, f *fakeObjectStore) local.BlobDriver {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	d, err := NewDriver(Config{
+		Endpoint: srv.URL,
+		Bucket:   "blobs",
+		Prefix:   "test",
+		CacheDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDriverRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeObjectStore()
+	d := newTestDriver(t, f)
+
+	blob := []byte("object store blob")
+	dgst := digest.FromBytes(blob)
+
+	if err := d.Put(ctx, dgst, bytes.NewReader(blob), int64(len(blob))); err != nil {
+		t.Fatal(err)
+	}
+
+	ra, err := d.ReaderAt(ctx, dgst, int64(len(blob)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, ra.Size())
+	if _, err := ra.ReadAt(got, 0); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	ra.Close()
+	if !bytes.Equal(got, blob) {
+		t.Fatalf("read %q, expected %q", got, blob)
+	}
+
+	if err := d.Delete(ctx, dgst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.ReaderAt(ctx, dgst, int64(len(blob))); !errdefs.IsNotFound(err) {
+		t.Fatalf("expected not found after delete, got %v", err)
+	}
+}
+
+func TestDriverMultipart(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeObjectStore()
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	d, err := NewDriver(Config{
+		Endpoint: srv.URL,
+		Bucket:   "blobs",
+		PartSize: minPartSize,
+		CacheDir: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob := make([]byte, minPartSize*2+1234)
+	if _, err := rand.Read(blob); err != nil {
+		t.Fatal(err)
+	}
+	dgst := digest.FromBytes(blob)
+
+	if err := d.Put(ctx, dgst, bytes.NewReader(blob), int64(len(blob))); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.pendingUploads(); n != 0 {
+		t.Fatalf("expected no pending multipart uploads, got %d", n)
+	}
+
+	ra, err := d.ReaderAt(ctx, dgst, int64(len(blob)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ra.Close()
+	if ra.Size() != int64(len(blob)) {
+		t.Fatalf("expected size %d, got %d", len(blob), ra.Size())
+	}
+	tail := make([]byte, 1234)
+	if _, err := ra.ReadAt(tail, int64(len(blob)-1234)); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tail, blob[len(blob)-1234:]) {
+		t.Fatal("tail read does not match uploaded data")
+	}
+}
+
+func TestDriverMultipartAbortedOnFailure(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeObjectStore()
+	d := newTestDriver(t, f)
+
+	blob := make([]byte, minPartSize+1)
+	dgst := digest.FromBytes(blob)
+
+	// Short read: the source ends before size bytes were read, the upload
+	// must fail and the initiated multipart upload must be aborted.
+	if err := d.Put(ctx, dgst, bytes.NewReader(blob[:16]), int64(len(blob))); err == nil {
+		t.Fatal("expected error from short read")
+	}
+	if n := f.pendingUploads(); n != 0 {
+		t.Fatalf("expected aborted multipart upload, %d still pending", n)
+	}
+}
+
+func TestDriverVerifiesOnFetch(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeObjectStore()
+	d := newTestDriver(t, f)
+
+	blob := []byte("verified blob")
+	dgst := digest.FromBytes(blob)
+	if err := d.Put(ctx, dgst, bytes.NewReader(blob), int64(len(blob))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the stored object, the next (uncached) fetch must fail
+	// verification.
+	f.mu.Lock()
+	for k := range f.objects {
+		f.objects[k] = append([]byte("x"), f.objects[k][1:]...)
+	}
+	f.mu.Unlock()
+
+	if _, err := d.ReaderAt(ctx, dgst, int64(len(blob))); err == nil || !strings.Contains(err.Error(), "verification") {
+		t.Fatalf("expected verification failure, got %v", err)
+	}
+}
+
+func TestDriverStore(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeObjectStore()
+	d := newTestDriver(t, f)
+
+	cs, err := local.NewDriverStore(t.TempDir(), nil, d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob := []byte("driver store blob")
+	desc := ocispec.Descriptor{Size: int64(len(blob)), Digest: digest.FromBytes(blob)}
+	if err := content.WriteBlob(ctx, cs, "driver-ref", bytes.NewReader(blob), desc); err != nil {
+		t.Fatal(err)
+	}
+
+	// The data must live in the object store, not in the blobs tree.
+	f.mu.Lock()
+	stored := len(f.objects)
+	f.mu.Unlock()
+	if stored != 1 {
+		t.Fatalf("expected 1 object in the object store, got %d", stored)
+	}
+
+	info, err := cs.Info(ctx, desc.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != desc.Size {
+		t.Fatalf("expected size %d from marker file, got %d", desc.Size, info.Size)
+	}
+
+	ra, err := cs.ReaderAt(ctx, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, ra.Size())
+	if _, err := ra.ReadAt(got, 0); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	ra.Close()
+	if !bytes.Equal(got, blob) {
+		t.Fatalf("read %q through store, expected %q", got, blob)
+	}
+
+	if err := cs.Delete(ctx, desc.Digest); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	stored = len(f.objects)
+	f.mu.Unlock()
+	if stored != 0 {
+		t.Fatalf("expected object removed from the object store, %d left", stored)
+	}
+}
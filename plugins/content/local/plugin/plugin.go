@@ -20,18 +20,41 @@ import (
 	"github.com/containerd/plugin"
 	"github.com/containerd/plugin/registry"
 
+	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/plugins"
 	"github.com/containerd/containerd/v2/plugins/content/local"
 )
 
+// Config for the local content store plugin.
+type Config struct {
+	// ReadOnlyRoots are additional pre-seeded content directories, for
+	// example shared over NFS, opened read-only and consulted before the
+	// writable store when reading blobs during pull and unpack.
+	ReadOnlyRoots []string `toml:"ro_roots"`
+}
+
 func init() {
 	registry.Register(&plugin.Registration{
-		Type: plugins.ContentPlugin,
-		ID:   "content",
+		Type:   plugins.ContentPlugin,
+		ID:     "content",
+		Config: &Config{},
 		InitFn: func(ic *plugin.InitContext) (any, error) {
+			cfg := ic.Config.(*Config)
 			root := ic.Properties[plugins.PropertyRootDir]
 			ic.Meta.Exports["root"] = root
-			return local.NewStore(root)
+			cs, err := local.NewStore(root)
+			if err != nil {
+				return nil, err
+			}
+			var providers []content.Provider
+			for _, roRoot := range cfg.ReadOnlyRoots {
+				ro, err := local.NewReadOnlyStore(roRoot)
+				if err != nil {
+					return nil, err
+				}
+				providers = append(providers, ro)
+			}
+			return local.NewStoreWithProviders(cs, providers...), nil
 		},
 	})
 }
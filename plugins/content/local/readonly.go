@@ -0,0 +1,103 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/containerd/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+)
+
+// ErrReadOnly is returned by every mutating operation of a store opened
+// with NewReadOnlyStore.
+var ErrReadOnly = fmt.Errorf("content store is read-only: %w", errdefs.ErrFailedPrecondition)
+
+// NewReadOnlyStore opens the content directory at root without ever
+// writing to it: Writer, Abort, Delete and Update fail with ErrReadOnly
+// and no ingest directory, lock file or timestamp updates are performed.
+// This allows forensic inspection of a store and serving a pre-seeded
+// content directory, for example over NFS, to many nodes at once.
+func NewReadOnlyStore(root string) (content.Store, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, fmt.Errorf("failed to stat content root %q: %w", root, err)
+	}
+	s := &store{
+		root:        root,
+		locks:       map[string]*lock{},
+		broadcaster: newStatusBroadcaster(),
+	}
+	// never create the ingest directory
+	s.ensureIngestRootOnce = func() error { return ErrReadOnly }
+	return &readOnlyStore{s}, nil
+}
+
+type readOnlyStore struct {
+	*store
+}
+
+func (s *readOnlyStore) Update(ctx context.Context, info content.Info, fieldpaths ...string) (content.Info, error) {
+	return content.Info{}, ErrReadOnly
+}
+
+func (s *readOnlyStore) Delete(ctx context.Context, dgst digest.Digest) error {
+	return ErrReadOnly
+}
+
+func (s *readOnlyStore) DeleteAll(ctx context.Context, dgsts []digest.Digest) ([]digest.Digest, error) {
+	return nil, ErrReadOnly
+}
+
+func (s *readOnlyStore) Writer(ctx context.Context, opts ...content.WriterOpt) (content.Writer, error) {
+	return nil, ErrReadOnly
+}
+
+func (s *readOnlyStore) Abort(ctx context.Context, ref string) error {
+	return ErrReadOnly
+}
+
+// NewStoreWithProviders returns a store that consults the given read-only
+// providers before the writable store on reads. Everything else, including
+// all writes, goes to the writable store, so pre-seeded content is served
+// without being copied while new content still lands locally.
+func NewStoreWithProviders(rw content.Store, providers ...content.Provider) content.Store {
+	if len(providers) == 0 {
+		return rw
+	}
+	return &fallbackStore{Store: rw, providers: providers}
+}
+
+type fallbackStore struct {
+	content.Store
+	providers []content.Provider
+}
+
+func (s *fallbackStore) ReaderAt(ctx context.Context, desc ocispec.Descriptor) (content.ReaderAt, error) {
+	for _, p := range s.providers {
+		if ra, err := p.ReaderAt(ctx, desc); err == nil {
+			return ra, nil
+		} else if !errdefs.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return s.Store.ReaderAt(ctx, desc)
+}
@@ -0,0 +1,138 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package local
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+)
+
+func TestReadOnlyStore(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+
+	rw, err := NewStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := []byte("read-only store blob")
+	desc := ocispec.Descriptor{Size: int64(len(blob)), Digest: digest.FromBytes(blob)}
+	if err := content.WriteBlob(ctx, rw, "seed", bytes.NewReader(blob), desc); err != nil {
+		t.Fatal(err)
+	}
+	// remove the ingest directory to verify the read-only store never
+	// recreates it
+	if err := os.RemoveAll(filepath.Join(root, "ingest")); err != nil {
+		t.Fatal(err)
+	}
+
+	ro, err := NewReadOnlyStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ra, err := ro.ReaderAt(ctx, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, ra.Size())
+	if _, err := ra.ReadAt(got, 0); err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	ra.Close()
+	if !bytes.Equal(got, blob) {
+		t.Fatalf("read %q, expected %q", got, blob)
+	}
+	if _, err := ro.Info(ctx, desc.Digest); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ro.Writer(ctx, content.WithRef("w")); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly from Writer, got %v", err)
+	}
+	if err := ro.Delete(ctx, desc.Digest); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly from Delete, got %v", err)
+	}
+	if _, err := ro.Update(ctx, content.Info{Digest: desc.Digest}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly from Update, got %v", err)
+	}
+	if err := ro.Abort(ctx, "w"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected ErrReadOnly from Abort, got %v", err)
+	}
+	if !errdefs.IsFailedPrecondition(ErrReadOnly) {
+		t.Fatal("ErrReadOnly should wrap ErrFailedPrecondition")
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "ingest")); !os.IsNotExist(err) {
+		t.Fatalf("read-only store recreated the ingest directory: %v", err)
+	}
+
+	if _, err := NewReadOnlyStore(filepath.Join(root, "missing")); err == nil {
+		t.Fatal("expected error opening a missing root read-only")
+	}
+}
+
+func TestStoreWithProviders(t *testing.T) {
+	ctx := context.Background()
+
+	seeded, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := []byte("pre-seeded blob")
+	desc := ocispec.Descriptor{Size: int64(len(blob)), Digest: digest.FromBytes(blob)}
+	if err := content.WriteBlob(ctx, seeded, "seed", bytes.NewReader(blob), desc); err != nil {
+		t.Fatal(err)
+	}
+
+	rw, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := NewStoreWithProviders(rw, seeded)
+
+	// served from the read-only provider without copying
+	ra, err := cs.ReaderAt(ctx, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ra.Close()
+	if _, err := rw.Info(ctx, desc.Digest); !errdefs.IsNotFound(err) {
+		t.Fatalf("blob should not have been copied into the writable store: %v", err)
+	}
+
+	// writes still land in the writable store
+	other := []byte("locally written blob")
+	otherDesc := ocispec.Descriptor{Size: int64(len(other)), Digest: digest.FromBytes(other)}
+	if err := content.WriteBlob(ctx, cs, "local", bytes.NewReader(other), otherDesc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cs.ReaderAt(ctx, otherDesc); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package local
+
+import (
+	"context"
+	"sync"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/pkg/filters"
+)
+
+// subscriberBuffer is how many undelivered events a subscriber may
+// accumulate before it is disconnected. Delivery order per ref is
+// guaranteed by publishing under the broadcaster lock, so a slow consumer
+// cannot be skipped around, only dropped.
+const subscriberBuffer = 128
+
+// statusBroadcaster fans ingest status events out to subscribers.
+type statusBroadcaster struct {
+	mu   sync.Mutex
+	subs map[*statusSubscriber]struct{}
+}
+
+type statusSubscriber struct {
+	ch     chan content.StatusEvent
+	filter filters.Filter
+}
+
+func newStatusBroadcaster() *statusBroadcaster {
+	return &statusBroadcaster{subs: map[*statusSubscriber]struct{}{}}
+}
+
+func (b *statusBroadcaster) publish(ev content.StatusEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		if !sub.filter.Match(adaptStatus(ev.Status)) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			// The subscriber cannot keep up, disconnect it rather than
+			// blocking ingests or reordering its events.
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (b *statusBroadcaster) subscribe(ctx context.Context, fs ...string) (<-chan content.StatusEvent, error) {
+	filter, err := filters.ParseAll(fs...)
+	if err != nil {
+		return nil, err
+	}
+	sub := &statusSubscriber{
+		ch:     make(chan content.StatusEvent, subscriberBuffer),
+		filter: filter,
+	}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub.ch)
+		}
+		b.mu.Unlock()
+	}()
+
+	return sub.ch, nil
+}
+
+// SubscribeStatus implements content.StatusTracker: the returned channel
+// receives started, updated, committed and aborted events for ingests
+// matching the filters, in order per ref, until the context ends.
+func (s *store) SubscribeStatus(ctx context.Context, fs ...string) (<-chan content.StatusEvent, error) {
+	return s.broadcaster.subscribe(ctx, fs...)
+}
+
+func (s *store) publishStatus(t content.StatusEventType, status content.Status) {
+	s.broadcaster.publish(content.StatusEvent{Type: t, Status: status})
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package local
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/containerd/containerd/v2/core/content"
+)
+
+func collectEvents(t *testing.T, ch <-chan content.StatusEvent, n int) []content.StatusEvent {
+	t.Helper()
+	var events []content.StatusEvent
+	for len(events) < n {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed after %d events, expected %d", len(events), n)
+			}
+			events = append(events, ev)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timed out after %d events, expected %d", len(events), n)
+		}
+	}
+	return events
+}
+
+func TestSubscribeStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cs, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker, ok := cs.(content.StatusTracker)
+	if !ok {
+		t.Fatal("local store should implement content.StatusTracker")
+	}
+
+	ch, err := tracker.SubscribeStatus(ctx, `ref=="sub-ref"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A non-matching ingest must not produce events on the filtered
+	// subscription.
+	other := []byte("other data")
+	if err := content.WriteBlob(ctx, cs, "other-ref", bytes.NewReader(other),
+		ocispec.Descriptor{Size: int64(len(other)), Digest: digest.FromBytes(other)}); err != nil {
+		t.Fatal(err)
+	}
+
+	blob := []byte("subscribed data")
+	if err := content.WriteBlob(ctx, cs, "sub-ref", bytes.NewReader(blob),
+		ocispec.Descriptor{Size: int64(len(blob)), Digest: digest.FromBytes(blob)}); err != nil {
+		t.Fatal(err)
+	}
+
+	events := collectEvents(t, ch, 3)
+	expected := []content.StatusEventType{
+		content.StatusEventStarted,
+		content.StatusEventUpdated,
+		content.StatusEventCommitted,
+	}
+	for i, ev := range events {
+		if ev.Type != expected[i] {
+			t.Fatalf("event %d: expected %q, got %q", i, expected[i], ev.Type)
+		}
+		if ev.Status.Ref != "sub-ref" {
+			t.Fatalf("event %d: unexpected ref %q", i, ev.Status.Ref)
+		}
+	}
+	if events[2].Status.Expected != digest.FromBytes(blob) {
+		t.Fatalf("committed event should carry the digest, got %v", events[2].Status.Expected)
+	}
+
+	// Aborting an ingest emits an aborted event.
+	w, err := cs.Writer(ctx, content.WithRef("sub-ref"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if err := cs.Abort(ctx, "sub-ref"); err != nil {
+		t.Fatal(err)
+	}
+	events = collectEvents(t, ch, 2)
+	if events[0].Type != content.StatusEventStarted || events[1].Type != content.StatusEventAborted {
+		t.Fatalf("expected started+aborted, got %v, %v", events[0].Type, events[1].Type)
+	}
+
+	// Cancelling the context closes the channel.
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("expected channel to be closed")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("channel not closed after context cancel")
+	}
+}
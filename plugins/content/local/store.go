@@ -70,6 +70,13 @@ type store struct {
 	ls                 LabelStore
 	integritySupported bool
 
+	// driver, when set, holds committed blob data instead of the blobs
+	// tree, which then only keeps sparse marker files. See BlobDriver.
+	driver BlobDriver
+
+	// broadcaster delivers ingest status events to subscribers.
+	broadcaster *statusBroadcaster
+
 	locksMu              sync.Mutex
 	locks                map[string]*lock
 	ensureIngestRootOnce func() error
@@ -103,6 +110,7 @@ func NewLabeledStore(root string, ls LabelStore) (content.Store, error) {
 		ls:                 ls,
 		integritySupported: supported,
 		locks:              map[string]*lock{},
+		broadcaster:        newStatusBroadcaster(),
 	}
 	s.ensureIngestRootOnce = sync.OnceValue(s.ensureIngestRoot)
 	return s, nil
@@ -149,6 +157,17 @@ func (s *store) ReaderAt(ctx context.Context, desc ocispec.Descriptor) (content.
 		return nil, fmt.Errorf("calculating blob path for ReaderAt: %w", err)
 	}
 
+	if s.driver != nil {
+		fi, err := os.Stat(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				err = fmt.Errorf("content %v: %w", desc.Digest, errdefs.ErrNotFound)
+			}
+			return nil, err
+		}
+		return s.driver.ReaderAt(ctx, desc.Digest, fi.Size())
+	}
+
 	reader, err := OpenReader(p)
 	if err != nil {
 		return nil, fmt.Errorf("blob %s expected at %s: %w", desc.Digest, p, err)
@@ -167,6 +186,21 @@ func (s *store) Delete(ctx context.Context, dgst digest.Digest) error {
 		return fmt.Errorf("calculating blob path for delete: %w", err)
 	}
 
+	if s.driver != nil {
+		// Remove the driver's data before the local marker: if the driver
+		// fails the marker is still present and the delete can be retried,
+		// rather than leaking the object in the backing store.
+		if _, err := os.Stat(bp); err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			return fmt.Errorf("content %v: %w", dgst, errdefs.ErrNotFound)
+		}
+		if err := s.driver.Delete(ctx, dgst); err != nil && !errdefs.IsNotFound(err) {
+			return err
+		}
+	}
+
 	if err := os.RemoveAll(bp); err != nil {
 		if !os.IsNotExist(err) {
 			return err
@@ -178,6 +212,19 @@ func (s *store) Delete(ctx context.Context, dgst digest.Digest) error {
 	return nil
 }
 
+// DeleteAll removes the content for each of the provided digests, returning
+// the digests removed before any failure.
+func (s *store) DeleteAll(ctx context.Context, dgsts []digest.Digest) ([]digest.Digest, error) {
+	deleted := make([]digest.Digest, 0, len(dgsts))
+	for _, dgst := range dgsts {
+		if err := s.Delete(ctx, dgst); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, dgst)
+	}
+	return deleted, nil
+}
+
 func (s *store) Update(ctx context.Context, info content.Info, fieldpaths ...string) (content.Info, error) {
 	if s.ls == nil {
 		return content.Info{}, fmt.Errorf("update not supported on immutable content store: %w", errdefs.ErrFailedPrecondition)
@@ -615,6 +662,14 @@ func (s *store) writer(ctx context.Context, ref string, total int64, expected di
 		return nil, fmt.Errorf("could not seek to current write offset: %w", err)
 	}
 
+	s.publishStatus(content.StatusEventStarted, content.Status{
+		Ref:       ref,
+		Offset:    offset,
+		Total:     total,
+		StartedAt: startedAt,
+		UpdatedAt: updatedAt,
+	})
+
 	return &writer{
 		s:         s,
 		fp:        fp,
@@ -640,6 +695,8 @@ func (s *store) Abort(ctx context.Context, ref string) error {
 		return err
 	}
 
+	s.publishStatus(content.StatusEventAborted, content.Status{Ref: ref, UpdatedAt: time.Now()})
+
 	return nil
 }
 
@@ -73,6 +73,13 @@ func (w *writer) Write(p []byte) (n int, err error) {
 	w.digester.Hash().Write(p[:n])
 	w.offset += int64(len(p))
 	w.updatedAt = time.Now()
+	w.s.publishStatus(content.StatusEventUpdated, content.Status{
+		Ref:       w.ref,
+		Offset:    w.offset,
+		Total:     w.total,
+		StartedAt: w.startedAt,
+		UpdatedAt: w.updatedAt,
+	})
 	return n, err
 }
 
@@ -153,7 +160,38 @@ func (w *writer) Commit(ctx context.Context, size int64, expected digest.Digest,
 		return fmt.Errorf("content %v: %w", dgst, errdefs.ErrAlreadyExists)
 	}
 
-	if err := os.Rename(ingest, target); err != nil {
+	if w.s.driver != nil {
+		// Hand the verified data to the driver and leave a sparse marker
+		// file of the blob's size in the blobs tree so stat-based queries
+		// and walks keep working locally.
+		f, err := os.Open(ingest)
+		if err != nil {
+			return fmt.Errorf("failed to open ingest data for driver commit: %w", err)
+		}
+		err = w.s.driver.Put(ctx, dgst, f, fi.Size())
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("driver commit of blob %v failed: %w", dgst, err)
+		}
+		if err := os.Remove(ingest); err != nil {
+			log.G(ctx).WithField("ref", w.ref).WithError(err).Error("failed to remove ingest data after driver commit")
+		}
+		marker, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			if os.IsExist(err) {
+				// lost a commit race, the content is already present
+				return fmt.Errorf("content %v: %w", dgst, errdefs.ErrAlreadyExists)
+			}
+			return err
+		}
+		truncErr := marker.Truncate(fi.Size())
+		if err := marker.Close(); truncErr == nil {
+			truncErr = err
+		}
+		if truncErr != nil {
+			return fmt.Errorf("failed to write blob marker for %v: %w", dgst, truncErr)
+		}
+	} else if err := os.Rename(ingest, target); err != nil {
 		return err
 	}
 
@@ -162,7 +200,7 @@ func (w *writer) Commit(ctx context.Context, size int64, expected digest.Digest,
 	}
 	// Enable content blob integrity verification if supported
 
-	if w.s.integritySupported {
+	if w.s.integritySupported && w.s.driver == nil {
 		if err := fsverity.Enable(target); err != nil {
 			log.G(ctx).Warnf("failed to enable integrity for blob %v: %s", target, err.Error())
 		}
@@ -188,6 +226,15 @@ func (w *writer) Commit(ctx context.Context, size int64, expected digest.Digest,
 		}
 	}
 
+	w.s.publishStatus(content.StatusEventCommitted, content.Status{
+		Ref:       w.ref,
+		Offset:    fi.Size(),
+		Total:     fi.Size(),
+		Expected:  dgst,
+		StartedAt: w.startedAt,
+		UpdatedAt: commitTime,
+	})
+
 	// change to readonly, more important for read, but provides _some_
 	// protection from this point on. We use the existing perms with a mask
 	// only allowing reads honoring the umask on creation.
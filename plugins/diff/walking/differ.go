@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/containerd/errdefs"
@@ -37,6 +38,7 @@ import (
 	"github.com/containerd/containerd/v2/pkg/archive/compression"
 	"github.com/containerd/containerd/v2/pkg/epoch"
 	"github.com/containerd/containerd/v2/pkg/labels"
+	"github.com/containerd/continuity/fs"
 )
 
 type walkingDiff struct {
@@ -97,9 +99,35 @@ func (s *walkingDiff) Compare(ctx context.Context, lower, upper []mount.Mount, o
 		}
 	}
 
+	// When the upper mounts are a plain overlay, the changeset can be read
+	// straight off the upperdir (interpreting whiteouts and opaque xattrs)
+	// instead of mounting the snapshot and walking both trees.
+	writeChanges := func(ctx context.Context, w io.Writer, lowerRoot, upperRoot string) error {
+		return archive.WriteDiff(ctx, w, lowerRoot, upperRoot, writeDiffOpts...)
+	}
+	withUpperRoot := func(ctx context.Context, fn func(upperRoot string) error) error {
+		return mount.WithReadonlyTempMount(ctx, upper, fn)
+	}
+	if upperDir, ok := overlayUpperdir(upper); ok {
+		var cwOpts []archive.ChangeWriterOpt
+		if config.SourceDateEpoch != nil {
+			cwOpts = append(cwOpts, archive.WithModTimeUpperBound(*config.SourceDateEpoch))
+		}
+		writeChanges = func(ctx context.Context, w io.Writer, lowerRoot, upperRoot string) error {
+			cw := archive.NewChangeWriter(w, upperRoot, cwOpts...)
+			if err := fs.DiffDirChanges(ctx, lowerRoot, upperRoot, fs.DiffSourceOverlayFS, cw.HandleChange); err != nil {
+				return fmt.Errorf("failed to create diff tar stream: %w", err)
+			}
+			return cw.Close()
+		}
+		withUpperRoot = func(ctx context.Context, fn func(upperRoot string) error) error {
+			return fn(upperDir)
+		}
+	}
+
 	var ocidesc ocispec.Descriptor
 	if err := mount.WithTempMount(ctx, lower, func(lowerRoot string) error {
-		return mount.WithReadonlyTempMount(ctx, upper, func(upperRoot string) error {
+		return withUpperRoot(ctx, func(upperRoot string) error {
 			var newReference bool
 			if config.Reference == "" {
 				newReference = true
@@ -148,7 +176,7 @@ func (s *walkingDiff) Compare(ctx context.Context, lower, upper []mount.Mount, o
 						return fmt.Errorf("failed to get compressed stream: %w", errOpen)
 					}
 				}
-				errOpen = archive.WriteDiff(ctx, io.MultiWriter(compressed, dgstr.Hash()), lowerRoot, upperRoot, writeDiffOpts...)
+				errOpen = writeChanges(ctx, io.MultiWriter(compressed, dgstr.Hash()), lowerRoot, upperRoot)
 				compressed.Close()
 				if errOpen != nil {
 					return fmt.Errorf("failed to write compressed diff: %w", errOpen)
@@ -159,7 +187,7 @@ func (s *walkingDiff) Compare(ctx context.Context, lower, upper []mount.Mount, o
 				}
 				config.Labels[labels.LabelUncompressed] = dgstr.Digest().String()
 			} else {
-				if errOpen = archive.WriteDiff(ctx, cw, lowerRoot, upperRoot, writeDiffOpts...); errOpen != nil {
+				if errOpen = writeChanges(ctx, cw, lowerRoot, upperRoot); errOpen != nil {
 					return fmt.Errorf("failed to write diff: %w", errOpen)
 				}
 			}
@@ -206,6 +234,35 @@ func (s *walkingDiff) Compare(ctx context.Context, lower, upper []mount.Mount, o
 	return ocidesc, nil
 }
 
+// overlayUpperdir reports the upperdir of a plain overlay (or the source
+// of a bind mounted first layer) whose changeset is safe to read directly:
+// metacopy, redirect_dir and userxattr rewrite upperdir entries in ways
+// the direct walk does not interpret, those fall back to mounting and
+// walking both trees.
+func overlayUpperdir(upper []mount.Mount) (string, bool) {
+	if len(upper) != 1 {
+		return "", false
+	}
+	m := upper[0]
+	if m.Type != "overlay" {
+		// bind mounted snapshots carry the fully materialized filesystem,
+		// not a changeset
+		return "", false
+	}
+	var upperDir string
+	for _, opt := range m.Options {
+		if v, ok := strings.CutPrefix(opt, "upperdir="); ok {
+			upperDir = v
+			continue
+		}
+		if strings.HasPrefix(opt, "metacopy=") || opt == "userxattr" ||
+			opt == "redirect_dir=on" || opt == "redirect_dir=follow" {
+			return "", false
+		}
+	}
+	return upperDir, upperDir != ""
+}
+
 func uniqueRef() string {
 	t := time.Now()
 	var b [3]byte
@@ -0,0 +1,258 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package walking
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/diff"
+	"github.com/containerd/containerd/v2/core/diff/apply"
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
+	"github.com/containerd/containerd/v2/pkg/testutil"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type memoryLabelStore struct {
+	mu     sync.Mutex
+	labels map[digest.Digest]map[string]string
+}
+
+func newMemoryLabelStore() *memoryLabelStore {
+	return &memoryLabelStore{labels: map[digest.Digest]map[string]string{}}
+}
+
+func (s *memoryLabelStore) Get(d digest.Digest) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.labels[d], nil
+}
+
+func (s *memoryLabelStore) Set(d digest.Digest, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.labels[d] = labels
+	return nil
+}
+
+func (s *memoryLabelStore) Update(d digest.Digest, update map[string]string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	labels := s.labels[d]
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range update {
+		if v == "" {
+			delete(labels, k)
+		} else {
+			labels[k] = v
+		}
+	}
+	s.labels[d] = labels
+	return labels, nil
+}
+
+// TestZstdDiffRoundTrip proves a zstd compressed diff produced by the
+// walking differ applies back onto a fresh snapshot.
+func TestZstdDiffRoundTrip(t *testing.T) {
+	testutil.RequiresRoot(t)
+	ctx := context.Background()
+
+	store, err := local.NewLabeledStore(t.TempDir(), newMemoryLabelStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upperDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(upperDir, "data.txt"), []byte("zstd round trip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lowerDir := t.TempDir()
+
+	bind := func(dir string, ro bool) []mount.Mount {
+		opts := []string{"rbind"}
+		if ro {
+			opts = append(opts, "ro")
+		} else {
+			opts = append(opts, "rw")
+		}
+		return []mount.Mount{{Type: "bind", Source: dir, Options: opts}}
+	}
+
+	differ := NewWalkingDiff(store)
+	desc, err := differ.Compare(ctx, bind(lowerDir, true), bind(upperDir, true),
+		diff.WithMediaType(ocispec.MediaTypeImageLayerZstd),
+		diff.WithReference("zstd-diff"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.MediaType != ocispec.MediaTypeImageLayerZstd {
+		t.Fatalf("expected zstd media type, got %q", desc.MediaType)
+	}
+
+	// the blob must really be zstd
+	ra, err := store.ReaderAt(ctx, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := make([]byte, 4)
+	ra.ReadAt(head, 0)
+	ra.Close()
+	if compression.DetectCompression(head) != compression.Zstd {
+		t.Fatalf("blob does not look like zstd: %x", head)
+	}
+
+	target := t.TempDir()
+	applier := apply.NewFileSystemApplier(store)
+	applied, err := applier.Apply(ctx, desc, bind(target, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Digest == "" {
+		t.Fatal("apply returned no digest")
+	}
+
+	data, err := os.ReadFile(filepath.Join(target, "data.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte("zstd round trip")) {
+		t.Fatalf("unexpected content after apply: %q", data)
+	}
+}
+
+// TestApplyFilters proves exclude patterns and xattr tolerance travel
+// through the apply payload.
+func TestApplyFilters(t *testing.T) {
+	testutil.RequiresRoot(t)
+	ctx := context.Background()
+
+	store, err := local.NewLabeledStore(t.TempDir(), newMemoryLabelStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upperDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(upperDir, "keep.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(upperDir, "usr/share/doc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(upperDir, "usr/share/doc/README"), []byte("doc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	bind := func(dir string, ro bool) []mount.Mount {
+		opts := []string{"rbind"}
+		if ro {
+			opts = append(opts, "ro")
+		} else {
+			opts = append(opts, "rw")
+		}
+		return []mount.Mount{{Type: "bind", Source: dir, Options: opts}}
+	}
+
+	differ := NewWalkingDiff(store)
+	desc, err := differ.Compare(ctx, bind(t.TempDir(), true), bind(upperDir, true),
+		diff.WithMediaType(ocispec.MediaTypeImageLayer), diff.WithReference("filters"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := t.TempDir()
+	applier := apply.NewFileSystemApplier(store)
+	if _, err := applier.Apply(ctx, desc, bind(target, false), diff.WithApplyFilters(diff.ApplyFilters{
+		ExcludePatterns:   []string{"usr/share/doc/*"},
+		IgnoreXattrErrors: true,
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(target, "keep.txt")); err != nil {
+		t.Fatalf("kept file missing: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "usr/share/doc/README")); !os.IsNotExist(err) {
+		t.Fatalf("excluded path was extracted: %v", err)
+	}
+}
+
+// TestApplyIDMappingFallback verifies the explicit ownership remap applies
+// when the target mounts are not idmapped.
+func TestApplyIDMappingFallback(t *testing.T) {
+	testutil.RequiresRoot(t)
+	ctx := context.Background()
+
+	store, err := local.NewLabeledStore(t.TempDir(), newMemoryLabelStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upperDir := t.TempDir()
+	p := filepath.Join(upperDir, "owned.txt")
+	if err := os.WriteFile(p, []byte("owned"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chown(p, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	bind := func(dir string, ro bool) []mount.Mount {
+		opts := []string{"rbind"}
+		if ro {
+			opts = append(opts, "ro")
+		} else {
+			opts = append(opts, "rw")
+		}
+		return []mount.Mount{{Type: "bind", Source: dir, Options: opts}}
+	}
+
+	differ := NewWalkingDiff(store)
+	desc, err := differ.Compare(ctx, bind(t.TempDir(), true), bind(upperDir, true),
+		diff.WithMediaType(ocispec.MediaTypeImageLayer), diff.WithReference("idmap"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := t.TempDir()
+	applier := apply.NewFileSystemApplier(store)
+	if _, err := applier.Apply(ctx, desc, bind(target, false), diff.WithIDMapping(diff.ApplyIDMapping{
+		UIDMappings: []diff.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+		GIDMappings: []diff.LinuxIDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}},
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(filepath.Join(target, "owned.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := fi.Sys().(*syscall.Stat_t)
+	if st.Uid != 100001 || st.Gid != 100001 {
+		t.Fatalf("expected remapped ownership 100001:100001, got %d:%d", st.Uid, st.Gid)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package walking
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/diff"
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/pkg/testutil"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestOverlayUpperdirDetection(t *testing.T) {
+	for _, tc := range []struct {
+		options []string
+		upper   string
+	}{
+		{options: []string{"lowerdir=/l", "upperdir=/u", "workdir=/w"}, upper: "/u"},
+		{options: []string{"lowerdir=/l", "upperdir=/u", "workdir=/w", "volatile"}, upper: "/u"},
+		{options: []string{"lowerdir=/l", "upperdir=/u", "workdir=/w", "metacopy=on"}},
+		{options: []string{"lowerdir=/l", "upperdir=/u", "workdir=/w", "redirect_dir=on"}},
+		{options: []string{"lowerdir=/l", "upperdir=/u", "workdir=/w", "userxattr"}},
+		{options: []string{"lowerdir=/l"}},
+	} {
+		got, ok := overlayUpperdir([]mount.Mount{{Type: "overlay", Options: tc.options}})
+		if tc.upper == "" {
+			if ok {
+				t.Errorf("expected %v to fall back, got upperdir %q", tc.options, got)
+			}
+		} else if !ok || got != tc.upper {
+			t.Errorf("expected upperdir %q for %v, got %q (ok=%v)", tc.upper, tc.options, got, ok)
+		}
+	}
+	if _, ok := overlayUpperdir([]mount.Mount{{Type: "bind", Source: "/some/fs", Options: []string{"rbind", "rw"}}}); ok {
+		t.Error("bind mounts must not take the upperdir fast path")
+	}
+}
+
+// TestOverlayFastPathMatchesWalk verifies the upperdir fast path produces
+// the same layer digest as the double-mount walk for the same change set.
+func TestOverlayFastPathMatchesWalk(t *testing.T) {
+	testutil.RequiresRoot(t)
+	ctx := context.Background()
+
+	var (
+		root   = t.TempDir()
+		lower  = filepath.Join(root, "lower")
+		upper  = filepath.Join(root, "upper")
+		work   = filepath.Join(root, "work")
+		merged = filepath.Join(root, "merged")
+	)
+	for _, dir := range []string{lower, upper, work, merged} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(lower, "keep.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(lower, "delete.txt"), []byte("bye"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Produce real overlay changes: add, modify and delete. Opaque
+	// directories are intentionally not part of the comparison: the fast
+	// path emits the overlay-native opaque whiteout while the mounted
+	// walk expands it into per-child whiteouts; both are valid OCI
+	// representations of the same change but not byte-identical.
+	m := mount.Mount{
+		Type:   "overlay",
+		Source: "overlay",
+		Options: []string{
+			"lowerdir=" + lower,
+			"upperdir=" + upper,
+			"workdir=" + work,
+		},
+	}
+	if err := m.Mount(merged); err != nil {
+		t.Skipf("failed to mount overlay: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(merged, "new.txt"), []byte("new"), 0644); err != nil {
+		testutil.Unmount(t, merged)
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(merged, "keep.txt"), []byte("changed"), 0644); err != nil {
+		testutil.Unmount(t, merged)
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(merged, "delete.txt")); err != nil {
+		testutil.Unmount(t, merged)
+		t.Fatal(err)
+	}
+	testutil.Unmount(t, merged)
+
+	store, err := local.NewLabeledStore(t.TempDir(), newMemoryLabelStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	differ := NewWalkingDiff(store)
+
+	lowerMounts := []mount.Mount{{Type: "bind", Source: lower, Options: []string{"rbind", "ro"}}}
+	upperMounts := []mount.Mount{m}
+
+	fast, err := differ.Compare(ctx, lowerMounts, upperMounts,
+		diff.WithMediaType(ocispec.MediaTypeImageLayer), diff.WithReference("fast"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// metacopy forces the double-mount fallback over the same data
+	fallbackMount := m
+	fallbackMount.Options = append(append([]string{}, m.Options...), "metacopy=off")
+	if _, ok := overlayUpperdir([]mount.Mount{fallbackMount}); ok {
+		t.Fatal("metacopy option should force the fallback")
+	}
+	slow, err := differ.Compare(ctx, lowerMounts, []mount.Mount{fallbackMount},
+		diff.WithMediaType(ocispec.MediaTypeImageLayer), diff.WithReference("slow"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fast.Digest != slow.Digest {
+		t.Fatalf("fast path digest %s differs from walk digest %s", fast.Digest, slow.Digest)
+	}
+}
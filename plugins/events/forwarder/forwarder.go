@@ -0,0 +1,156 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package forwarder delivers event envelopes to external sinks (HTTP
+// webhooks, exec binaries) from inside the daemon, replacing forwarding
+// sidecars. Delivery is fully decoupled from the exchange: envelopes go
+// through a bounded queue that drops the oldest entry under pressure, so
+// a slow sink can never block publishing.
+package forwarder
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	metrics "github.com/docker/go-metrics"
+
+	"github.com/containerd/log"
+
+	"github.com/containerd/containerd/v2/core/events"
+)
+
+// defaultQueueSize bounds the forwarder's envelope queue.
+const defaultQueueSize = 1024
+
+var (
+	forwardedCounter metrics.LabeledCounter
+	droppedCounter   metrics.Counter
+	metricsOnce      sync.Once
+)
+
+func registerMetrics() {
+	metricsOnce.Do(func() {
+		ns := metrics.NewNamespace("containerd", "events_forwarder", nil)
+		forwardedCounter = ns.NewLabeledCounter("forwarded", "envelopes delivered to external sinks", "sink")
+		droppedCounter = ns.NewCounter("dropped", "envelopes dropped because the forward queue was full")
+		metrics.Register(ns)
+	})
+}
+
+// Sink delivers one JSON-encoded envelope to an external system.
+type Sink interface {
+	// Name identifies the sink in logs and metrics.
+	Name() string
+	// Deliver sends one JSON-encoded envelope; it may block and retry
+	// internally, the forwarder's queue absorbs the latency.
+	Deliver(ctx context.Context, payload []byte) error
+}
+
+// payloadEnvelope is the wire encoding sent to sinks, one JSON object per
+// event.
+type payloadEnvelope struct {
+	Timestamp time.Time `json:"timestamp"`
+	Namespace string    `json:"namespace"`
+	Topic     string    `json:"topic"`
+	TypeURL   string    `json:"type_url,omitempty"`
+	Value     []byte    `json:"value,omitempty"`
+}
+
+// Forwarder consumes envelopes and fans them out to its sinks.
+type Forwarder struct {
+	queue chan *events.Envelope
+	sinks []Sink
+	done  chan struct{}
+}
+
+// New returns a forwarder delivering to the given sinks through a
+// bounded queue of the given size (defaultQueueSize when zero or less).
+func New(queueSize int, sinks ...Sink) *Forwarder {
+	registerMetrics()
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	return &Forwarder{
+		queue: make(chan *events.Envelope, queueSize),
+		sinks: sinks,
+		done:  make(chan struct{}),
+	}
+}
+
+// Enqueue adds the envelope for delivery without ever blocking: when the
+// queue is full the oldest queued envelope is dropped and counted.
+func (f *Forwarder) Enqueue(envelope *events.Envelope) {
+	for {
+		select {
+		case f.queue <- envelope:
+			return
+		default:
+		}
+		select {
+		case <-f.queue:
+			// drop the oldest entry to make room
+			droppedCounter.Inc(1)
+		default:
+		}
+	}
+}
+
+// Run delivers queued envelopes until the context ends.
+func (f *Forwarder) Run(ctx context.Context) {
+	defer close(f.done)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case envelope := <-f.queue:
+			f.deliver(ctx, envelope)
+		}
+	}
+}
+
+// Wait blocks until Run has returned.
+func (f *Forwarder) Wait() {
+	<-f.done
+}
+
+func (f *Forwarder) deliver(ctx context.Context, envelope *events.Envelope) {
+	p := payloadEnvelope{
+		Timestamp: envelope.Timestamp,
+		Namespace: envelope.Namespace,
+		Topic:     envelope.Topic,
+	}
+	if envelope.Event != nil {
+		p.TypeURL = envelope.Event.GetTypeUrl()
+		p.Value = envelope.Event.GetValue()
+	}
+	payload, err := json.Marshal(p)
+	if err != nil {
+		log.G(ctx).WithError(err).Warn("failed to encode event for forwarding")
+		return
+	}
+	for _, sink := range f.sinks {
+		if err := sink.Deliver(ctx, payload); err != nil {
+			log.G(ctx).WithError(err).WithFields(log.Fields{
+				"sink":  sink.Name(),
+				"topic": envelope.Topic,
+			}).Warn("failed to forward event")
+			continue
+		}
+		forwardedCounter.WithValues(sink.Name()).Inc(1)
+	}
+}
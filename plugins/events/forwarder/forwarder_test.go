@@ -0,0 +1,194 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/events"
+)
+
+// fakeSink records delivered payloads.
+type fakeSink struct {
+	mu       sync.Mutex
+	payloads [][]byte
+	fail     atomic.Bool
+}
+
+func (f *fakeSink) Name() string { return "fake" }
+
+func (f *fakeSink) Deliver(ctx context.Context, payload []byte) error {
+	if f.fail.Load() {
+		return errors.New("sink down")
+	}
+	f.mu.Lock()
+	f.payloads = append(f.payloads, payload)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeSink) topics(t *testing.T) []string {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var topics []string
+	for _, p := range f.payloads {
+		var pe payloadEnvelope
+		if err := json.Unmarshal(p, &pe); err != nil {
+			t.Fatal(err)
+		}
+		topics = append(topics, pe.Topic)
+	}
+	return topics
+}
+
+func envelope(topic string) *events.Envelope {
+	return &events.Envelope{
+		Timestamp: time.Now().UTC(),
+		Namespace: "test",
+		Topic:     topic,
+	}
+}
+
+func TestForwarderDelivers(t *testing.T) {
+	sink := &fakeSink{}
+	f := New(8, sink)
+	ctx, cancel := context.WithCancel(context.Background())
+	go f.Run(ctx)
+
+	f.Enqueue(envelope("/tasks/start"))
+	f.Enqueue(envelope("/tasks/exit"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sink.topics(t)) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	f.Wait()
+
+	topics := sink.topics(t)
+	if len(topics) != 2 || topics[0] != "/tasks/start" || topics[1] != "/tasks/exit" {
+		t.Fatalf("unexpected deliveries %v", topics)
+	}
+}
+
+func TestForwarderDropsOldest(t *testing.T) {
+	sink := &fakeSink{}
+	f := New(2, sink)
+	// no Run: the queue fills and the oldest entries must give way
+	for i := 0; i < 5; i++ {
+		f.Enqueue(envelope("/topic/" + string(rune('a'+i))))
+	}
+	if len(f.queue) != 2 {
+		t.Fatalf("queue size %d, want 2", len(f.queue))
+	}
+	// drain: only the newest two remain
+	got := []string{(<-f.queue).Topic, (<-f.queue).Topic}
+	if got[0] != "/topic/d" || got[1] != "/topic/e" {
+		t.Fatalf("expected newest entries to survive, got %v", got)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		bodies   []string
+		sigs     []string
+		failures atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures.Load() > 0 {
+			failures.Add(-1)
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(b))
+		sigs = append(sigs, r.Header.Get(signatureHeader))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookSink(srv.URL, "sekret", 2, time.Second)
+	sink.backoff = 10 * time.Millisecond
+
+	payload := []byte(`{"topic":"/tasks/exit"}`)
+	if err := sink.Deliver(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+
+	// transient failures are retried
+	failures.Store(1)
+	if err := sink.Deliver(context.Background(), payload); err != nil {
+		t.Fatalf("delivery not retried: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(bodies))
+	}
+	mac := hmac.New(sha256.New, []byte("sekret"))
+	mac.Write(payload)
+	want := hex.EncodeToString(mac.Sum(nil))
+	if sigs[0] != want {
+		t.Fatalf("bad signature %q, want %q", sigs[0], want)
+	}
+}
+
+func TestExecSink(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "events.jsonl")
+	script := filepath.Join(t.TempDir(), "consume.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat >> "+out+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	sink := NewExecSink(script)
+	defer sink.Close()
+
+	for _, topic := range []string{"/a", "/b"} {
+		if err := sink.Deliver(context.Background(), []byte(`{"topic":"`+topic+`"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sink.Close()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "/a") || !strings.Contains(lines[1], "/b") {
+		t.Fatalf("unexpected consumer output %q", data)
+	}
+}
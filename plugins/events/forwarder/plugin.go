@@ -0,0 +1,132 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/containerd/log"
+	"github.com/containerd/plugin"
+	"github.com/containerd/plugin/registry"
+
+	"github.com/containerd/containerd/v2/core/events/exchange"
+	"github.com/containerd/containerd/v2/internal/tomlext"
+	"github.com/containerd/containerd/v2/plugins"
+)
+
+// WebhookConfig configures one HTTP webhook sink.
+type WebhookConfig struct {
+	// URL receives a POST per envelope.
+	URL string `toml:"url"`
+	// Secret enables HMAC-SHA256 signing of the body, carried in the
+	// X-Containerd-Signature header.
+	Secret string `toml:"secret"`
+	// Retries is how many times a failed delivery is retried.
+	Retries int `toml:"retries"`
+	// Timeout bounds each HTTP request.
+	Timeout tomlext.Duration `toml:"timeout"`
+}
+
+// ExecConfig configures one exec sink receiving JSON lines on stdin.
+type ExecConfig struct {
+	// Path of the consumer binary.
+	Path string `toml:"path"`
+	// Args passed to the binary.
+	Args []string `toml:"args"`
+}
+
+// Config is the event forwarder configuration; with no sinks the plugin
+// stays disabled.
+type Config struct {
+	// Filters restrict which envelopes are forwarded, using the usual
+	// subscription filter syntax.
+	Filters []string `toml:"filters"`
+	// QueueSize bounds the delivery queue; the oldest envelope is
+	// dropped when it overflows.
+	QueueSize int `toml:"queue_size"`
+	// Webhooks are HTTP sinks.
+	Webhooks []WebhookConfig `toml:"webhooks"`
+	// Execs are binary sinks fed JSON lines on stdin.
+	Execs []ExecConfig `toml:"execs"`
+}
+
+func init() {
+	registry.Register(&plugin.Registration{
+		Type: plugins.InternalPlugin,
+		ID:   "event-forwarder",
+		Requires: []plugin.Type{
+			plugins.EventPlugin,
+		},
+		Config: &Config{
+			QueueSize: defaultQueueSize,
+		},
+		InitFn: func(ic *plugin.InitContext) (any, error) {
+			config := ic.Config.(*Config)
+			var sinks []Sink
+			for _, w := range config.Webhooks {
+				if w.URL == "" {
+					return nil, fmt.Errorf("webhook sink requires a url")
+				}
+				sinks = append(sinks, NewWebhookSink(w.URL, w.Secret, w.Retries, tomlext.ToStdTime(w.Timeout)))
+			}
+			for _, e := range config.Execs {
+				if e.Path == "" {
+					return nil, fmt.Errorf("exec sink requires a path")
+				}
+				sinks = append(sinks, NewExecSink(e.Path, e.Args...))
+			}
+			if len(sinks) == 0 {
+				return nil, fmt.Errorf("no event sinks configured: %w", plugin.ErrSkipPlugin)
+			}
+
+			ep, err := ic.GetByID(plugins.EventPlugin, "exchange")
+			if err != nil {
+				return nil, err
+			}
+
+			ctx := ic.Context
+			f := New(config.QueueSize, sinks...)
+			go f.Run(ctx)
+			go func() {
+				for ctx.Err() == nil {
+					ch, errs := ep.(*exchange.Exchange).Subscribe(ctx, config.Filters...)
+				consume:
+					for {
+						select {
+						case envelope, ok := <-ch:
+							if !ok {
+								break consume
+							}
+							f.Enqueue(envelope)
+						case err := <-errs:
+							if err != nil {
+								log.G(ctx).WithError(err).Warn("event forwarder subscription failed, resubscribing")
+							}
+							break consume
+						}
+					}
+					select {
+					case <-time.After(time.Second):
+					case <-ctx.Done():
+					}
+				}
+			}()
+			return f, nil
+		},
+	})
+}
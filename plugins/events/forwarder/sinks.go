@@ -0,0 +1,211 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package forwarder
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// signatureHeader carries the hex HMAC-SHA256 of the request body when a
+// webhook secret is configured.
+const signatureHeader = "X-Containerd-Signature"
+
+// WebhookSink posts envelopes to an HTTP endpoint, retrying transient
+// failures with backoff and signing bodies with HMAC-SHA256 when a
+// secret is set.
+type WebhookSink struct {
+	url     string
+	secret  []byte
+	retries int
+	backoff time.Duration
+	client  *http.Client
+}
+
+// NewWebhookSink returns a webhook sink for the URL. A zero timeout
+// defaults to 10s, negative retries to 0.
+func NewWebhookSink(url, secret string, retries int, timeout time.Duration) *WebhookSink {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	return &WebhookSink{
+		url:     url,
+		secret:  []byte(secret),
+		retries: retries,
+		backoff: time.Second,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Name implements Sink.
+func (w *WebhookSink) Name() string {
+	return "webhook:" + w.url
+}
+
+// Deliver implements Sink.
+func (w *WebhookSink) Deliver(ctx context.Context, payload []byte) error {
+	var lastErr error
+	for attempt := 0; attempt <= w.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(w.backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if lastErr = w.post(ctx, payload); lastErr == nil {
+			return nil
+		}
+		var se *statusError
+		if errors.As(lastErr, &se) && !se.retryable() {
+			// a definitive client error will not improve with retries
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// statusError is a non-2xx webhook response.
+type statusError struct {
+	url  string
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("webhook %s returned status %d", e.url, e.code)
+}
+
+// retryable reports whether the status may succeed on retry: server
+// errors and the throttling/timeout client codes do, other 4xx will not.
+func (e *statusError) retryable() bool {
+	return e.code >= 500 || e.code == http.StatusTooManyRequests || e.code == http.StatusRequestTimeout
+}
+
+func (w *WebhookSink) post(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.secret) > 0 {
+		mac := hmac.New(sha256.New, w.secret)
+		mac.Write(payload)
+		req.Header.Set(signatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{url: w.url, code: resp.StatusCode}
+	}
+	return nil
+}
+
+// ExecSink pipes JSON lines to a long-running binary's stdin, in the
+// manner of logging binaries. The process is (re)started on demand, so a
+// crashed consumer only loses the envelopes delivered while it was down.
+type ExecSink struct {
+	path string
+	args []string
+
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+// NewExecSink returns an exec sink running the binary at path with args.
+func NewExecSink(path string, args ...string) *ExecSink {
+	return &ExecSink{path: path, args: args}
+}
+
+// Name implements Sink.
+func (e *ExecSink) Name() string {
+	return "exec:" + e.path
+}
+
+// Deliver implements Sink.
+func (e *ExecSink) Deliver(ctx context.Context, payload []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stdin == nil {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	if _, err := e.stdin.Write(append(payload, '\n')); err != nil {
+		// the consumer died; reap it and retry once with a fresh one
+		e.stop()
+		if serr := e.start(); serr != nil {
+			return serr
+		}
+		if _, werr := e.stdin.Write(append(payload, '\n')); werr != nil {
+			e.stop()
+			return werr
+		}
+	}
+	return nil
+}
+
+// Close terminates the consumer process, if running.
+func (e *ExecSink) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stop()
+	return nil
+}
+
+func (e *ExecSink) start() error {
+	cmd := exec.Command(e.path, e.args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		return err
+	}
+	e.cmd = cmd
+	e.stdin = stdin
+	return nil
+}
+
+func (e *ExecSink) stop() {
+	if e.stdin != nil {
+		e.stdin.Close()
+		e.stdin = nil
+	}
+	if e.cmd != nil {
+		e.cmd.Wait()
+		e.cmd = nil
+	}
+}
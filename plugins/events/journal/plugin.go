@@ -0,0 +1,118 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package journal registers the optional event journal plugin. When
+// enabled it records every envelope crossing the exchange into a bounded
+// bolt database which the events service replays for subscribers passing
+// a from position.
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containerd/log"
+	"github.com/containerd/plugin"
+	"github.com/containerd/plugin/registry"
+
+	"github.com/containerd/containerd/v2/core/events/exchange"
+	"github.com/containerd/containerd/v2/core/events/journal"
+	"github.com/containerd/containerd/v2/internal/tomlext"
+	"github.com/containerd/containerd/v2/plugins"
+)
+
+// Config is the event journal configuration.
+type Config struct {
+	// Enable turns the journal on; it is off by default since it adds a
+	// write per event.
+	Enable bool `toml:"enable"`
+	// MaxEntries bounds how many envelopes are retained.
+	MaxEntries uint64 `toml:"max_entries"`
+	// MaxAge drops envelopes older than this during pruning.
+	MaxAge tomlext.Duration `toml:"max_age"`
+}
+
+func init() {
+	registry.Register(&plugin.Registration{
+		Type: plugins.InternalPlugin,
+		ID:   "event-journal",
+		Requires: []plugin.Type{
+			plugins.EventPlugin,
+		},
+		Config: &Config{
+			MaxEntries: 8192,
+			MaxAge:     tomlext.FromStdTime(24 * time.Hour),
+		},
+		InitFn: func(ic *plugin.InitContext) (any, error) {
+			config := ic.Config.(*Config)
+			if !config.Enable {
+				return nil, fmt.Errorf("event journal is disabled: %w", plugin.ErrSkipPlugin)
+			}
+			ep, err := ic.GetByID(plugins.EventPlugin, "exchange")
+			if err != nil {
+				return nil, err
+			}
+			root := ic.Properties[plugins.PropertyRootDir]
+			if err := os.MkdirAll(root, 0711); err != nil {
+				return nil, err
+			}
+			j, err := journal.Open(filepath.Join(root, "events.db"),
+				journal.WithMaxEntries(config.MaxEntries),
+				journal.WithMaxAge(tomlext.ToStdTime(config.MaxAge)),
+			)
+			if err != nil {
+				return nil, err
+			}
+
+			ctx := ic.Context
+			go func() {
+				for ctx.Err() == nil {
+					// opt out of coalescing so the journal records raw
+					// events with their real timestamps, not opaque
+					// batches
+					ch, errs := ep.(*exchange.Exchange).Subscribe(ctx, `coalesce=="false"`)
+				consume:
+					for {
+						select {
+						case envelope, ok := <-ch:
+							if !ok {
+								break consume
+							}
+							if _, err := j.Append(envelope); err != nil {
+								log.G(ctx).WithError(err).Warn("failed to journal event")
+							}
+						case err := <-errs:
+							if err != nil {
+								// e.g. the bounded subscriber queue
+								// overflowed; resubscribe rather than
+								// silently stop journaling
+								log.G(ctx).WithError(err).Warn("event journal subscription failed, resubscribing")
+							}
+							break consume
+						}
+					}
+					select {
+					case <-time.After(time.Second):
+					case <-ctx.Done():
+					}
+				}
+			}()
+			return j, nil
+		},
+	})
+}
@@ -18,17 +18,45 @@ package events
 
 import (
 	"github.com/containerd/containerd/v2/core/events/exchange"
+	"github.com/containerd/containerd/v2/internal/tomlext"
 	"github.com/containerd/containerd/v2/plugins"
 	"github.com/containerd/plugin"
 	"github.com/containerd/plugin/registry"
 )
 
+// Config tunes the exchange for high-churn workloads.
+type Config struct {
+	// Coalesce batches events on the given topic prefixes, delivering at
+	// most one envelope per interval per (namespace, topic).
+	Coalesce map[string]tomlext.Duration `toml:"coalesce"`
+	// PublishRate bounds events per second accepted from publishers;
+	// zero means unlimited. Publishers over the limit block rather than
+	// lose events.
+	PublishRate float64 `toml:"publish_rate"`
+	// PublishBurst is the burst size for publish_rate (defaults to the
+	// rate when zero).
+	PublishBurst int `toml:"publish_burst"`
+}
+
 func init() {
 	registry.Register(&plugin.Registration{
-		Type: plugins.EventPlugin,
-		ID:   "exchange",
+		Type:   plugins.EventPlugin,
+		ID:     "exchange",
+		Config: &Config{},
 		InitFn: func(ic *plugin.InitContext) (any, error) {
-			return exchange.NewExchange(), nil
+			config := ic.Config.(*Config)
+			var opts []exchange.Option
+			for prefix, interval := range config.Coalesce {
+				opts = append(opts, exchange.WithCoalescing(prefix, tomlext.ToStdTime(interval)))
+			}
+			if config.PublishRate > 0 {
+				burst := config.PublishBurst
+				if burst <= 0 {
+					burst = int(config.PublishRate)
+				}
+				opts = append(opts, exchange.WithPublishRateLimit(config.PublishRate, burst))
+			}
+			return exchange.NewExchange(opts...), nil
 		},
 	})
 }
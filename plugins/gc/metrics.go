@@ -24,11 +24,21 @@ var (
 
 	// gcTimeHist histogram metrics for duration of gc scheduler collections.
 	gcTimeHist metrics.Timer
+
+	// cleanupTimeHist histogram metrics for duration of per snapshotter
+	// cleanup after a collection.
+	cleanupTimeHist metrics.LabeledTimer
+
+	// cleanupBacklog gauge of how many snapshotters needed cleanup in the
+	// latest collection.
+	cleanupBacklog metrics.Gauge
 )
 
 func init() {
 	ns := metrics.NewNamespace("containerd", "gc", nil)
 	collectionCounter = ns.NewLabeledCounter("collections", "counter of gc scheduler collections", "status")
 	gcTimeHist = ns.NewTimer("gc", "duration of gc scheduler collections")
+	cleanupTimeHist = ns.NewLabeledTimer("snapshotter_cleanup", "duration of snapshotter cleanup after collections", "snapshotter")
+	cleanupBacklog = ns.NewGauge("cleanup_backlog", "number of snapshotters cleaned up in the latest collection", metrics.Total)
 	metrics.Register(ns)
 }
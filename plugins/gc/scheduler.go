@@ -323,6 +323,15 @@ func (s *gcScheduler) run(ctx context.Context) {
 
 		gcTime := stats.Elapsed()
 		gcTimeHist.Update(gcTime)
+		if sd, ok := stats.(interface {
+			SnapshotDurations() map[string]time.Duration
+		}); ok {
+			durations := sd.SnapshotDurations()
+			cleanupBacklog.Set(float64(len(durations)))
+			for name, d := range durations {
+				cleanupTimeHist.WithValues(name).Update(d)
+			}
+		}
 		log.G(ctx).WithField("d", gcTime).Trace("garbage collected")
 		gcTimeSum += gcTime
 		collections++
@@ -26,6 +26,7 @@ import (
 	"github.com/containerd/containerd/v2/core/events"
 	"github.com/containerd/containerd/v2/core/metadata"
 	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/internal/tomlext"
 	"github.com/containerd/containerd/v2/pkg/timeout"
 	"github.com/containerd/containerd/v2/plugins"
 	"github.com/containerd/errdefs"
@@ -72,6 +73,12 @@ type BoltConfig struct {
 	//
 	// These settings can improve performance, but introduce a risk of data loss during crashes. Use with care!
 	NoSync bool `toml:"no_sync"`
+
+	// SnapshotRetention keeps unreferenced snapshots collectible only
+	// after they have been idle for the given duration, so alternating
+	// pulls do not constantly re-unpack the same layers. Zero collects
+	// unreferenced snapshots immediately.
+	SnapshotRetention tomlext.Duration `toml:"snapshot_retention"`
 }
 
 const (
@@ -144,8 +151,10 @@ func init() {
 			options.NoStatistics = true
 
 			shared := true
+			var retention time.Duration
 			ic.Meta.Exports["policy"] = SharingPolicyShared
 			if cfg, ok := ic.Config.(*BoltConfig); ok {
+				retention = time.Duration(cfg.SnapshotRetention)
 				if cfg.ContentSharingPolicy != "" {
 					if err := cfg.Validate(); err != nil {
 						return nil, err
@@ -195,6 +204,10 @@ func init() {
 				dbopts = append(dbopts, metadata.WithPolicyIsolated)
 			}
 
+			if retention > 0 {
+				dbopts = append(dbopts, metadata.WithSnapshotRetention(retention))
+			}
+
 			mdb := metadata.NewDB(db, cs.(content.Store), snapshotters, dbopts...)
 			if err := mdb.Init(ic.Context); err != nil {
 				return nil, err
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"time"
 
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
@@ -31,10 +32,16 @@ import (
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/stats"
 
 	"github.com/containerd/containerd/v2/defaults"
+	// accept gzip and zstd compressed rpcs
+	_ "github.com/containerd/containerd/v2/internal/grpccompress"
+	"github.com/containerd/containerd/v2/internal/idempotency"
+	"github.com/containerd/containerd/v2/internal/tomlext"
 	"github.com/containerd/containerd/v2/internal/wintls"
+	"github.com/containerd/containerd/v2/pkg/logging"
 	"github.com/containerd/containerd/v2/pkg/sys"
 	"github.com/containerd/containerd/v2/plugins"
 	"github.com/containerd/containerd/v2/plugins/server/internal"
@@ -46,6 +53,11 @@ type config struct {
 	GID            int    `toml:"gid"`
 	MaxRecvMsgSize int    `toml:"max_recv_message_size"`
 	MaxSendMsgSize int    `toml:"max_send_message_size"`
+	// KeepaliveMinTime is the smallest keepalive ping interval clients
+	// are permitted to use; more frequent pings are answered with
+	// ENHANCE_YOUR_CALM and the connection is closed. Defaults to 30s to
+	// accept the client library's keepalive defaults.
+	KeepaliveMinTime tomlext.Duration `toml:"keepalive_min_time"`
 }
 
 type tcpConfig struct {
@@ -56,6 +68,25 @@ type tcpConfig struct {
 	TLSCName       string `toml:"tls_common_name"`
 	MaxRecvMsgSize int    `toml:"max_recv_message_size"`
 	MaxSendMsgSize int    `toml:"max_send_message_size"`
+	// KeepaliveMinTime is the smallest keepalive ping interval clients
+	// are permitted to use, see the main grpc server configuration.
+	KeepaliveMinTime tomlext.Duration `toml:"keepalive_min_time"`
+}
+
+// defaultKeepaliveMinTime accepts the 30s keepalive time the client
+// library defaults to, while still calming clients pinging more often.
+const defaultKeepaliveMinTime = tomlext.Duration(30 * time.Second)
+
+// withKeepalivePolicy appends the keepalive enforcement policy for the
+// configured minimum ping interval.
+func withKeepalivePolicy(serverOpts []grpc.ServerOption, minTime tomlext.Duration) []grpc.ServerOption {
+	if minTime <= 0 {
+		return serverOpts
+	}
+	return append(serverOpts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime:             time.Duration(minTime),
+		PermitWithoutStream: true,
+	}))
 }
 
 func init() {
@@ -67,11 +98,12 @@ func init() {
 			plugins.MetricsPlugin,
 		},
 		Config: &config{
-			Address:        defaults.DefaultAddress,
-			UID:            os.Geteuid(),
-			GID:            os.Getegid(),
-			MaxRecvMsgSize: defaults.DefaultMaxRecvMsgSize,
-			MaxSendMsgSize: defaults.DefaultMaxSendMsgSize,
+			Address:          defaults.DefaultAddress,
+			UID:              os.Geteuid(),
+			GID:              os.Getegid(),
+			MaxRecvMsgSize:   defaults.DefaultMaxRecvMsgSize,
+			MaxSendMsgSize:   defaults.DefaultMaxSendMsgSize,
+			KeepaliveMinTime: defaultKeepaliveMinTime,
 		},
 		InitFn: func(ic *plugin.InitContext) (any, error) {
 			c := ic.Config.(*config)
@@ -81,7 +113,7 @@ func init() {
 
 			var (
 				streamOpts              = []grpc.StreamServerInterceptor{streamNamespaceInterceptor}
-				unaryOpts               = []grpc.UnaryServerInterceptor{unaryNamespaceInterceptor}
+				unaryOpts               = []grpc.UnaryServerInterceptor{unaryNamespaceInterceptor, logging.UnaryServerInterceptor(), idempotency.UnaryServerInterceptor()}
 				serverOpts              = []grpc.ServerOption{}
 				prometheusServerMetrics *grpc_prometheus.ServerMetrics // This should be grpc handler
 			)
@@ -104,6 +136,7 @@ func init() {
 			if c.MaxSendMsgSize > 0 {
 				serverOpts = append(serverOpts, grpc.MaxSendMsgSize(c.MaxSendMsgSize))
 			}
+			serverOpts = withKeepalivePolicy(serverOpts, c.KeepaliveMinTime)
 
 			// grpcService allows GRPC services to be registered with the underlying server
 			type grpcService interface {
@@ -147,8 +180,9 @@ func init() {
 			plugins.MetricsPlugin,
 		},
 		Config: &tcpConfig{
-			MaxRecvMsgSize: defaults.DefaultMaxRecvMsgSize,
-			MaxSendMsgSize: defaults.DefaultMaxSendMsgSize,
+			MaxRecvMsgSize:   defaults.DefaultMaxRecvMsgSize,
+			MaxSendMsgSize:   defaults.DefaultMaxSendMsgSize,
+			KeepaliveMinTime: defaultKeepaliveMinTime,
 		},
 		InitFn: func(ic *plugin.InitContext) (any, error) {
 			c := ic.Config.(*tcpConfig)
@@ -158,7 +192,7 @@ func init() {
 
 			var (
 				streamOpts              = []grpc.StreamServerInterceptor{streamNamespaceInterceptor}
-				unaryOpts               = []grpc.UnaryServerInterceptor{unaryNamespaceInterceptor}
+				unaryOpts               = []grpc.UnaryServerInterceptor{unaryNamespaceInterceptor, logging.UnaryServerInterceptor(), idempotency.UnaryServerInterceptor()}
 				serverOpts              = []grpc.ServerOption{}
 				prometheusServerMetrics *grpc_prometheus.ServerMetrics // This should be grpc handler
 			)
@@ -182,6 +216,7 @@ func init() {
 			if c.MaxSendMsgSize > 0 {
 				serverOpts = append(serverOpts, grpc.MaxSendMsgSize(c.MaxSendMsgSize))
 			}
+			serverOpts = withKeepalivePolicy(serverOpts, c.KeepaliveMinTime)
 
 			if c.TLSCert != "" {
 				log.G(ic.Context).Info("setting up tls on tcp GRPC services...")
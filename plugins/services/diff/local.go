@@ -19,6 +19,7 @@ package diff
 import (
 	"context"
 	"fmt"
+	"io"
 
 	diffapi "github.com/containerd/containerd/api/services/diff/v1"
 	"github.com/containerd/errdefs"
@@ -31,6 +32,9 @@ import (
 
 	"github.com/containerd/containerd/v2/core/diff"
 	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/core/streaming"
+	tstreaming "github.com/containerd/containerd/v2/core/transfer/streaming"
+	"github.com/containerd/containerd/v2/pkg/archive/compression"
 	"github.com/containerd/containerd/v2/pkg/oci"
 	"github.com/containerd/containerd/v2/plugins"
 	"github.com/containerd/containerd/v2/plugins/services"
@@ -61,6 +65,7 @@ func init() {
 		ID:   services.DiffService,
 		Requires: []plugin.Type{
 			plugins.DiffPlugin,
+			plugins.StreamingPlugin,
 		},
 		Config: defaultDifferConfig,
 		InitFn: func(ic *plugin.InitContext) (any, error) {
@@ -68,6 +73,11 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
+
+			var streams streaming.StreamGetter
+			if sp, err := ic.GetSingle(plugins.StreamingPlugin); err == nil {
+				streams = sp.(streaming.StreamManager)
+			}
 			syncFs := ic.Config.(*config).SyncFs
 			orderedNames := ic.Config.(*config).Order
 			ordered := make([]differ, len(orderedNames))
@@ -86,6 +96,7 @@ func init() {
 			return &local{
 				differs: ordered,
 				syncfs:  syncFs,
+				streams: streams,
 			}, nil
 		},
 	})
@@ -94,10 +105,30 @@ func init() {
 type local struct {
 	differs []differ
 	syncfs  bool
+
+	// streams resolves blob streams offered by senders whose differs
+	// cannot reach the content store, see diff.BlobStream.
+	streams streaming.StreamGetter
 }
 
 var _ diffapi.DiffClient = &local{}
 
+// closeBoth closes the decompressor and the underlying stream together.
+type closeBoth struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (c *closeBoth) Close() error {
+	var err error
+	for _, closer := range c.closers {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
 func (l *local) Apply(ctx context.Context, er *diffapi.ApplyRequest, _ ...grpc.CallOption) (*diffapi.ApplyResponse, error) {
 	var (
 		ocidesc ocispec.Descriptor
@@ -112,7 +143,32 @@ func (l *local) Apply(ctx context.Context, er *diffapi.ApplyRequest, _ ...grpc.C
 		for k, v := range er.Payloads {
 			payloads[k] = v
 		}
-		opts = append(opts, diff.WithPayloads(payloads))
+
+		// A sender may stream the layer bytes instead of relying on this
+		// process reaching the content store. Resolve the stream into a
+		// decompressed input for the differs and drop the payload.
+		if payload, ok := payloads[diff.BlobStreamPayloadKey]; ok && l.streams != nil {
+			v, err := typeurl.UnmarshalAny(payload)
+			if err != nil {
+				return nil, errgrpc.ToGRPC(fmt.Errorf("invalid blob stream payload: %w", err))
+			}
+			if bs, ok := v.(*diff.BlobStream); ok {
+				if stream, err := l.streams.Get(ctx, bs.StreamID); err == nil {
+					rc := tstreaming.ReadByteStream(ctx, stream)
+					dec, err := compression.DecompressStream(rc)
+					if err != nil {
+						rc.Close()
+						return nil, errgrpc.ToGRPC(err)
+					}
+					opts = append(opts, diff.WithDecompressedStream(&closeBoth{Reader: dec, closers: []io.Closer{dec, rc}}))
+					delete(payloads, diff.BlobStreamPayloadKey)
+				}
+			}
+		}
+
+		if len(payloads) > 0 {
+			opts = append(opts, diff.WithPayloads(payloads))
+		}
 	}
 	if l.syncfs {
 		er.SyncFs = true
@@ -19,10 +19,15 @@ package events
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	api "github.com/containerd/containerd/api/services/events/v1"
 	apittrpc "github.com/containerd/containerd/api/services/ttrpc/events/v1"
 	"github.com/containerd/containerd/api/types"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/errdefs/pkg/errgrpc"
 	"github.com/containerd/plugin"
 	"github.com/containerd/plugin/registry"
@@ -32,6 +37,8 @@ import (
 
 	"github.com/containerd/containerd/v2/core/events"
 	"github.com/containerd/containerd/v2/core/events/exchange"
+	"github.com/containerd/containerd/v2/core/events/journal"
+	"github.com/containerd/containerd/v2/pkg/filters"
 	"github.com/containerd/containerd/v2/pkg/protobuf"
 	ptypes "github.com/containerd/containerd/v2/pkg/protobuf/types"
 	"github.com/containerd/containerd/v2/plugins"
@@ -45,13 +52,20 @@ func init() {
 		ID:   "events",
 		Requires: []plugin.Type{
 			plugins.EventPlugin,
+			plugins.InternalPlugin,
 		},
 		InitFn: func(ic *plugin.InitContext) (any, error) {
 			ep, err := ic.GetByID(plugins.EventPlugin, "exchange")
 			if err != nil {
 				return nil, err
 			}
-			return NewService(ep.(*exchange.Exchange)), nil
+			s := NewService(ep.(*exchange.Exchange))
+			// the journal is optional; without it subscriptions simply
+			// cannot replay
+			if jp, err := ic.GetByID(plugins.InternalPlugin, "event-journal"); err == nil {
+				s.(*service).journal = jp.(*journal.Journal)
+			}
+			return s, nil
 		},
 	})
 }
@@ -59,6 +73,7 @@ func init() {
 type service struct {
 	ttService *ttrpcService
 	events    *exchange.Exchange
+	journal   *journal.Journal
 	api.UnimplementedEventsServer
 }
 
@@ -102,7 +117,46 @@ func (s *service) Subscribe(req *api.SubscribeRequest, srv api.Events_SubscribeS
 	ctx, cancel := context.WithCancel(srv.Context())
 	defer cancel()
 
-	eventq, errq := s.events.Subscribe(ctx, req.Filters...)
+	position, fs, replay, err := parseReplayPosition(req.Filters)
+	if err != nil {
+		return errgrpc.ToGRPC(err)
+	}
+
+	// subscribe live before replaying so nothing is missed between the
+	// two; events published while the replay runs may be delivered twice
+	// (see the journal package's deduplication guidance)
+	eventq, errq := s.events.Subscribe(ctx, fs...)
+
+	if replay {
+		if s.journal == nil {
+			return errgrpc.ToGRPC(fmt.Errorf("event journal is not enabled: %w", errdefs.ErrNotImplemented))
+		}
+		// pseudo-clauses like the coalescing opt-out are handled by the
+		// exchange and must not reach the replay filter
+		replayFs, _ := exchange.StripNoCoalesce(fs)
+		var filter filters.Filter
+		if len(replayFs) > 0 {
+			if filter, err = filters.ParseAll(replayFs...); err != nil {
+				return errgrpc.ToGRPC(err)
+			}
+		}
+		if err := s.journal.Replay(ctx, position, func(seq uint64, envelope *events.Envelope) error {
+			if filter != nil && !filter.Match(envelope) {
+				return nil
+			}
+			return srv.Send(toProto(envelope))
+		}); err != nil {
+			return errgrpc.ToGRPC(err)
+		}
+		// the marker separates replayed envelopes from live delivery
+		if err := srv.Send(toProto(&events.Envelope{
+			Timestamp: time.Now().UTC(),
+			Topic:     journal.ReplayEndTopic,
+		})); err != nil {
+			return err
+		}
+	}
+
 	for {
 		select {
 		case ev := <-eventq:
@@ -136,3 +190,27 @@ func fromProto(env *types.Envelope) *events.Envelope {
 		Event:     env.Event,
 	}
 }
+
+// parseReplayPosition extracts the pseudo-filter `from==<seq|rfc3339>`
+// from the subscription filters, returning the remaining filters. The
+// position syntax rides in the filter list because the subscribe request
+// carries nothing else, and older daemons reject it cleanly as an
+// unknown field.
+func parseReplayPosition(fs []string) (journal.Position, []string, bool, error) {
+	for i, f := range fs {
+		raw, ok := strings.CutPrefix(strings.TrimSpace(f), "from==")
+		if !ok {
+			continue
+		}
+		rest := slices.Delete(slices.Clone(fs), i, i+1)
+		value := strings.Trim(raw, `"`)
+		if seq, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return journal.Position{Sequence: seq}, rest, true, nil
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return journal.Position{Time: ts}, rest, true, nil
+		}
+		return journal.Position{}, nil, false, fmt.Errorf("invalid replay position %q, expected a sequence number or RFC3339 timestamp: %w", value, errdefs.ErrInvalidArgument)
+	}
+	return journal.Position{}, fs, false, nil
+}
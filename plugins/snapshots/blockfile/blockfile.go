@@ -29,11 +29,17 @@ import (
 	"github.com/containerd/containerd/v2/core/snapshots"
 	"github.com/containerd/containerd/v2/core/snapshots/storage"
 	"github.com/containerd/continuity/fs"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 	"github.com/containerd/plugin"
+	"github.com/docker/go-units"
 )
 
 // viewHookHelper is only used in test for recover the filesystem.
+// sizeLabel grows an active snapshot's block image (and, for the ext
+// family, the filesystem on it) to the given size at prepare time.
+const sizeLabel = "containerd.io/snapshot/blockfile.size"
+
 type viewHookHelper func(backingFile string, fsType string, defaultOpts []string) error
 
 // SnapshotterConfig holds the configurable properties for the blockfile snapshotter
@@ -306,6 +312,15 @@ func (o *snapshotter) Commit(ctx context.Context, name, key string, opts ...snap
 			return err
 		}
 
+		// Return blocks freed inside the filesystem to the host by
+		// trimming the image before it becomes immutable; best effort
+		// since losing the trim only costs space.
+		if runtime.GOOS == "linux" {
+			if err := trimBlockFile(o.getBlockFile(id), o.fsType, o.options); err != nil {
+				log.G(ctx).WithError(err).WithField("key", key).Warn("failed to trim block image on commit")
+			}
+		}
+
 		st, err := os.Stat(o.getBlockFile(id))
 		if err != nil {
 			return err
@@ -398,6 +413,22 @@ func (o *snapshotter) createSnapshot(ctx context.Context, kind snapshots.Kind, k
 					return fmt.Errorf("copying of scratch failed: %w", err)
 				}
 			}
+
+			if s.Kind == snapshots.KindActive {
+				_, info, _, err := storage.GetInfo(ctx, key)
+				if err != nil {
+					return err
+				}
+				if v, ok := info.Labels[sizeLabel]; ok {
+					size, err := units.RAMInBytes(v)
+					if err != nil {
+						return fmt.Errorf("failed to parse snapshot size %q: %w", v, errdefs.ErrInvalidArgument)
+					}
+					if err := growBlockFile(path, o.fsType, size); err != nil {
+						return fmt.Errorf("failed to grow block image: %w", err)
+					}
+				}
+			}
 		} else {
 			path = o.getBlockFile(s.ParentIDs[0])
 		}
@@ -464,6 +495,10 @@ func copyFileWithSync(target, source string) error {
 	if runtime.GOOS == "darwin" {
 		return fs.CopyFile(target, source)
 	}
+	if runtime.GOOS == "linux" {
+		// clone or sparse-copy so images stay as sparse as their source
+		return copyBlockFile(target, source)
+	}
 
 	src, err := os.Open(source)
 	if err != nil {
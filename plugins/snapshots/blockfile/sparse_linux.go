@@ -0,0 +1,142 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package blockfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	"golang.org/x/sys/unix"
+)
+
+// copyBlockFile copies a block image preserving sparseness: the whole file
+// is cloned with FICLONE when the filesystem supports it, otherwise only
+// data segments are copied with copy_file_range, leaving holes unwritten
+// so an image stays as sparse as its source.
+func copyBlockFile(target, source string) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open source %s: %w", source, err)
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tgt, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to open target %s: %w", target, err)
+	}
+	defer tgt.Close()
+	defer tgt.Sync()
+
+	if err := unix.IoctlFileClone(int(tgt.Fd()), int(src.Fd())); err == nil {
+		return nil
+	}
+
+	if err := tgt.Truncate(fi.Size()); err != nil {
+		return err
+	}
+
+	var offset int64
+	for offset < fi.Size() {
+		dataStart, err := unix.Seek(int(src.Fd()), offset, unix.SEEK_DATA)
+		if err != nil {
+			if errors.Is(err, unix.ENXIO) {
+				// only holes remain
+				return nil
+			}
+			// filesystem without SEEK_DATA support, dense copy
+			if _, err := src.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			_, err = io.Copy(tgt, src)
+			return err
+		}
+		holeStart, err := unix.Seek(int(src.Fd()), dataStart, unix.SEEK_HOLE)
+		if err != nil {
+			return err
+		}
+
+		srcOff, tgtOff := dataStart, dataStart
+		for remain := holeStart - dataStart; remain > 0; {
+			n, err := unix.CopyFileRange(int(src.Fd()), &srcOff, int(tgt.Fd()), &tgtOff, int(remain), 0)
+			if err != nil {
+				return fmt.Errorf("failed to copy data segment of %s: %w", source, err)
+			}
+			remain -= int64(n)
+		}
+		offset = holeStart
+	}
+	return nil
+}
+
+// growBlockFile extends the image to newSize (sparsely) and grows the
+// filesystem on it; only the ext family can be resized while the image is
+// a plain file.
+func growBlockFile(path, fsType string, newSize int64) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if newSize < fi.Size() {
+		return fmt.Errorf("new size %d is smaller than current size %d", newSize, fi.Size())
+	}
+	if newSize == fi.Size() {
+		return nil
+	}
+	if !strings.HasPrefix(fsType, "ext") {
+		return fmt.Errorf("cannot grow %s images offline", fsType)
+	}
+	if err := os.Truncate(path, newSize); err != nil {
+		return err
+	}
+	if out, err := exec.Command("resize2fs", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("resize2fs failed: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// trimBlockFile mounts the image briefly and runs fstrim so blocks freed
+// inside the filesystem are punched out of the backing file.
+func trimBlockFile(path, fsType string, options []string) error {
+	opts := make([]string, 0, len(options)+1)
+	for _, o := range options {
+		if o != "ro" && o != "rw" {
+			opts = append(opts, o)
+		}
+	}
+	m := []mount.Mount{{
+		Source:  path,
+		Type:    fsType,
+		Options: append(opts, "rw", "discard"),
+	}}
+	return mount.WithTempMount(context.Background(), m, func(root string) error {
+		if out, err := exec.Command("fstrim", root).CombinedOutput(); err != nil {
+			return fmt.Errorf("fstrim failed: %s: %w", strings.TrimSpace(string(out)), err)
+		}
+		return nil
+	})
+}
@@ -39,13 +39,28 @@ type snapshotter struct {
 	device string // device of the root
 	root   string // root provides paths for internal storage.
 	ms     *storage.MetaStore
+
+	// defaultSize limits new active snapshots without a size label, empty
+	// for no default limit.
+	defaultSize string
+}
+
+// Opt configures the btrfs snapshotter.
+type Opt func(*snapshotter)
+
+// WithDefaultSizeLimit applies the given qgroup size limit to every active
+// snapshot that does not carry its own size label.
+func WithDefaultSizeLimit(size string) Opt {
+	return func(b *snapshotter) {
+		b.defaultSize = size
+	}
 }
 
 // NewSnapshotter returns a Snapshotter using btrfs. Uses the provided
 // root directory for snapshots and stores the metadata in
 // a file in the provided root.
 // root needs to be a mount point of btrfs.
-func NewSnapshotter(root string) (snapshots.Snapshotter, error) {
+func NewSnapshotter(root string, opts ...Opt) (snapshots.Snapshotter, error) {
 	// If directory does not exist, create it
 	if st, err := os.Stat(root); err != nil {
 		if !os.IsNotExist(err) {
@@ -87,11 +102,15 @@ func NewSnapshotter(root string) (snapshots.Snapshotter, error) {
 		return nil, err
 	}
 
-	return &snapshotter{
+	b := &snapshotter{
 		device: mnt.Source,
 		root:   root,
 		ms:     ms,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b, nil
 }
 
 // Stat returns the info for an active or committed snapshot by name or
@@ -113,8 +132,13 @@ func (b *snapshotter) Stat(ctx context.Context, key string) (info snapshots.Info
 }
 
 func (b *snapshotter) Update(ctx context.Context, info snapshots.Info, fieldpaths ...string) (_ snapshots.Info, err error) {
+	var id string
 	err = b.ms.WithTransaction(ctx, true, func(ctx context.Context) error {
 		info, err = storage.UpdateInfo(ctx, info, fieldpaths...)
+		if err != nil {
+			return err
+		}
+		id, _, _, err = storage.GetInfo(ctx, info.Name)
 		return err
 	})
 
@@ -122,9 +146,27 @@ func (b *snapshotter) Update(ctx context.Context, info snapshots.Info, fieldpath
 		return snapshots.Info{}, err
 	}
 
+	// A changed size label adjusts the qgroup limit of active snapshots.
+	if info.Kind == snapshots.KindActive {
+		if size := snapshotSize(info, ""); size != "" {
+			if err := applyQuota(ctx, filepath.Join(b.root, "active", id), size); err != nil {
+				return snapshots.Info{}, err
+			}
+		}
+	}
+
 	return info, nil
 }
 
+// snapshotSize resolves the size limit for a snapshot from its label,
+// falling back to the given default.
+func snapshotSize(info snapshots.Info, defaultSize string) string {
+	if v, ok := info.Labels[sizeLabel]; ok {
+		return v
+	}
+	return defaultSize
+}
+
 // Usage retrieves the disk usage of the top-level snapshot.
 func (b *snapshotter) Usage(ctx context.Context, key string) (snapshots.Usage, error) {
 	return b.usage(ctx, key)
@@ -150,8 +192,17 @@ func (b *snapshotter) usage(ctx context.Context, key string) (usage snapshots.Us
 	}
 
 	if info.Kind == snapshots.KindActive {
-		var du fs.Usage
 		p := filepath.Join(b.root, "active", id)
+
+		// Prefer qgroup accounting over walking the filetree, it is both
+		// cheaper and reflects the quota actually enforced. Quotas may not
+		// be enabled, then fall back to walking.
+		if referenced, qerr := qgroupUsage(ctx, p); qerr == nil {
+			usage.Size = referenced
+			return usage, nil
+		}
+
+		var du fs.Usage
 		if parentID != "" {
 			du, err = fs.DiffUsage(ctx, filepath.Join(b.root, "snapshots", parentID), p)
 		} else {
@@ -200,13 +251,29 @@ func (b *snapshotter) makeSnapshot(ctx context.Context, kind snapshots.Kind, key
 		if len(s.ParentIDs) == 0 {
 			// create new subvolume
 			// btrfs subvolume create /dir
-			return btrfs.SubvolCreate(target)
+			if err := btrfs.SubvolCreate(target); err != nil {
+				return err
+			}
+		} else {
+			parentp := filepath.Join(b.root, "snapshots", s.ParentIDs[0])
+
+			// btrfs subvolume snapshot /parent /subvol
+			readOnly := kind == snapshots.KindView
+			if err := btrfs.SubvolSnapshot(target, parentp, readOnly); err != nil {
+				return err
+			}
 		}
-		parentp := filepath.Join(b.root, "snapshots", s.ParentIDs[0])
 
-		// btrfs subvolume snapshot /parent /subvol
-		readOnly := kind == snapshots.KindView
-		return btrfs.SubvolSnapshot(target, parentp, readOnly)
+		if kind == snapshots.KindActive {
+			_, info, _, err := storage.GetInfo(ctx, key)
+			if err != nil {
+				return err
+			}
+			if size := snapshotSize(info, b.defaultSize); size != "" {
+				return applyQuota(ctx, target, size)
+			}
+		}
+		return nil
 	})
 
 	if err != nil {
@@ -34,6 +34,10 @@ import (
 type Config struct {
 	// Root directory for the plugin
 	RootPath string `toml:"root_path"`
+
+	// DefaultSize is the qgroup limit applied to active snapshots without
+	// their own size label, e.g. "10GB". Empty for no default limit.
+	DefaultSize string `toml:"default_size"`
 }
 
 func init() {
@@ -55,7 +59,11 @@ func init() {
 			}
 
 			ic.Meta.Exports[plugins.SnapshotterRootDir] = root
-			return btrfs.NewSnapshotter(root)
+			var opts []btrfs.Opt
+			if config.DefaultSize != "" {
+				opts = append(opts, btrfs.WithDefaultSizeLimit(config.DefaultSize))
+			}
+			return btrfs.NewSnapshotter(root, opts...)
 		},
 	})
 }
@@ -0,0 +1,81 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package btrfs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/errdefs"
+	"github.com/containerd/log"
+	"github.com/docker/go-units"
+)
+
+// sizeLabel limits how much data a snapshot's subvolume may reference,
+// enforced through btrfs qgroups. The value accepts the usual size syntax
+// ("10GB"). Without the label the snapshotter's configured default size, if
+// any, applies.
+const sizeLabel = "containerd.io/snapshot/btrfs.size"
+
+// applyQuota sets the qgroup limit for the subvolume at path. When quotas
+// are not enabled on the filesystem the limit is skipped with a warning
+// instead of failing the snapshot, since enabling quotas is a filesystem
+// level decision.
+func applyQuota(ctx context.Context, path, size string) error {
+	limit, err := units.RAMInBytes(size)
+	if err != nil {
+		return fmt.Errorf("failed to parse snapshot size %q: %w", size, errdefs.ErrInvalidArgument)
+	}
+
+	out, err := exec.Command("btrfs", "qgroup", "limit", strconv.FormatInt(limit, 10), path).CombinedOutput()
+	if err != nil {
+		if strings.Contains(string(out), "quota") || strings.Contains(string(out), "Invalid argument") {
+			log.G(ctx).WithField("subvolume", path).Warn("btrfs quotas not enabled on the filesystem, snapshot size limit not enforced")
+			return nil
+		}
+		return fmt.Errorf("failed to limit qgroup of %s: %s: %w", path, strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// qgroupUsage reports the bytes referenced by the subvolume at path from
+// qgroup accounting, avoiding a full filetree walk. It fails when quotas
+// are not enabled, callers fall back to walking.
+func qgroupUsage(ctx context.Context, path string) (int64, error) {
+	out, err := exec.Command("btrfs", "qgroup", "show", "--raw", "-f", path).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query qgroup of %s: %s: %w", path, strings.TrimSpace(string(out)), err)
+	}
+
+	// Output ends with the qgroup row: "0/<subvolid> <referenced> <exclusive>"
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		fields := strings.Fields(lines[i])
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "0/") {
+			continue
+		}
+		referenced, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected qgroup usage %q: %w", fields[1], err)
+		}
+		return referenced, nil
+	}
+	return 0, fmt.Errorf("no qgroup found for %s", path)
+}
@@ -163,6 +163,19 @@ func ResumeDevice(deviceName string) error {
 	return err
 }
 
+// LoadTable loads a new table into the device's inactive slot, activated
+// by the next suspend/resume cycle.
+func LoadTable(deviceName, table string) error {
+	_, err := dmsetup("load", deviceName, "--table", table)
+	return err
+}
+
+// LoadThinTable loads a thin target table with the given size into the
+// device's inactive slot, used to resize a thin device.
+func LoadThinTable(poolName, deviceName string, deviceID uint32, sizeBytes uint64) error {
+	return LoadTable(deviceName, makeThinMapping(poolName, deviceID, sizeBytes, ""))
+}
+
 // Table returns the current table for the device
 func Table(deviceName string) (string, error) {
 	return dmsetup("table", deviceName)
@@ -31,6 +31,7 @@ import (
 
 	blkdiscard "github.com/containerd/containerd/v2/plugins/snapshots/devmapper/blkdiscard"
 	"github.com/containerd/containerd/v2/plugins/snapshots/devmapper/dmsetup"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
 )
 
@@ -621,3 +622,87 @@ func (p *PoolDevice) WalkDevices(ctx context.Context, cb func(info *DeviceInfo)
 func (p *PoolDevice) Close() error {
 	return p.metadata.Close()
 }
+
+// ResizeDevice extends the thin device to newSizeBytes, validating that the
+// new size is larger than the current one and fits into the pool's free
+// data space. The device table is reloaded and cycled through
+// suspend/resume when the device is active; growing the filesystem on top
+// is up to the caller. Shrinking is rejected and resizing to the current
+// size is a no-op, which keeps retries idempotent.
+func (p *PoolDevice) ResizeDevice(ctx context.Context, deviceName string, newSizeBytes uint64) error {
+	info, err := p.metadata.GetDevice(ctx, deviceName)
+	if err != nil {
+		return fmt.Errorf("failed to query device metadata for %q: %w", deviceName, err)
+	}
+	if newSizeBytes == info.Size {
+		return nil
+	}
+	if newSizeBytes < info.Size {
+		return fmt.Errorf("new size %d is smaller than current size %d of %q: %w", newSizeBytes, info.Size, deviceName, errdefs.ErrInvalidArgument)
+	}
+
+	free, err := p.poolFreeDataBytes()
+	if err != nil {
+		return fmt.Errorf("failed to determine pool free space: %w", err)
+	}
+	if delta := newSizeBytes - info.Size; delta > free {
+		return fmt.Errorf("resizing %q by %d bytes exceeds the pool's free space of %d bytes: %w", deviceName, delta, free, errdefs.ErrInvalidArgument)
+	}
+
+	if p.IsActivated(deviceName) {
+		if err := dmsetup.LoadThinTable(p.poolName, deviceName, info.DeviceID, newSizeBytes); err != nil {
+			return fmt.Errorf("failed to load resized table for %q: %w", deviceName, err)
+		}
+		if err := p.SuspendDevice(ctx, deviceName); err != nil {
+			return err
+		}
+		if err := p.ResumeDevice(ctx, deviceName); err != nil {
+			return err
+		}
+	}
+
+	return p.metadata.UpdateDevice(ctx, deviceName, func(di *DeviceInfo) error {
+		di.Size = newSizeBytes
+		return nil
+	})
+}
+
+// poolFreeDataBytes reports the unallocated data space of the thin-pool.
+func (p *PoolDevice) poolFreeDataBytes() (uint64, error) {
+	status, err := dmsetup.Status(p.poolName)
+	if err != nil {
+		return 0, err
+	}
+	// thin-pool status: <transaction id> <used meta>/<total meta> <used data>/<total data> ...
+	if len(status.Params) < 3 {
+		return 0, fmt.Errorf("unexpected thin-pool status %v", status.Params)
+	}
+	usedBlocks, totalBlocks, ok := strings.Cut(status.Params[2], "/")
+	if !ok {
+		return 0, fmt.Errorf("unexpected thin-pool data usage %q", status.Params[2])
+	}
+	used, err := strconv.ParseUint(usedBlocks, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	total, err := strconv.ParseUint(totalBlocks, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	// the data block size in sectors is the third table parameter
+	table, err := dmsetup.Table(p.poolName)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(table)
+	if len(fields) < 6 {
+		return 0, fmt.Errorf("unexpected thin-pool table %q", table)
+	}
+	blockSizeSectors, err := strconv.ParseUint(fields[5], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return (total - used) * blockSizeSectors * dmsetup.SectorSize, nil
+}
@@ -22,9 +22,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -34,6 +36,7 @@ import (
 	"github.com/containerd/containerd/v2/plugins/snapshots/devmapper/dmsetup"
 	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
+	"github.com/docker/go-units"
 )
 
 type fsType string
@@ -59,12 +62,29 @@ type Snapshotter struct {
 	config    *Config
 	cleanupFn []closeFunc
 	closeOnce sync.Once
+
+	// preAllocSize pre-allocates writable snapshots up to this many
+	// bytes, see WithPreAllocSize.
+	preAllocSize int64
 }
 
 // NewSnapshotter creates new device mapper snapshotter.
 // Internally it creates thin-pool device (or reloads if it's already exists) and
 // initializes a database file for metadata.
-func NewSnapshotter(ctx context.Context, config *Config) (*Snapshotter, error) {
+// Opt configures the snapshotter beyond its file based configuration.
+type Opt func(*Snapshotter)
+
+// WithPreAllocSize pre-allocates up to the given number of bytes of every
+// writable snapshot's thin device at creation by rewriting its blocks in
+// place, trading creation time for stable first-write latency. Zero keeps
+// the default lazy allocation.
+func WithPreAllocSize(bytes int64) Opt {
+	return func(s *Snapshotter) {
+		s.preAllocSize = bytes
+	}
+}
+
+func NewSnapshotter(ctx context.Context, config *Config, opts ...Opt) (*Snapshotter, error) {
 	// Make sure snapshotter configuration valid before running
 	if err := config.parse(); err != nil {
 		return nil, err
@@ -94,12 +114,16 @@ func NewSnapshotter(ctx context.Context, config *Config) (*Snapshotter, error) {
 
 	cleanupFn = append(cleanupFn, poolDevice.Close)
 
-	return &Snapshotter{
+	s := &Snapshotter{
 		store:     store,
 		config:    config,
 		pool:      poolDevice,
 		cleanupFn: cleanupFn,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
 }
 
 // Stat returns the info for an active or committed snapshot from store
@@ -119,17 +143,96 @@ func (s *Snapshotter) Stat(ctx context.Context, key string) (snapshots.Info, err
 	return info, err
 }
 
-// Update updates an existing snapshot info's data
+// Update updates an existing snapshot info's data. Setting the
+// "containerd.io/snapshot/devmapper.resize" label to a size (e.g. "20GB")
+// grows the snapshot's thin device and its filesystem online.
 func (s *Snapshotter) Update(ctx context.Context, info snapshots.Info, fieldpaths ...string) (snapshots.Info, error) {
 	log.G(ctx).Debugf("update: %s", strings.Join(fieldpaths, ", "))
 
-	var err error
+	var (
+		err    error
+		snapID string
+	)
 	err = s.store.WithTransaction(ctx, true, func(ctx context.Context) error {
 		info, err = storage.UpdateInfo(ctx, info, fieldpaths...)
+		if err != nil {
+			return err
+		}
+		snapID, _, _, err = storage.GetInfo(ctx, info.Name)
 		return err
 	})
+	if err != nil {
+		return info, err
+	}
 
-	return info, err
+	if v, ok := info.Labels[resizeLabel]; ok {
+		if err := s.resizeSnapshot(ctx, snapID, v); err != nil {
+			return info, err
+		}
+	}
+
+	return info, nil
+}
+
+// preAllocSizeLabel pre-allocates the snapshot's thin device up to the
+// given size at creation, overriding the snapshotter wide setting.
+const preAllocSizeLabel = "containerd.io/snapshot/devmapper.prealloc-size"
+
+// compressedSizeLabel carries the compressed size of the layer the
+// snapshot is created for, recorded by unpackers that know the layer
+// descriptor; the uncompressed estimate used for pre-allocation is three
+// times this value.
+const compressedSizeLabel = "containerd.io/snapshot/devmapper.compressed-size"
+
+// resizeLabel requests growing a snapshot's thin device to the given size,
+// accepting the same size syntax as the base_image_size setting. Resizing
+// to the current size is a no-op so the label can remain on the snapshot.
+const resizeLabel = "containerd.io/snapshot/devmapper.resize"
+
+// resizeSnapshot extends the snapshot's thin device and grows the
+// filesystem on it. When the device was extended but the filesystem grow
+// fails, the filesystem stays intact at its old size and the operation can
+// be retried; the extra device space is simply not used until then.
+func (s *Snapshotter) resizeSnapshot(ctx context.Context, snapID, sizeStr string) error {
+	newSize, err := units.RAMInBytes(sizeStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse resize value %q: %w", sizeStr, errdefs.ErrInvalidArgument)
+	}
+
+	deviceName := s.getDeviceName(snapID)
+	if err := s.pool.ResizeDevice(ctx, deviceName, uint64(newSize)); err != nil {
+		return err
+	}
+
+	if err := growFS(ctx, s.config.FileSystemType, dmsetup.GetFullDevicePath(deviceName)); err != nil {
+		return fmt.Errorf("thin device %q extended but growing the filesystem failed, retry the resize: %w", deviceName, err)
+	}
+
+	log.G(ctx).WithFields(log.Fields{
+		"device": deviceName,
+		"size":   newSize,
+	}).Info("resized thin device")
+	return nil
+}
+
+// growFS grows the filesystem on the device to its full size.
+func growFS(ctx context.Context, fs fsType, devicePath string) error {
+	var command string
+	switch fs {
+	case fsTypeExt4, fsTypeExt2:
+		command = "resize2fs"
+	case fsTypeXFS:
+		// requires the filesystem to be mounted
+		command = "xfs_growfs"
+	default:
+		return fmt.Errorf("unsupported filesystem type %q for resize", fs)
+	}
+	log.G(ctx).Debugf("%s %s", command, devicePath)
+	b, err := exec.Command(command, devicePath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %s: %w", command, string(b), err)
+	}
+	return nil
 }
 
 // Usage returns the resource usage of an active or committed snapshot excluding the usage of parent snapshots.
@@ -210,6 +313,11 @@ func (s *Snapshotter) Prepare(ctx context.Context, key, parent string, opts ...s
 		mounts, err = s.createSnapshot(ctx, snapshots.KindActive, key, parent, opts...)
 		return err
 	})
+	if err == nil {
+		// Pre-allocation rewrites potentially gigabytes of device blocks,
+		// keep it outside the metadata transaction.
+		s.preAllocateSnapshot(ctx, key)
+	}
 
 	return mounts, err
 }
@@ -460,6 +568,94 @@ func (s *Snapshotter) createSnapshot(ctx context.Context, kind snapshots.Kind, k
 	return mounts, nil
 }
 
+// preAllocateSnapshot provisions the new snapshot's thin device outside of
+// any metadata transaction; failures only cost first-write latency since
+// lazy allocation still works.
+func (s *Snapshotter) preAllocateSnapshot(ctx context.Context, key string) {
+	var (
+		size   int64
+		snapID string
+	)
+	if err := s.store.WithTransaction(ctx, false, func(ctx context.Context) error {
+		id, _, _, err := storage.GetInfo(ctx, key)
+		if err != nil {
+			return err
+		}
+		snapID = id
+		size = s.preAllocTarget(ctx, key)
+		return nil
+	}); err != nil || size <= 0 {
+		return
+	}
+
+	deviceName := s.getDeviceName(snapID)
+	if err := preAllocate(ctx, dmsetup.GetFullDevicePath(deviceName), size); err != nil {
+		log.G(ctx).WithError(err).WithField("device", deviceName).Warn("failed to pre-allocate thin device")
+	}
+}
+
+// preAllocTarget resolves how many bytes of the new snapshot's device to
+// pre-allocate: an explicit prealloc-size label wins, a compressed-size
+// label (recorded by unpackers that know the layer descriptor) is
+// multiplied by three as an uncompressed estimate, and otherwise the
+// snapshotter wide setting applies. The result never exceeds the device's
+// virtual size.
+func (s *Snapshotter) preAllocTarget(ctx context.Context, key string) int64 {
+	size := s.preAllocSize
+
+	if _, info, _, err := storage.GetInfo(ctx, key); err == nil {
+		if v, ok := info.Labels[preAllocSizeLabel]; ok {
+			if parsed, err := units.RAMInBytes(v); err == nil {
+				size = parsed
+			} else {
+				log.G(ctx).WithField("value", v).Warn("ignoring invalid pre-alloc size label")
+			}
+		} else if v, ok := info.Labels[compressedSizeLabel]; ok {
+			if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+				size = parsed * 3
+			}
+		}
+	}
+
+	if size > int64(s.config.BaseImageSizeBytes) {
+		size = int64(s.config.BaseImageSizeBytes)
+	}
+	return size
+}
+
+// preAllocate provisions blocks of the thin device up to size by rewriting
+// them in place, which breaks the lazy zero-fill on first write without
+// changing the data the device holds.
+func preAllocate(ctx context.Context, devicePath string, size int64) error {
+	f, err := os.OpenFile(devicePath, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 1<<20)
+	for offset := int64(0); offset < size; offset += int64(len(buf)) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk := buf
+		if remain := size - offset; remain < int64(len(buf)) {
+			chunk = buf[:remain]
+		}
+		n, err := f.ReadAt(chunk, offset)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		if _, err := f.WriteAt(chunk[:n], offset); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
 // mkfs creates filesystem on the given devmapper device based on type
 // specified in config.
 func mkfs(ctx context.Context, fs fsType, fsOptions string, path string) error {
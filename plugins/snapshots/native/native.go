@@ -33,6 +33,10 @@ import (
 type snapshotter struct {
 	root string
 	ms   *storage.MetaStore
+
+	// reflink indicates the root filesystem supports cloning files with
+	// FICLONE, letting Prepare share extents instead of copying data.
+	reflink bool
 }
 
 // NewSnapshotter returns a Snapshotter which copies layers on the underlying
@@ -51,8 +55,9 @@ func NewSnapshotter(root string) (snapshots.Snapshotter, error) {
 	}
 
 	return &snapshotter{
-		root: root,
-		ms:   ms,
+		root:    root,
+		ms:      ms,
+		reflink: ReflinkSupported(root),
 	}, nil
 }
 
@@ -206,9 +211,7 @@ func (o *snapshotter) Remove(ctx context.Context, key string) (err error) {
 
 // Walk the committed snapshots.
 func (o *snapshotter) Walk(ctx context.Context, fn snapshots.WalkFunc, fs ...string) error {
-	return o.ms.WithTransaction(ctx, false, func(ctx context.Context) error {
-		return storage.WalkInfo(ctx, fn, fs...)
-	})
+	return storage.WalkInfoPaged(ctx, o.ms, fn, fs...)
 }
 
 func (o *snapshotter) createSnapshot(ctx context.Context, kind snapshots.Kind, key, parent string, opts []snapshots.Opt) (_ []mount.Mount, err error) {
@@ -258,7 +261,12 @@ func (o *snapshotter) createSnapshot(ctx context.Context, kind snapshots.Kind, k
 				copyDirOpts := []fs.CopyDirOpt{
 					fs.WithXAttrErrorHandler(xattrErrorHandler),
 				}
-				if err = fs.CopyDir(td, parent, copyDirOpts...); err != nil {
+				if o.reflink {
+					err = reflinkCopyDir(td, parent)
+				} else {
+					err = fs.CopyDir(td, parent, copyDirOpts...)
+				}
+				if err != nil {
 					return fmt.Errorf("copying of parent failed: %w", err)
 				}
 			}
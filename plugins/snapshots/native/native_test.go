@@ -18,6 +18,9 @@ package native
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -42,3 +45,44 @@ func TestNative(t *testing.T) {
 	testutil.RequiresRoot(t)
 	testsuite.SnapshotterSuite(t, "Native", newSnapshotter)
 }
+
+// BenchmarkPrepareWithParent measures preparing a snapshot on top of a
+// populated parent, the operation reflinks collapse on supporting
+// filesystems (the copy fallback runs where FICLONE is unavailable).
+func BenchmarkPrepareWithParent(b *testing.B) {
+	ctx := context.Background()
+	root := b.TempDir()
+
+	sn, err := NewSnapshotter(root)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer sn.Close()
+
+	mounts, err := sn.Prepare(ctx, "prep", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := make([]byte, 64<<10)
+	for i := 0; i < 500; i++ {
+		if err := os.WriteFile(filepath.Join(mounts[0].Source, fmt.Sprintf("file-%d", i)), data, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := sn.Commit(ctx, "parent", "prep"); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("bench-%d", i)
+		if _, err := sn.Prepare(ctx, key, "parent"); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		if err := sn.Remove(ctx, key); err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+	}
+}
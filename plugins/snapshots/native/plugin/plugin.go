@@ -18,6 +18,7 @@ package plugin
 
 import (
 	"errors"
+	"strconv"
 
 	"github.com/containerd/containerd/v2/plugins"
 	"github.com/containerd/containerd/v2/plugins/snapshots/native"
@@ -51,7 +52,13 @@ func init() {
 			}
 
 			ic.Meta.Exports[plugins.SnapshotterRootDir] = root
-			return native.NewSnapshotter(root)
+			sn, err := native.NewSnapshotter(root)
+			if err != nil {
+				return nil, err
+			}
+			// surface whether prepares clone instead of copy
+			ic.Meta.Exports["reflink"] = strconv.FormatBool(native.ReflinkSupported(root))
+			return sn, nil
 		},
 	})
 }
@@ -0,0 +1,197 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package native
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/containerd/continuity/sysx"
+	"golang.org/x/sys/unix"
+)
+
+// ReflinkSupported reports whether the filesystem holding root can clone
+// files with FICLONE, probed by cloning a scratch file.
+func ReflinkSupported(root string) bool {
+	src, err := os.CreateTemp(root, ".reflink-probe-")
+	if err != nil {
+		return false
+	}
+	defer os.Remove(src.Name())
+	defer src.Close()
+	if _, err := src.WriteString("probe"); err != nil {
+		return false
+	}
+
+	dst, err := os.CreateTemp(root, ".reflink-probe-")
+	if err != nil {
+		return false
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+
+	return unix.IoctlFileClone(int(dst.Fd()), int(src.Fd())) == nil
+}
+
+// reflinkCopyDir replicates src into dst like fs.CopyDir but clones regular
+// files with FICLONE, sharing their extents instead of duplicating data.
+// Modes, ownership, timestamps, xattrs, sparse layout (implicit in the
+// clone) and hardlinks are preserved; files the kernel refuses to clone
+// (for example across devices) fall back to a plain copy.
+func reflinkCopyDir(dst, src string) error {
+	return reflinkCopy(dst, src, map[uint64]string{})
+}
+
+func reflinkCopy(dst, src string, inodes map[uint64]string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, fi.Mode()); err != nil {
+		return err
+	}
+	if err := copyMetadata(dst, src, fi); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		var (
+			source = filepath.Join(src, entry.Name())
+			target = filepath.Join(dst, entry.Name())
+		)
+		info, err := os.Lstat(source)
+		if err != nil {
+			return err
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			return fmt.Errorf("unsupported stat type for %s", source)
+		}
+
+		switch {
+		case info.IsDir():
+			if err := reflinkCopy(target, source, inodes); err != nil {
+				return err
+			}
+			continue
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(source)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		case info.Mode()&(os.ModeDevice|os.ModeNamedPipe|os.ModeSocket) != 0:
+			if err := unix.Mknod(target, uint32(stat.Mode), int(stat.Rdev)); err != nil {
+				return fmt.Errorf("failed to create irregular file %s: %w", target, err)
+			}
+		default:
+			// preserve hardlinks within the tree
+			if stat.Nlink > 1 {
+				if linked, ok := inodes[stat.Ino]; ok {
+					if err := os.Link(linked, target); err != nil {
+						return err
+					}
+					continue
+				}
+				inodes[stat.Ino] = target
+			}
+			if err := cloneFile(target, source, info); err != nil {
+				return err
+			}
+		}
+
+		if err := copyMetadata(target, source, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneFile clones source into target with FICLONE, falling back to a
+// plain copy when the kernel refuses the clone.
+func cloneFile(target, source string, info os.FileInfo) error {
+	srcFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	tgtFile, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	err = unix.IoctlFileClone(int(tgtFile.Fd()), int(srcFile.Fd()))
+	if cerr := tgtFile.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, unix.EXDEV) || errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EINVAL) {
+		return fs.CopyFile(target, source)
+	}
+	return fmt.Errorf("failed to clone %s: %w", source, err)
+}
+
+// copyMetadata applies ownership, mode, xattrs and timestamps of src to
+// dst. security xattrs that cannot be written are skipped, matching the
+// copy path's error handler.
+func copyMetadata(dst, src string, fi os.FileInfo) error {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("unsupported stat type for %s", src)
+	}
+	if err := os.Lchown(dst, int(stat.Uid), int(stat.Gid)); err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink == 0 {
+		if err := os.Chmod(dst, fi.Mode()); err != nil {
+			return err
+		}
+	}
+
+	xattrs, err := sysx.LListxattr(src)
+	if err != nil && !errors.Is(err, unix.ENOTSUP) {
+		return err
+	}
+	for _, xattr := range xattrs {
+		data, err := sysx.LGetxattr(src, xattr)
+		if err != nil {
+			continue
+		}
+		// security.* xattrs usually cannot be written, skip like CopyDir
+		_ = sysx.LSetxattr(dst, xattr, data, 0)
+	}
+
+	times := []unix.Timespec{
+		unix.NsecToTimespec(stat.Atim.Nano()),
+		unix.NsecToTimespec(stat.Mtim.Nano()),
+	}
+	return unix.UtimesNanoAt(unix.AT_FDCWD, dst, times, unix.AT_SYMLINK_NOFOLLOW)
+}
@@ -0,0 +1,121 @@
+//go:build linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package overlay
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/pkg/testutil"
+	"github.com/containerd/errdefs"
+)
+
+func TestWithOverlayMountOptionsValidation(t *testing.T) {
+	for _, tc := range []struct {
+		options []string
+		valid   bool
+	}{
+		{options: []string{"metacopy=on"}, valid: true},
+		{options: []string{"redirect_dir=follow", "xino=auto"}, valid: true},
+		{options: []string{"userxattr"}, valid: true},
+		{options: []string{"metacopy=sideways"}, valid: false},
+		{options: []string{"lowerdir=/etc"}, valid: false},
+		{options: []string{"userxattr=on"}, valid: false},
+	} {
+		var config SnapshotterConfig
+		err := WithOverlayMountOptions(tc.options)(&config)
+		if tc.valid && err != nil {
+			t.Errorf("expected %v to be accepted: %v", tc.options, err)
+		}
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("expected %v to be rejected", tc.options)
+			} else if !errdefs.IsInvalidArgument(err) {
+				t.Errorf("expected invalid argument for %v, got %v", tc.options, err)
+			}
+		}
+	}
+}
+
+// TestMetacopyAvoidsDataCopyUp verifies that with metacopy=on a metadata
+// only change (chmod) of a lower file does not copy the file's data up.
+func TestMetacopyAvoidsDataCopyUp(t *testing.T) {
+	testutil.RequiresRoot(t)
+	if !supportsMetacopy() {
+		t.Skip("kernel without metacopy support")
+	}
+
+	var (
+		root   = t.TempDir()
+		lower  = filepath.Join(root, "lower")
+		upper  = filepath.Join(root, "upper")
+		work   = filepath.Join(root, "work")
+		merged = filepath.Join(root, "merged")
+	)
+	for _, dir := range []string{lower, upper, work, merged} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := make([]byte, 1<<20)
+	if err := os.WriteFile(filepath.Join(lower, "big"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := mount.Mount{
+		Type:   "overlay",
+		Source: "overlay",
+		Options: []string{
+			"lowerdir=" + lower,
+			"upperdir=" + upper,
+			"workdir=" + work,
+			"metacopy=on",
+		},
+	}
+	if err := m.Mount(merged); err != nil {
+		t.Skipf("failed to mount overlay with metacopy: %v", err)
+	}
+	defer testutil.Unmount(t, merged)
+
+	if err := os.Chmod(filepath.Join(merged, "big"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(filepath.Join(upper, "big"))
+	if err != nil {
+		t.Fatalf("chmod should have created an upper entry: %v", err)
+	}
+	// A metacopy entry carries the file's size but almost no data blocks;
+	// allow a little slack for filesystems that allocate a block for the
+	// metacopy xattr. A full copy-up of the 1MiB file would show ~2048
+	// 512-byte blocks.
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		t.Fatal("unexpected stat type")
+	}
+	if st.Blocks > 64 {
+		t.Fatalf("metacopy chmod copied %d blocks of data up", st.Blocks)
+	}
+	if fi.Size() != int64(len(data)) {
+		t.Fatalf("metacopy entry should keep the file size, got %d", fi.Size())
+	}
+}
@@ -23,16 +23,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"syscall"
 
 	"github.com/containerd/containerd/v2/core/mount"
 	"github.com/containerd/containerd/v2/core/snapshots"
 	"github.com/containerd/containerd/v2/core/snapshots/storage"
 	"github.com/containerd/containerd/v2/internal/userns"
+	"github.com/containerd/containerd/v2/pkg/kernelversion"
 	"github.com/containerd/containerd/v2/plugins/snapshots/overlay/overlayutils"
 	"github.com/containerd/continuity/fs"
+	"github.com/containerd/errdefs"
 	"github.com/containerd/log"
+	"github.com/docker/go-units"
+	"golang.org/x/sys/unix"
 )
 
 // upperdirKey is a key of an optional label to each snapshot.
@@ -40,6 +46,13 @@ import (
 // the change set between this snapshot and its parent is stored.
 const upperdirKey = "containerd.io/snapshot/overlay.upperdir"
 
+// volatileKey is a key of an optional label to a snapshot. Setting it to
+// "true" mounts the active snapshot with the volatile overlay option,
+// skipping sync on the upperdir. Suitable for scratch containers whose
+// data may be thrown away after a crash, per-snapshot rather than the
+// daemon-wide mount option configuration.
+const volatileKey = "containerd.io/snapshot/overlay.volatile"
+
 // SnapshotterConfig is used to configure the overlay snapshotter instance
 type SnapshotterConfig struct {
 	asyncRemove   bool
@@ -80,6 +93,47 @@ func WithMountOptions(options []string) Opt {
 	}
 }
 
+// allowedMountOptions are the overlayfs options WithOverlayMountOptions
+// accepts: tuning knobs that cannot turn the mount into something other
+// than an overlay or point it at different directories.
+var allowedMountOptions = map[string][]string{
+	"metacopy":     {"on", "off"},
+	"redirect_dir": {"on", "off", "follow", "nofollow"},
+	"index":        {"on", "off"},
+	"xino":         {"on", "off", "auto"},
+	"nfs_export":   {"on", "off"},
+	"userxattr":    nil,
+}
+
+// WithOverlayMountOptions appends extra options to the overlay mount after
+// validating them against a whitelist of safe overlayfs tuning options
+// (metacopy, redirect_dir, index, xino, nfs_export, userxattr). Options
+// like metacopy=on and redirect_dir=on dramatically reduce copy-up cost
+// for large files at the cost of layers only being interpretable by
+// overlayfs.
+func WithOverlayMountOptions(options []string) Opt {
+	return func(config *SnapshotterConfig) error {
+		for _, option := range options {
+			key, value, hasValue := strings.Cut(option, "=")
+			values, ok := allowedMountOptions[key]
+			if !ok {
+				return fmt.Errorf("overlay mount option %q is not allowed: %w", option, errdefs.ErrInvalidArgument)
+			}
+			if !hasValue {
+				if values != nil {
+					return fmt.Errorf("overlay mount option %q requires a value: %w", option, errdefs.ErrInvalidArgument)
+				}
+				continue
+			}
+			if !slices.Contains(values, value) {
+				return fmt.Errorf("invalid value %q for overlay mount option %q: %w", value, key, errdefs.ErrInvalidArgument)
+			}
+		}
+		config.mountOptions = append(config.mountOptions, options...)
+		return nil
+	}
+}
+
 type MetaStore interface {
 	TransactionContext(ctx context.Context, writable bool) (context.Context, storage.Transactor, error)
 	WithTransaction(ctx context.Context, writable bool, fn storage.TransactionCallback) error
@@ -113,6 +167,10 @@ type snapshotter struct {
 	options       []string
 	remapIDs      bool
 	slowChown     bool
+
+	// quota answers and enforces snapshot usage through project quotas,
+	// nil when the backing filesystem does not have them enabled.
+	quota *projectQuota
 }
 
 // NewSnapshotter returns a Snapshotter which uses overlayfs. The overlayfs
@@ -164,6 +222,13 @@ func NewSnapshotter(root string, opts ...Opt) (snapshots.Snapshotter, error) {
 		config.mountOptions = append(config.mountOptions, "index=off")
 	}
 
+	// metacopy turns copy-ups for metadata-only changes (chmod, chown)
+	// into metadata copies, available since kernel 4.19. Respect an
+	// explicitly configured value.
+	if !hasOption(config.mountOptions, "metacopy") && supportsMetacopy() {
+		config.mountOptions = append(config.mountOptions, "metacopy=on")
+	}
+
 	return &snapshotter{
 		root:          root,
 		ms:            config.ms,
@@ -172,6 +237,7 @@ func NewSnapshotter(root string, opts ...Opt) (snapshots.Snapshotter, error) {
 		options:       config.mountOptions,
 		remapIDs:      config.remapIDs,
 		slowChown:     config.slowChown,
+		quota:         newProjectQuota(root),
 	}, nil
 }
 
@@ -219,6 +285,10 @@ func (o *snapshotter) Update(ctx context.Context, info snapshots.Info, fieldpath
 			return err
 		}
 
+		if err := checkVolatileSupported(newInfo); err != nil {
+			return err
+		}
+
 		if o.upperdirLabel {
 			id, _, _, err := storage.GetInfo(ctx, newInfo.Name)
 			if err != nil {
@@ -254,6 +324,16 @@ func (o *snapshotter) Usage(ctx context.Context, key string) (_ snapshots.Usage,
 	}
 
 	if info.Kind == snapshots.KindActive {
+		// Project quota counters answer in O(1) and count sparse files
+		// correctly; fall back to walking the upperdir without them.
+		if o.quota != nil {
+			if pid, ok := projectID(id); ok {
+				if size, err := o.quota.usage(pid); err == nil {
+					return snapshots.Usage{Size: size}, nil
+				}
+			}
+		}
+
 		upperPath := o.upperPath(id)
 		du, err := fs.DiskUsage(ctx, upperPath)
 		if err != nil {
@@ -300,11 +380,20 @@ func (o *snapshotter) Mounts(ctx context.Context, key string) (_ []mount.Mount,
 func (o *snapshotter) Commit(ctx context.Context, name, key string, opts ...snapshots.Opt) error {
 	return o.ms.WithTransaction(ctx, true, func(ctx context.Context) error {
 		// grab the existing id
-		id, _, _, err := storage.GetInfo(ctx, key)
+		id, info, _, err := storage.GetInfo(ctx, key)
 		if err != nil {
 			return err
 		}
 
+		if info.Labels[volatileKey] == "true" {
+			// Volatile mounts skip sync on the upperdir, flush it before
+			// the data becomes a committed layer so torn writes are not
+			// captured.
+			if err := syncUpperDir(o.upperPath(id)); err != nil {
+				return err
+			}
+		}
+
 		usage, err := fs.DiskUsage(ctx, o.upperPath(id))
 		if err != nil {
 			return err
@@ -352,6 +441,11 @@ func (o *snapshotter) Remove(ctx context.Context, key string) (err error) {
 
 // Walk the snapshots.
 func (o *snapshotter) Walk(ctx context.Context, fn snapshots.WalkFunc, fs ...string) error {
+	if !o.upperdirLabel {
+		// Page the walk so the metadata transaction is scoped to a page
+		// instead of blocking writers for the whole walk.
+		return storage.WalkInfoPaged(ctx, o.ms, fn, fs...)
+	}
 	return o.ms.WithTransaction(ctx, false, func(ctx context.Context) error {
 		if o.upperdirLabel {
 			return storage.WalkInfo(ctx, func(ctx context.Context, info snapshots.Info) error {
@@ -468,6 +562,27 @@ func (o *snapshotter) createSnapshot(ctx context.Context, kind snapshots.Kind, k
 			return fmt.Errorf("failed to get snapshot info: %w", err)
 		}
 
+		if err := checkVolatileSupported(info); err != nil {
+			return err
+		}
+
+		if !o.remapIDs {
+			// Without idmapped mount support the mapping labels would be
+			// silently ignored and the container would see wrong file
+			// ownership, fail here instead.
+			_, uidSet := info.Labels[snapshots.LabelSnapshotUIDMapping]
+			_, gidSet := info.Labels[snapshots.LabelSnapshotGIDMapping]
+			if uidSet || gidSet {
+				return fmt.Errorf("idmapped lower layers require kernel support for idmapped overlay mounts: %w", errdefs.ErrFailedPrecondition)
+			}
+		}
+
+		if kind == snapshots.KindActive {
+			if err := o.applyQuota(ctx, td, s.ID, info); err != nil {
+				return err
+			}
+		}
+
 		var (
 			mappedUID, mappedGID     = -1, -1
 			uidmapLabel, gidmapLabel string
@@ -588,6 +703,9 @@ func (o *snapshotter) mounts(s storage.Snapshot, info snapshots.Info) []mount.Mo
 			fmt.Sprintf("workdir=%s", o.workPath(s.ID)),
 			fmt.Sprintf("upperdir=%s", o.upperPath(s.ID)),
 		)
+		if info.Labels[volatileKey] == "true" {
+			options = append(options, "volatile")
+		}
 	} else if len(s.ParentIDs) == 1 {
 		return []mount.Mount{
 			{
@@ -637,3 +755,80 @@ func supportsIndex() bool {
 	}
 	return false
 }
+
+// supportsMetacopy reports whether the running kernel supports the
+// metacopy overlay mount option, added in kernel 4.19.
+var supportsMetacopy = sync.OnceValue(func() bool {
+	ok, err := kernelversion.GreaterEqualThan(kernelversion.KernelVersion{Kernel: 4, Major: 19})
+	if err != nil {
+		log.L.WithError(err).Warn("failed to check kernel version for metacopy support")
+		return false
+	}
+	return ok
+})
+
+// volatileSupported reports whether the running kernel supports the
+// volatile overlay mount option, added in kernel 5.10.
+var volatileSupported = sync.OnceValue(func() bool {
+	ok, err := kernelversion.GreaterEqualThan(kernelversion.KernelVersion{Kernel: 5, Major: 10})
+	if err != nil {
+		log.L.WithError(err).Warn("failed to check kernel version for volatile overlay support")
+		return false
+	}
+	return ok
+})
+
+// checkVolatileSupported rejects the volatile label on kernels without
+// support for the mount option, so the failure surfaces when the snapshot
+// is created rather than when it is mounted.
+func checkVolatileSupported(info snapshots.Info) error {
+	if info.Labels[volatileKey] != "true" {
+		return nil
+	}
+	if !volatileSupported() {
+		return fmt.Errorf("volatile overlay snapshots require kernel 5.10 or newer: %w", errdefs.ErrInvalidArgument)
+	}
+	return nil
+}
+
+// syncUpperDir flushes the filesystem holding the upperdir.
+func syncUpperDir(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open upperdir for sync: %w", err)
+	}
+	defer f.Close()
+	if err := unix.Syncfs(int(f.Fd())); err != nil {
+		return fmt.Errorf("failed to sync upperdir %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyQuota puts the snapshot's upperdir into its own quota project and
+// applies the size label as a hard limit. Without project quotas on the
+// backing filesystem a requested limit is skipped with a warning, usage
+// falls back to walking the upperdir.
+func (o *snapshotter) applyQuota(ctx context.Context, td, snapshotID string, info snapshots.Info) error {
+	size, sizeSet := info.Labels[sizeKey]
+	if o.quota == nil {
+		if sizeSet {
+			log.G(ctx).WithField("size", size).Warn("snapshot size limit requires project quotas on the backing filesystem, limit not enforced")
+		}
+		return nil
+	}
+	pid, ok := projectID(snapshotID)
+	if !ok {
+		return nil
+	}
+	if err := o.quota.assign(pid, filepath.Join(td, "fs")); err != nil {
+		return err
+	}
+	if !sizeSet {
+		return nil
+	}
+	limit, err := units.RAMInBytes(size)
+	if err != nil {
+		return fmt.Errorf("failed to parse snapshot size %q: %w", size, errdefs.ErrInvalidArgument)
+	}
+	return o.quota.setLimit(pid, uint64(limit))
+}
@@ -807,6 +807,10 @@ func testOverlayView(t *testing.T, newSnapshotter testsuite.SnapshotterFunc) {
 	if !supportsIndex {
 		expectedOptions--
 	}
+	if supportsMetacopy() {
+		// metacopy=on is auto-enabled on supporting kernels
+		expectedOptions++
+	}
 	userxattr, err := overlayutils.NeedsUserXAttr(root)
 	if err != nil {
 		t.Fatal(err)
@@ -0,0 +1,174 @@
+//go:build linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package overlay
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"unsafe"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	"golang.org/x/sys/unix"
+)
+
+// sizeKey is a key of an optional label limiting how much data an active
+// snapshot's upperdir may hold, enforced through project quotas when the
+// backing filesystem has them enabled.
+const sizeKey = "containerd.io/snapshot/overlay.size"
+
+// projectIDBase offsets snapshot project ids away from ids commonly used
+// by other project quota consumers.
+const projectIDBase uint32 = 1 << 20
+
+// Project quota plumbing. The trimmed x/sys/unix build containerd vendors
+// does not wrap quotactl or the FSXATTR ioctls, so the stable kernel ABI is
+// declared here.
+const (
+	fsIOCGetXattr = 0x801c581f // FS_IOC_FSGETXATTR
+	fsIOCSetXattr = 0x401c5820 // FS_IOC_FSSETXATTR
+
+	fsXflagProjInherit = 0x00000200 // FS_XFLAG_PROJINHERIT
+
+	prjQuota    = 2 // PRJQUOTA
+	subCmdShift = 8
+	qGetQuota   = 0x800007<<subCmdShift | prjQuota
+	qSetQuota   = 0x800008<<subCmdShift | prjQuota
+
+	qifBLimits = 1 // QIF_BLIMITS
+)
+
+type fsxattr struct {
+	xflags     uint32
+	extsize    uint32
+	nextents   uint32
+	projid     uint32
+	cowextsize uint32
+	pad        [8]byte
+}
+
+type dqblk struct {
+	bHardLimit uint64 // in 1024 byte blocks
+	bSoftLimit uint64
+	curSpace   uint64 // in bytes
+	iHardLimit uint64
+	iSoftLimit uint64
+	curInodes  uint64
+	bTime      uint64
+	iTime      uint64
+	valid      uint32
+	pad        uint32
+}
+
+// projectQuota answers and enforces per-snapshot usage through project
+// quotas on the device backing the snapshotter root.
+type projectQuota struct {
+	backingDev string
+}
+
+// newProjectQuota probes whether the filesystem backing root has project
+// quotas enabled, returning nil when it does not so callers fall back to
+// walking the filetree.
+func newProjectQuota(root string) *projectQuota {
+	mnt, err := mount.Lookup(root)
+	if err != nil {
+		return nil
+	}
+	q := &projectQuota{backingDev: mnt.Source}
+	// Querying any project succeeds (possibly with ESRCH for an unused id)
+	// only when project quotas are enabled.
+	if _, err := q.usage(projectIDBase); err != nil && err != unix.ESRCH && err != unix.ENOENT {
+		return nil
+	}
+	// The filesystem must also support assigning project ids.
+	probe, err := os.MkdirTemp(root, ".quota-probe-")
+	if err != nil {
+		return nil
+	}
+	defer os.Remove(probe)
+	if err := q.assign(projectIDBase, probe); err != nil {
+		return nil
+	}
+	return q
+}
+
+func quotactl(cmd int, dev string, id uint32, addr unsafe.Pointer) error {
+	devp, err := unix.BytePtrFromString(dev)
+	if err != nil {
+		return err
+	}
+	_, _, errno := unix.Syscall6(unix.SYS_QUOTACTL, uintptr(cmd), uintptr(unsafe.Pointer(devp)), uintptr(id), uintptr(addr), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// assign puts dir (and, through inheritance, everything created below it)
+// into the project with the given id.
+func (q *projectQuota) assign(id uint32, dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var attr fsxattr
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), fsIOCGetXattr, uintptr(unsafe.Pointer(&attr))); errno != 0 {
+		return fmt.Errorf("failed to get project attributes of %s: %w", dir, errno)
+	}
+	attr.projid = id
+	attr.xflags |= fsXflagProjInherit
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), fsIOCSetXattr, uintptr(unsafe.Pointer(&attr))); errno != 0 {
+		return fmt.Errorf("failed to assign project %d to %s: %w", id, dir, errno)
+	}
+	return nil
+}
+
+// setLimit caps the project's disk usage at the given number of bytes.
+func (q *projectQuota) setLimit(id uint32, bytes uint64) error {
+	blocks := (bytes + 1023) / 1024
+	d := dqblk{
+		bHardLimit: blocks,
+		bSoftLimit: blocks,
+		valid:      qifBLimits,
+	}
+	if err := quotactl(qSetQuota, q.backingDev, id, unsafe.Pointer(&d)); err != nil {
+		return fmt.Errorf("failed to set project quota limit: %w", err)
+	}
+	return nil
+}
+
+// usage reports the bytes currently charged to the project.
+func (q *projectQuota) usage(id uint32) (int64, error) {
+	var d dqblk
+	if err := quotactl(qGetQuota, q.backingDev, id, unsafe.Pointer(&d)); err != nil {
+		return 0, err
+	}
+	return int64(d.curSpace), nil
+}
+
+// projectID maps a snapshot's storage id to its quota project id.
+func projectID(snapshotID string) (uint32, bool) {
+	sid, err := strconv.ParseUint(snapshotID, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return projectIDBase + uint32(sid), true
+}
@@ -0,0 +1,66 @@
+//go:build linux
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package overlay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/pkg/testutil"
+)
+
+// TestUsageFallsBackWithoutProjectQuotas covers the walk fallback: the
+// test filesystem has no project quotas enabled, Usage must still report
+// the upperdir's size.
+func TestUsageFallsBackWithoutProjectQuotas(t *testing.T) {
+	testutil.RequiresRoot(t)
+	ctx := context.Background()
+	root := t.TempDir()
+
+	o, err := NewSnapshotter(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer o.Close()
+
+	mounts, err := o.Prepare(ctx, "fallback", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// single layer snapshots are plain bind mounts of the upperdir
+	upper := mounts[0].Source
+	if err := os.WriteFile(filepath.Join(upper, "data"), make([]byte, 16384), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := o.Usage(ctx, "fallback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Size < 16384 {
+		t.Fatalf("expected at least 16384 bytes of usage, got %d", usage.Size)
+	}
+	if _, ok := o.(*snapshotter); !ok {
+		t.Fatal("unexpected snapshotter type")
+	}
+	var _ snapshots.Snapshotter = o
+}
@@ -270,7 +270,9 @@ func (s *wcowSnapshotter) createScratchLayer(ctx context.Context, snDir string,
 
 	if sizeInBytes != 0 {
 		if err := hcsshim.ExpandSandboxSize(s.info, filepath.Base(snDir), sizeInBytes); err != nil {
-			return fmt.Errorf("failed to expand sandbox vhdx size to %d bytes: %w", sizeInBytes, err)
+			// surface a typed error instead of a raw HRESULT string so
+			// clients can tell a bad size request apart from HCS failures
+			return fmt.Errorf("failed to expand sandbox vhdx size to %d bytes (%v): %w", sizeInBytes, err, errdefs.ErrInvalidArgument)
 		}
 	}
 	return nil
@@ -0,0 +1,105 @@
+//go:build linux || freebsd
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/core/snapshots/storage"
+	"github.com/containerd/log"
+)
+
+// destroyProgram is the ZFS channel program batching deferred destroys
+// into a single invocation and transaction group. A cleanup pass removing
+// N layers pays one zfs invocation and one txg sync instead of 2N
+// invocations each syncing its own txg, which is where the per-destroy
+// latency lives on busy pools.
+const destroyProgram = `
+args = ...
+for i, object in ipairs(args["argv"]) do
+	zfs.sync.destroy(object, true)
+end
+`
+
+// destroyBatch destroys the datasets and snapshots, deferred like
+// "destroy -d". Multiple objects go through one channel program; pools
+// whose zfs does not support channel programs fall back to sequential
+// destroys.
+func (z *snapshotter) destroyBatch(ctx context.Context, objects []string) error {
+	if len(objects) == 0 {
+		return nil
+	}
+	if len(objects) > 1 {
+		if err := z.destroyProgram(ctx, objects); err == nil {
+			return nil
+		} else {
+			log.G(ctx).WithError(err).Debug("zfs channel program destroy failed, falling back to sequential destroys")
+		}
+	}
+	for _, object := range objects {
+		if _, err := zfs(ctx, "destroy", "-d", object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (z *snapshotter) destroyProgram(ctx context.Context, objects []string) error {
+	f, err := os.CreateTemp("", "containerd-zfs-destroy-*.lua")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, werr := f.WriteString(destroyProgram)
+	f.Close()
+	if werr != nil {
+		return werr
+	}
+
+	pool, _, _ := strings.Cut(z.dataset, "/")
+	args := append([]string{"program", pool, f.Name()}, objects...)
+	_, err = zfs(ctx, args...)
+	return err
+}
+
+// RemoveBatch removes many snapshots in one metadata transaction and one
+// channel-program destroy, for cleanup passes that would otherwise issue
+// a zfs invocation per layer. It holds the same transaction semantics as
+// Remove: every key's metadata removal commits together with the
+// destroys, or none does.
+func (z *snapshotter) RemoveBatch(ctx context.Context, keys ...string) error {
+	return z.ms.WithTransaction(ctx, true, func(ctx context.Context) error {
+		var objects []string
+		for _, key := range keys {
+			id, kind, err := storage.Remove(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed to remove %q: %w", key, err)
+			}
+			if kind == snapshots.KindCommitted {
+				objects = append(objects, z.committedSnapshot(id))
+			}
+			objects = append(objects, z.activeDataset(id))
+		}
+		return z.destroyBatch(ctx, objects)
+	})
+}
@@ -0,0 +1,165 @@
+//go:build linux || freebsd
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/core/snapshots/storage"
+	"github.com/containerd/errdefs"
+)
+
+// SendReceiver is implemented by the zfs snapshotter for transferring
+// committed snapshots as zfs send streams.
+type SendReceiver interface {
+	ZfsSend(ctx context.Context, key, parent string) (io.ReadCloser, error)
+	ZfsReceive(ctx context.Context, key string, r io.Reader) error
+}
+
+var _ SendReceiver = (*snapshotter)(nil)
+
+// committedID resolves the id of a committed snapshot.
+func (z *snapshotter) committedID(ctx context.Context, key string) (string, error) {
+	var (
+		id   string
+		info snapshots.Info
+		err  error
+	)
+	if err := z.ms.WithTransaction(ctx, false, func(ctx context.Context) error {
+		id, info, _, err = storage.GetInfo(ctx, key)
+		return err
+	}); err != nil {
+		return "", err
+	}
+	if info.Kind != snapshots.KindCommitted {
+		return "", fmt.Errorf("zfs send requires a committed snapshot, %q is %s: %w", key, info.Kind, errdefs.ErrFailedPrecondition)
+	}
+	return id, nil
+}
+
+// ZfsSend streams the committed snapshot as a zfs send stream, incremental
+// against parent when one is given.
+func (z *snapshotter) ZfsSend(ctx context.Context, key, parent string) (io.ReadCloser, error) {
+	id, err := z.committedID(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"send"}
+	if parent != "" {
+		parentID, err := z.committedID(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "-i", z.committedSnapshot(parentID))
+	}
+	args = append(args, z.committedSnapshot(id))
+
+	cmd := exec.CommandContext(ctx, "zfs", args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start zfs send: %w", err)
+	}
+	return &sendStream{ReadCloser: stdout, cmd: cmd, stderr: &stderr}, nil
+}
+
+type sendStream struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *strings.Builder
+}
+
+func (s *sendStream) Close() error {
+	s.ReadCloser.Close()
+	if err := s.cmd.Wait(); err != nil {
+		return fmt.Errorf("zfs send failed: %s: %w", strings.TrimSpace(s.stderr.String()), err)
+	}
+	return nil
+}
+
+// ZfsReceive materializes a zfs send stream as a new active snapshot under
+// key. Incremental streams require their parent snapshot to already exist
+// in the pool, which zfs enforces.
+func (z *snapshotter) ZfsReceive(ctx context.Context, key string, r io.Reader) error {
+	return z.ms.WithTransaction(ctx, true, func(ctx context.Context) error {
+		s, err := storage.CreateSnapshot(ctx, snapshots.KindActive, key, "")
+		if err != nil {
+			return fmt.Errorf("failed to create snapshot record: %w", err)
+		}
+
+		target := z.activeDataset(s.ID)
+		cmd := exec.CommandContext(ctx, "zfs", "receive", "-o", "mountpoint=legacy", target)
+		cmd.Stdin = r
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("zfs receive failed: %s: %w", strings.TrimSpace(string(out)), err)
+		}
+		// receiving creates the dataset read-only relative to its origin
+		// snapshot, the active snapshot must be writable
+		if _, err := zfs(ctx, "set", "readonly=off", target); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// bookmarkLabel names the replication source a base layer is prepared
+// from: either a snapshot (dataset@snap) cloned directly, or a bookmark
+// (dataset#mark) left behind by `zfs send -b`, resolved to the local
+// snapshot sharing its guid. Bookmarks themselves cannot be cloned.
+const bookmarkLabel = "containerd.io/snapshot/zfs.bookmark"
+
+// resolveCloneSource turns the bookmark label's value into a snapshot
+// that can be cloned.
+func (z *snapshotter) resolveCloneSource(ctx context.Context, ref string) (string, error) {
+	if strings.Contains(ref, "@") {
+		return ref, nil
+	}
+	dataset, _, ok := strings.Cut(ref, "#")
+	if !ok {
+		return "", fmt.Errorf("clone source %q is neither a snapshot nor a bookmark: %w", ref, errdefs.ErrInvalidArgument)
+	}
+
+	guid, err := zfs(ctx, "get", "-H", "-o", "value", "guid", ref)
+	if err != nil {
+		return "", fmt.Errorf("bookmark %q: %w", ref, err)
+	}
+	// a bookmark preserves its snapshot's guid; find the local snapshot
+	// the replication created for it
+	out, err := zfs(ctx, "list", "-H", "-t", "snapshot", "-o", "name,guid", "-r", dataset)
+	if err != nil {
+		return "", err
+	}
+	for line := range strings.SplitSeq(out, "\n") {
+		name, g, ok := strings.Cut(line, "\t")
+		if ok && g == guid {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no local snapshot matches bookmark %q (guid %s): %w", ref, guid, errdefs.ErrNotFound)
+}
@@ -0,0 +1,284 @@
+//go:build linux || freebsd
+
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package zfs implements a snapshotter backed by a ZFS dataset. Active
+// snapshots are clones of their parent's committed snapshot, commits are
+// zfs snapshots, and the whole layer chain shares blocks through ZFS's
+// copy-on-write. The zfs command line tools drive the pool, so the
+// package works against any ZFS implementation providing them.
+package zfs
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/core/snapshots/storage"
+	"github.com/containerd/log"
+	"github.com/containerd/plugin"
+)
+
+const (
+	// snapshotSuffix is the name used for committed zfs snapshots of the
+	// active dataset.
+	snapshotSuffix = "snapshot"
+)
+
+type snapshotter struct {
+	dataset string // dataset backing the snapshotter root
+	ms      *storage.MetaStore
+}
+
+// NewSnapshotter returns a Snapshotter using zfs. The root directory must
+// be the mountpoint of a ZFS dataset, whose children hold the snapshots.
+func NewSnapshotter(root string) (snapshots.Snapshotter, error) {
+	dataset, err := datasetOf(root)
+	if err != nil {
+		return nil, fmt.Errorf("path %s is not on a zfs dataset: %w", root, plugin.ErrSkipPlugin)
+	}
+	ms, err := storage.NewMetaStore(filepath.Join(root, "metadata.db"))
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotter{
+		dataset: dataset,
+		ms:      ms,
+	}, nil
+}
+
+// zfs runs the zfs command, returning its trimmed output.
+func zfs(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "zfs", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("zfs %s failed: %s: %w", strings.Join(args, " "), strings.TrimSpace(string(out)), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// datasetOf resolves the dataset mounted at path.
+func datasetOf(path string) (string, error) {
+	out, err := exec.Command("zfs", "list", "-H", "-o", "name", path).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", strings.TrimSpace(string(out)), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (z *snapshotter) activeDataset(id string) string {
+	return z.dataset + "/" + id
+}
+
+func (z *snapshotter) committedSnapshot(id string) string {
+	return z.activeDataset(id) + "@" + snapshotSuffix
+}
+
+func (z *snapshotter) Stat(ctx context.Context, key string) (info snapshots.Info, err error) {
+	if err := z.ms.WithTransaction(ctx, false, func(ctx context.Context) error {
+		_, info, _, err = storage.GetInfo(ctx, key)
+		return err
+	}); err != nil {
+		return snapshots.Info{}, err
+	}
+	return info, nil
+}
+
+func (z *snapshotter) Update(ctx context.Context, info snapshots.Info, fieldpaths ...string) (snapshots.Info, error) {
+	var err error
+	if err := z.ms.WithTransaction(ctx, true, func(ctx context.Context) error {
+		info, err = storage.UpdateInfo(ctx, info, fieldpaths...)
+		return err
+	}); err != nil {
+		return snapshots.Info{}, err
+	}
+	return info, nil
+}
+
+func (z *snapshotter) Usage(ctx context.Context, key string) (snapshots.Usage, error) {
+	var id string
+	if err := z.ms.WithTransaction(ctx, false, func(ctx context.Context) (err error) {
+		id, _, _, err = storage.GetInfo(ctx, key)
+		return err
+	}); err != nil {
+		return snapshots.Usage{}, err
+	}
+
+	out, err := zfs(ctx, "get", "-Hp", "-o", "value", "used", z.activeDataset(id))
+	if err != nil {
+		return snapshots.Usage{}, err
+	}
+	used, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return snapshots.Usage{}, fmt.Errorf("unexpected zfs used value %q: %w", out, err)
+	}
+	return snapshots.Usage{Size: used, Inodes: -1}, nil
+}
+
+func (z *snapshotter) Prepare(ctx context.Context, key, parent string, opts ...snapshots.Opt) ([]mount.Mount, error) {
+	return z.createSnapshot(ctx, snapshots.KindActive, key, parent, opts)
+}
+
+func (z *snapshotter) View(ctx context.Context, key, parent string, opts ...snapshots.Opt) ([]mount.Mount, error) {
+	return z.createSnapshot(ctx, snapshots.KindView, key, parent, opts)
+}
+
+func (z *snapshotter) createSnapshot(ctx context.Context, kind snapshots.Kind, key, parent string, opts []snapshots.Opt) (_ []mount.Mount, err error) {
+	var (
+		s       storage.Snapshot
+		created string
+		base    snapshots.Info
+	)
+	for _, opt := range opts {
+		if err := opt(&base); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if err != nil && created != "" {
+			if _, derr := zfs(context.WithoutCancel(ctx), "destroy", created); derr != nil {
+				log.G(ctx).WithError(derr).WithField("dataset", created).Error("failed to destroy dataset after failed create")
+			}
+		}
+	}()
+
+	if err := z.ms.WithTransaction(ctx, true, func(ctx context.Context) error {
+		s, err = storage.CreateSnapshot(ctx, kind, key, parent, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to create snapshot: %w", err)
+		}
+
+		target := z.activeDataset(s.ID)
+		if len(s.ParentIDs) == 0 {
+			if ref := base.Labels[bookmarkLabel]; ref != "" {
+				// base layer replicated from elsewhere: clone the local
+				// snapshot the bookmark refers to
+				source, err := z.resolveCloneSource(ctx, ref)
+				if err != nil {
+					return err
+				}
+				args := []string{"clone", "-o", "mountpoint=legacy"}
+				if kind == snapshots.KindView {
+					args = append(args, "-o", "readonly=on")
+				}
+				if _, err := zfs(ctx, append(args, source, target)...); err != nil {
+					return err
+				}
+			} else if _, err := zfs(ctx, "create", "-o", "mountpoint=legacy", target); err != nil {
+				return err
+			}
+		} else {
+			args := []string{"clone", "-o", "mountpoint=legacy"}
+			if kind == snapshots.KindView {
+				args = append(args, "-o", "readonly=on")
+			}
+			args = append(args, z.committedSnapshot(s.ParentIDs[0]), target)
+			if _, err := zfs(ctx, args...); err != nil {
+				return err
+			}
+		}
+		created = target
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return z.mounts(s), nil
+}
+
+func (z *snapshotter) mounts(s storage.Snapshot) []mount.Mount {
+	var (
+		source  string
+		options []string
+	)
+	if len(s.ParentIDs) == 0 || s.Kind == snapshots.KindActive || s.Kind == snapshots.KindView {
+		source = z.activeDataset(s.ID)
+	} else {
+		source = z.activeDataset(s.ParentIDs[0])
+	}
+	if s.Kind == snapshots.KindView {
+		options = append(options, "ro")
+	}
+	return []mount.Mount{
+		{
+			Type:    "zfs",
+			Source:  source,
+			Options: options,
+		},
+	}
+}
+
+func (z *snapshotter) Mounts(ctx context.Context, key string) ([]mount.Mount, error) {
+	var s storage.Snapshot
+	if err := z.ms.WithTransaction(ctx, false, func(ctx context.Context) (err error) {
+		s, err = storage.GetSnapshot(ctx, key)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return z.mounts(s), nil
+}
+
+func (z *snapshotter) Commit(ctx context.Context, name, key string, opts ...snapshots.Opt) error {
+	usage, err := z.Usage(ctx, key)
+	if err != nil {
+		return err
+	}
+	return z.ms.WithTransaction(ctx, true, func(ctx context.Context) error {
+		id, err := storage.CommitActive(ctx, key, name, usage, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to commit snapshot: %w", err)
+		}
+		if _, err := zfs(ctx, "snapshot", z.committedSnapshot(id)); err != nil {
+			return err
+		}
+		// committed layers are immutable
+		_, err = zfs(ctx, "set", "readonly=on", z.activeDataset(id))
+		return err
+	})
+}
+
+func (z *snapshotter) Remove(ctx context.Context, key string) error {
+	return z.ms.WithTransaction(ctx, true, func(ctx context.Context) error {
+		id, kind, err := storage.Remove(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to remove: %w", err)
+		}
+
+		// destroys are deferred (like destroy -d) until clones are gone
+		var objects []string
+		if kind == snapshots.KindCommitted {
+			objects = append(objects, z.committedSnapshot(id))
+		}
+		objects = append(objects, z.activeDataset(id))
+		return z.destroyBatch(ctx, objects)
+	})
+}
+
+func (z *snapshotter) Walk(ctx context.Context, fn snapshots.WalkFunc, fs ...string) error {
+	return storage.WalkInfoPaged(ctx, z.ms, fn, fs...)
+}
+
+func (z *snapshotter) Close() error {
+	return z.ms.Close()
+}
+
+var _ snapshots.Snapshotter = (*snapshotter)(nil)